@@ -0,0 +1,78 @@
+package ambassador
+
+import (
+	"context"
+	"dokku-service/engine"
+	"dokku-service/service"
+	"fmt"
+	"strings"
+)
+
+// DefaultImage is the image used to proxy exposed ports to the service container
+const DefaultImage = "dokku/ambassador:0.8.2"
+
+// linkAlias is the alias the service container is linked into the ambassador as
+const linkAlias = "service"
+
+type CreateInput struct {
+	// Expose specifies the ports to expose
+	Expose service.ExposeConfig
+
+	// Image is the ambassador image to use
+	Image string
+
+	// Name is the name of the service container
+	Name string
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Create creates and starts an ambassador container publishing the exposed ports of a service
+func Create(ctx context.Context, input CreateInput) error {
+	if input.Image == "" {
+		input.Image = DefaultImage
+	}
+
+	spec := engine.ContainerSpec{
+		Image: input.Image,
+		Labels: map[string]string{
+			"dokku":                  "ambassador",
+			"com.dokku.service-name": input.ServiceName,
+			"com.dokku.service-type": input.ServiceType,
+		},
+		Links:         []string{fmt.Sprintf("%s:%s", input.Name, linkAlias)},
+		Name:          input.Name + ".ambassador",
+		RestartPolicy: "always",
+	}
+
+	for _, port := range input.Expose.Ports {
+		// the ambassador image proxies every *_PORT_*_TCP variable, which
+		// docker only injects for ports the service image declares via EXPOSE
+		spec.Ports = append(spec.Ports, engine.PortBinding{
+			ContainerPort: port.ContainerPort,
+			HostIP:        input.Expose.BindIP,
+			HostPort:      port.HostPort,
+		})
+		spec.Env = append(spec.Env, fmt.Sprintf("%s_PORT_%d_TCP=tcp://%s:%d", strings.ToUpper(linkAlias), port.ContainerPort, linkAlias, port.ContainerPort))
+	}
+
+	runtime := engine.FromContext(ctx)
+	engine.Trace(input.Trace, "container create", spec.Name, input.Image)
+	if _, err := runtime.ContainerCreate(ctx, spec); err != nil {
+		return fmt.Errorf("ambassador create for service failed: %w", err)
+	}
+
+	engine.Trace(input.Trace, "container start", spec.Name)
+	if err := runtime.ContainerStart(ctx, spec.Name); err != nil {
+		return fmt.Errorf("ambassador create for service failed: %w", err)
+	}
+
+	return nil
+}
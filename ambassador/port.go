@@ -0,0 +1,17 @@
+package ambassador
+
+import (
+	"fmt"
+	"net"
+)
+
+// FreePort returns a host port that is currently unused on the bind ip
+func FreePort(bindIP string) (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(bindIP, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
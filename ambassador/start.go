@@ -0,0 +1,20 @@
+package ambassador
+
+import (
+	"context"
+	"dokku-service/container"
+)
+
+type StartInput struct {
+	// Name is the name of the service
+	Name string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Start starts an existing ambassador container
+func Start(ctx context.Context, input StartInput) error {
+	input.Name = input.Name + ".ambassador"
+	return container.Start(ctx, container.StartInput(input))
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"dokku-service/logstreamer"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alexellis/go-execute/v2"
+)
+
+// SetConfigInput contains the input parameters for the SetConfig function
+type SetConfigInput struct {
+	// AppName is the name of the dokku app
+	AppName string
+
+	// NoRestart specifies whether to skip restarting the app
+	NoRestart bool
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+
+	// Variables are the environment variables to set on the app
+	Variables map[string]string
+}
+
+// SetConfig sets environment variables on a dokku app
+func SetConfig(ctx context.Context, input SetConfigInput) error {
+	if len(input.Variables) == 0 {
+		return nil
+	}
+
+	args := []string{"config:set"}
+	if input.NoRestart {
+		args = append(args, "--no-restart")
+	}
+	args = append(args, input.AppName)
+
+	keys := []string{}
+	for key := range input.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// exported variables usually contain credentials, so only trace the keys
+	traceArgs := append([]string{}, args...)
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("%s=%s", key, input.Variables[key]))
+		traceArgs = append(traceArgs, fmt.Sprintf("%s=***", key))
+	}
+
+	return dokku(ctx, args, traceArgs, input.Trace)
+}
+
+// UnsetConfigInput contains the input parameters for the UnsetConfig function
+type UnsetConfigInput struct {
+	// AppName is the name of the dokku app
+	AppName string
+
+	// Keys are the environment variables to unset on the app
+	Keys []string
+
+	// NoRestart specifies whether to skip restarting the app
+	NoRestart bool
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// UnsetConfig unsets environment variables on a dokku app
+func UnsetConfig(ctx context.Context, input UnsetConfigInput) error {
+	if len(input.Keys) == 0 {
+		return nil
+	}
+
+	args := []string{"config:unset"}
+	if input.NoRestart {
+		args = append(args, "--no-restart")
+	}
+	args = append(args, input.AppName)
+	args = append(args, input.Keys...)
+
+	return dokku(ctx, args, args, input.Trace)
+}
+
+func dokku(ctx context.Context, args []string, traceArgs []string, trace bool) error {
+	var mu sync.Mutex
+	cmd := execute.ExecTask{
+		Command:     "dokku",
+		Args:        args,
+		StreamStdio: false,
+		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+			Mutex:  &mu,
+			Writer: os.Stdout,
+		}),
+		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+			Mutex:  &mu,
+			Writer: os.Stderr,
+		}),
+	}
+
+	if trace {
+		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(traceArgs, " "))
+	}
+	res, err := cmd.Execute(ctx)
+	if err != nil {
+		return fmt.Errorf("dokku %s failed: %w", args[0], err)
+	}
+
+	if res.ExitCode != 0 {
+		// todo: return exit code
+		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
+	}
+
+	return nil
+}
@@ -4,4 +4,5 @@ type Argument struct {
 	Key      string
 	Value    string
 	Override bool
+	Secret   bool
 }
@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ConfigInput contains the input parameters for the Config function
+type ConfigInput struct {
+	// ServiceRoot is the root directory for the service
+	ServiceRoot string
+}
+
+// ConfigOutput contains the backup configuration for a service
+type ConfigOutput struct {
+	// Encryption contains the settings used to encrypt backups
+	Encryption EncryptionConfig `json:"encryption"`
+
+	// Retention is the policy applied when pruning backups
+	Retention Retention `json:"retention"`
+
+	// S3 contains the credentials used for s3 destinations
+	S3 S3Config `json:"s3"`
+
+	// Schedule contains the settings for scheduled backups
+	Schedule ScheduleConfig `json:"schedule"`
+}
+
+// EncryptionConfig contains the settings used to encrypt backups
+type EncryptionConfig struct {
+	// Recipients are the age public keys backups are encrypted to
+	Recipients []string `json:"recipients"`
+}
+
+// ScheduleConfig contains the settings for scheduled backups
+type ScheduleConfig struct {
+	// Destination is the destination scheduled backups are uploaded to
+	Destination string `json:"destination"`
+
+	// Directory is the directory the schedule entry was written to
+	Directory string `json:"directory"`
+
+	// Expression is the cron expression backups are taken on
+	Expression string `json:"expression"`
+
+	// Scheduler is the scheduler the schedule entry was written for
+	Scheduler string `json:"scheduler"`
+}
+
+// S3Config contains the credentials used for s3 destinations
+type S3Config struct {
+	// AccessKeyID is the access key id for the object store
+	AccessKeyID string `json:"access_key_id"`
+
+	// EndpointURL is the url of an S3-compatible object store, defaulting to aws s3
+	EndpointURL string `json:"endpoint_url"`
+
+	// Region is the region the bucket lives in
+	Region string `json:"region"`
+
+	// SecretAccessKey is the secret access key for the object store
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// Config returns the backup configuration for a service
+func Config(ctx context.Context, input ConfigInput) (ConfigOutput, error) {
+	b, err := os.ReadFile(configPath(input.ServiceRoot))
+	if errors.Is(err, os.ErrNotExist) {
+		return ConfigOutput{}, nil
+	}
+	if err != nil {
+		return ConfigOutput{}, fmt.Errorf("failed to read backup config: %s", err.Error())
+	}
+
+	config := ConfigOutput{}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return ConfigOutput{}, fmt.Errorf("failed to unmarshal backup config: %s", err.Error())
+	}
+
+	return config, nil
+}
+
+// WriteConfigInput contains the input parameters for the WriteConfig function
+type WriteConfigInput struct {
+	// ConfigOutput is the backup config to persist
+	ConfigOutput ConfigOutput
+
+	// ServiceRoot is the root directory for the service
+	ServiceRoot string
+}
+
+// WriteConfig persists the backup configuration for a service
+//
+// The file holds credentials, so it is only readable by the current user
+func WriteConfig(ctx context.Context, input WriteConfigInput) error {
+	data, err := json.MarshalIndent(input.ConfigOutput, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup config: %s", err.Error())
+	}
+
+	if err := os.WriteFile(configPath(input.ServiceRoot), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write backup config: %s", err.Error())
+	}
+
+	return nil
+}
+
+// configPath returns the path to the backup config for a service
+func configPath(serviceRoot string) string {
+	return fmt.Sprintf("%s/backup.json", serviceRoot)
+}
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+
+	"dokku-service/container"
+	"dokku-service/service"
+)
+
+// CreateInput contains the input parameters for the Create function
+type CreateInput struct {
+	// ConfigOutput is the config of the service to back up
+	ConfigOutput service.ConfigOutput
+
+	// ContainerName is the name of the service container
+	ContainerName string
+
+	// Name is the name to store the backup under
+	Name string
+
+	// Recipients are the age public keys to encrypt the backup to, if any
+	Recipients []string
+
+	// Store is the store to upload the backup to
+	Store BackupStore
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Create runs the export command for a service and uploads the compressed output to a store
+//
+// When recipients are specified, the compressed output is encrypted before upload
+func Create(ctx context.Context, input CreateInput) error {
+	if len(input.Recipients) > 0 {
+		if _, err := ParseRecipients(ParseRecipientsInput{
+			Recipients: input.Recipients,
+		}); err != nil {
+			return err
+		}
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(export(ctx, input, writer))
+	}()
+
+	if err := input.Store.Put(ctx, input.Name, reader); err != nil {
+		// unblock the export if the upload failed part way through
+		reader.CloseWithError(err)
+		return err
+	}
+
+	return nil
+}
+
+// export writes the compressed and optionally encrypted export of a service to a writer
+func export(ctx context.Context, input CreateInput, writer io.Writer) error {
+	var encryptWriter io.WriteCloser
+	if len(input.Recipients) > 0 {
+		var err error
+		encryptWriter, err = Encrypt(EncryptInput{
+			Recipients: input.Recipients,
+			Writer:     writer,
+		})
+		if err != nil {
+			return err
+		}
+		writer = encryptWriter
+	}
+
+	gzipWriter := gzip.NewWriter(writer)
+	if err := container.Execute(ctx, container.ExecuteInput{
+		Name:         input.ContainerName,
+		CommandName:  "export",
+		ConfigOutput: input.ConfigOutput,
+		StdOutWriter: gzipWriter,
+		Trace:        input.Trace,
+	}); err != nil {
+		return err
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	if encryptWriter != nil {
+		return encryptWriter.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// EncryptedExtension is appended to the name of encrypted backups
+const EncryptedExtension = ".age"
+
+// ParseRecipientsInput contains the input parameters for the ParseRecipients function
+type ParseRecipientsInput struct {
+	// Recipients are the age public keys to parse
+	Recipients []string
+}
+
+// ParseRecipients validates and parses a list of age public keys
+func ParseRecipients(input ParseRecipientsInput) ([]age.Recipient, error) {
+	if len(input.Recipients) == 0 {
+		return []age.Recipient{}, fmt.Errorf("no recipients specified")
+	}
+
+	recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(input.Recipients, "\n")))
+	if err != nil {
+		return []age.Recipient{}, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// EncryptInput contains the input parameters for the Encrypt function
+type EncryptInput struct {
+	// Recipients are the age public keys to encrypt to
+	Recipients []string
+
+	// Writer is the writer encrypted data is written to
+	Writer io.Writer
+}
+
+// Encrypt returns a writer that encrypts data to a set of recipients
+//
+// The returned writer must be closed to flush the final chunk
+func Encrypt(input EncryptInput) (io.WriteCloser, error) {
+	recipients, err := ParseRecipients(ParseRecipientsInput{
+		Recipients: input.Recipients,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := age.Encrypt(input.Writer, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt stream: %w", err)
+	}
+
+	return writer, nil
+}
+
+// DecryptInput contains the input parameters for the Decrypt function
+type DecryptInput struct {
+	// IdentityFile is the path to a file containing age private keys
+	IdentityFile string
+
+	// Reader is the reader encrypted data is read from
+	Reader io.Reader
+}
+
+// Decrypt returns a reader that decrypts data with the identities in an identity file
+func Decrypt(input DecryptInput) (io.Reader, error) {
+	if input.IdentityFile == "" {
+		return nil, fmt.Errorf("no identity file specified")
+	}
+
+	file, err := os.Open(input.IdentityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file: %w", err)
+	}
+
+	reader, err := age.Decrypt(input.Reader, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt stream: %w", err)
+	}
+
+	return reader, nil
+}
@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalStore stores backups in a directory on the local filesystem
+type LocalStore struct {
+	// Directory is the directory backups are written to
+	Directory string
+}
+
+// Delete removes a backup from the directory
+func (s LocalStore) Delete(ctx context.Context, name string) error {
+	if err := os.Remove(filepath.Join(s.Directory, name)); err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens a backup in the directory for reading
+func (s LocalStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.Directory, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	return file, nil
+}
+
+// List returns the backups in the directory whose names start with prefix
+func (s LocalStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	dirEntries, err := os.ReadDir(s.Directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Object{}, nil
+	}
+	if err != nil {
+		return []Object{}, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	objects := []Object{}
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasPrefix(dirEntry.Name(), prefix) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return []Object{}, fmt.Errorf("failed to stat backup: %w", err)
+		}
+
+		objects = append(objects, Object{
+			ModTime: info.ModTime(),
+			Name:    dirEntry.Name(),
+			Size:    info.Size(),
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	return objects, nil
+}
+
+// Put writes a backup to the directory
+//
+// The backup is written to a temporary file first so that a failed
+// upload never leaves a truncated backup behind
+func (s LocalStore) Put(ctx context.Context, name string, reader io.Reader) error {
+	if err := os.MkdirAll(s.Directory, 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(s.Directory, ".tmp-"+name)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), filepath.Join(s.Directory, name)); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return nil
+}
+
+// String returns the directory backups are written to
+func (s LocalStore) String() string {
+	return s.Directory
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timestampFormat is the format of the timestamp embedded in backup names
+const timestampFormat = "2006-01-02-15-04-05"
+
+// NameInput contains the input parameters for the Name function
+type NameInput struct {
+	// Extension is the file extension for the backup
+	Extension string
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+
+	// Time is the time the backup was taken
+	Time time.Time
+}
+
+// Name returns the name of a backup taken at a given time
+func Name(input NameInput) string {
+	return fmt.Sprintf("%s%s%s", NamePrefix(NamePrefixInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.ServiceType,
+	}), input.Time.UTC().Format(timestampFormat), input.Extension)
+}
+
+// NamePrefixInput contains the input parameters for the NamePrefix function
+type NamePrefixInput struct {
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// NamePrefix returns the prefix shared by all backups of a service
+func NamePrefix(input NamePrefixInput) string {
+	return fmt.Sprintf("%s-%s-", input.ServiceType, input.ServiceName)
+}
+
+// ParseNameInput contains the input parameters for the ParseName function
+type ParseNameInput struct {
+	// Name is the name of the backup
+	Name string
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// ParseName returns the time a backup of a service was taken
+//
+// Names that do not belong to the service, such as backups of another service
+// whose name shares the same prefix, are reported as not matching
+func ParseName(input ParseNameInput) (time.Time, bool) {
+	prefix := NamePrefix(NamePrefixInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.ServiceType,
+	})
+	if !strings.HasPrefix(input.Name, prefix) {
+		return time.Time{}, false
+	}
+
+	remainder := strings.TrimPrefix(input.Name, prefix)
+	if len(remainder) < len(timestampFormat) {
+		return time.Time{}, false
+	}
+
+	extension := remainder[len(timestampFormat):]
+	if extension != "" && !strings.HasPrefix(extension, ".") {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(timestampFormat, remainder[:len(timestampFormat)])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
@@ -0,0 +1,103 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultS3Endpoint is the endpoint used when no endpoint url is configured
+const defaultS3Endpoint = "s3.amazonaws.com"
+
+// NewStoreInput contains the input parameters for the NewStore function
+type NewStoreInput struct {
+	// ConfigOutput is the backup configuration for the service
+	ConfigOutput ConfigOutput
+
+	// Destination is the location to store backups in
+	//
+	// Supported formats are s3://bucket/prefix, file:///path and plain paths
+	Destination string
+}
+
+// NewStore returns the store for a backup destination
+func NewStore(ctx context.Context, input NewStoreInput) (BackupStore, error) {
+	if input.Destination == "" {
+		return nil, fmt.Errorf("no backup destination specified")
+	}
+
+	if !strings.Contains(input.Destination, "://") {
+		return newLocalStore(input.Destination)
+	}
+
+	destination, err := url.Parse(input.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup destination: %w", err)
+	}
+
+	switch destination.Scheme {
+	case "file":
+		return newLocalStore(destination.Path)
+	case "s3":
+		return newS3Store(destination, input.ConfigOutput.S3)
+	}
+
+	return nil, fmt.Errorf("unsupported backup destination scheme: %s", destination.Scheme)
+}
+
+// newLocalStore returns a store for a local directory
+func newLocalStore(directory string) (BackupStore, error) {
+	if directory == "" {
+		return nil, fmt.Errorf("no backup directory specified")
+	}
+
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup directory: %w", err)
+	}
+
+	return LocalStore{Directory: directory}, nil
+}
+
+// newS3Store returns a store for an s3 bucket
+func newS3Store(destination *url.URL, config S3Config) (BackupStore, error) {
+	if destination.Host == "" {
+		return nil, fmt.Errorf("no bucket specified in backup destination")
+	}
+
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("no backup credentials configured, run service-backup-auth first")
+	}
+
+	endpoint := defaultS3Endpoint
+	secure := true
+	if config.EndpointURL != "" {
+		endpointURL, err := url.Parse(config.EndpointURL)
+		if err != nil || endpointURL.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint url: %s", config.EndpointURL)
+		}
+
+		endpoint = endpointURL.Host
+		secure = endpointURL.Scheme != "http"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Region: config.Region,
+		Secure: secure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return S3Store{
+		Bucket: destination.Host,
+		Client: client,
+		Prefix: strings.Trim(destination.Path, "/"),
+	}, nil
+}
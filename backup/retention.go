@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Retention is a policy describing which backups to keep
+//
+// A backup is kept if any rule selects it. Each periodic rule keeps the
+// newest backup from each of the most recent periods that have a backup
+type Retention struct {
+	// KeepDaily is the number of days to keep the newest backup for
+	KeepDaily int `json:"keep_daily"`
+
+	// KeepLast is the number of most recent backups to keep
+	KeepLast int `json:"keep_last"`
+
+	// KeepMonthly is the number of months to keep the newest backup for
+	KeepMonthly int `json:"keep_monthly"`
+
+	// KeepWeekly is the number of weeks to keep the newest backup for
+	KeepWeekly int `json:"keep_weekly"`
+}
+
+// Empty returns whether the policy keeps nothing
+func (r Retention) Empty() bool {
+	return r.KeepDaily <= 0 && r.KeepLast <= 0 && r.KeepMonthly <= 0 && r.KeepWeekly <= 0
+}
+
+// String returns a human readable description of the policy
+func (r Retention) String() string {
+	return fmt.Sprintf("last=%d daily=%d weekly=%d monthly=%d", r.KeepLast, r.KeepDaily, r.KeepWeekly, r.KeepMonthly)
+}
+
+// ApplyRetentionInput contains the input parameters for the ApplyRetention function
+type ApplyRetentionInput struct {
+	// Objects are the backups in the store
+	Objects []Object
+
+	// Retention is the policy to apply
+	Retention Retention
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// ApplyRetentionOutput contains the output parameters for the ApplyRetention function
+type ApplyRetentionOutput struct {
+	// Keep are the backups selected by the policy, newest first
+	Keep []Object
+
+	// Remove are the backups not selected by the policy, newest first
+	Remove []Object
+}
+
+// ApplyRetention splits the backups of a service into those to keep and those to remove
+//
+// Objects that are not backups of the service are ignored
+func ApplyRetention(input ApplyRetentionInput) (ApplyRetentionOutput, error) {
+	if input.Retention.Empty() {
+		return ApplyRetentionOutput{}, fmt.Errorf("retention policy does not keep any backups")
+	}
+
+	type backupTime struct {
+		object Object
+		time   time.Time
+	}
+
+	backups := []backupTime{}
+	for _, object := range input.Objects {
+		t, ok := ParseName(ParseNameInput{
+			Name:        object.Name,
+			ServiceName: input.ServiceName,
+			ServiceType: input.ServiceType,
+		})
+		if ok {
+			backups = append(backups, backupTime{object: object, time: t})
+		}
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	keep := map[int]bool{}
+	for i := 0; i < len(backups) && i < input.Retention.KeepLast; i++ {
+		keep[i] = true
+	}
+
+	periods := []struct {
+		count  int
+		period func(time.Time) string
+	}{
+		{input.Retention.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{input.Retention.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{input.Retention.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, p := range periods {
+		seen := map[string]bool{}
+		for i, b := range backups {
+			if len(seen) >= p.count {
+				break
+			}
+
+			period := p.period(b.time)
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[i] = true
+		}
+	}
+
+	output := ApplyRetentionOutput{
+		Keep:   []Object{},
+		Remove: []Object{},
+	}
+	for i, b := range backups {
+		if keep[i] {
+			output.Keep = append(output.Keep, b.object)
+		} else {
+			output.Remove = append(output.Remove, b.object)
+		}
+	}
+
+	return output, nil
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// backupNames returns the names of backups of the lollipop postgres service taken at each time
+func backupNames(t *testing.T, timestamps ...string) []string {
+	t.Helper()
+	names := []string{}
+	for _, timestamp := range timestamps {
+		backupTime, err := time.Parse(time.DateTime, timestamp)
+		if err != nil {
+			t.Fatalf("invalid timestamp %s: %s", timestamp, err)
+		}
+
+		names = append(names, Name(NameInput{
+			Extension:   ".tgz",
+			ServiceName: "lollipop",
+			ServiceType: "postgres",
+			Time:        backupTime,
+		}))
+	}
+
+	return names
+}
+
+func TestApplyRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		backups   []string
+		extra     []string
+		retention Retention
+		keep      []string
+		remove    []string
+	}{
+		{
+			name:      "keeps the most recent backups",
+			backups:   []string{"2024-01-01 00:00:00", "2024-01-03 00:00:00", "2024-01-02 00:00:00"},
+			retention: Retention{KeepLast: 2},
+			keep:      []string{"2024-01-03 00:00:00", "2024-01-02 00:00:00"},
+			remove:    []string{"2024-01-01 00:00:00"},
+		},
+		{
+			name:      "keeps every backup when there are fewer than the last count",
+			backups:   []string{"2024-01-01 00:00:00", "2024-01-02 00:00:00"},
+			retention: Retention{KeepLast: 5},
+			keep:      []string{"2024-01-02 00:00:00", "2024-01-01 00:00:00"},
+			remove:    []string{},
+		},
+		{
+			name:      "keeps the newest backup of each day",
+			backups:   []string{"2024-01-01 01:00:00", "2024-01-01 23:00:00", "2024-01-02 00:30:00", "2024-01-02 12:00:00", "2024-01-03 12:00:00"},
+			retention: Retention{KeepDaily: 2},
+			keep:      []string{"2024-01-03 12:00:00", "2024-01-02 12:00:00"},
+			remove:    []string{"2024-01-02 00:30:00", "2024-01-01 23:00:00", "2024-01-01 01:00:00"},
+		},
+		{
+			name:      "splits days at midnight",
+			backups:   []string{"2024-01-01 23:59:59", "2024-01-02 00:00:00"},
+			retention: Retention{KeepDaily: 2},
+			keep:      []string{"2024-01-02 00:00:00", "2024-01-01 23:59:59"},
+			remove:    []string{},
+		},
+		{
+			name:      "counts days with backups rather than calendar days",
+			backups:   []string{"2024-01-01 00:00:00", "2024-01-10 00:00:00", "2024-01-20 00:00:00"},
+			retention: Retention{KeepDaily: 2},
+			keep:      []string{"2024-01-20 00:00:00", "2024-01-10 00:00:00"},
+			remove:    []string{"2024-01-01 00:00:00"},
+		},
+		{
+			name:      "keeps the newest backup of each week, starting weeks on monday",
+			backups:   []string{"2024-01-01 00:00:00", "2024-01-07 23:59:59", "2024-01-08 00:00:00", "2024-01-14 12:00:00"},
+			retention: Retention{KeepWeekly: 2},
+			keep:      []string{"2024-01-14 12:00:00", "2024-01-07 23:59:59"},
+			remove:    []string{"2024-01-08 00:00:00", "2024-01-01 00:00:00"},
+		},
+		{
+			name:      "uses iso weeks across the year boundary",
+			backups:   []string{"2024-12-29 12:00:00", "2024-12-30 12:00:00", "2025-01-05 12:00:00"},
+			retention: Retention{KeepWeekly: 2},
+			keep:      []string{"2025-01-05 12:00:00", "2024-12-29 12:00:00"},
+			remove:    []string{"2024-12-30 12:00:00"},
+		},
+		{
+			name:      "keeps the newest backup of each month",
+			backups:   []string{"2024-01-15 00:00:00", "2024-01-31 23:59:59", "2024-02-01 00:00:00", "2024-02-29 12:00:00", "2024-03-01 00:00:00"},
+			retention: Retention{KeepMonthly: 2},
+			keep:      []string{"2024-03-01 00:00:00", "2024-02-29 12:00:00"},
+			remove:    []string{"2024-02-01 00:00:00", "2024-01-31 23:59:59", "2024-01-15 00:00:00"},
+		},
+		{
+			name:      "splits months across the year boundary",
+			backups:   []string{"2023-12-31 23:59:59", "2024-01-01 00:00:00"},
+			retention: Retention{KeepMonthly: 3},
+			keep:      []string{"2024-01-01 00:00:00", "2023-12-31 23:59:59"},
+			remove:    []string{},
+		},
+		{
+			name:      "keeps backups selected by any rule",
+			backups:   []string{"2024-01-31 12:00:00", "2024-02-27 12:00:00", "2024-02-28 06:00:00", "2024-02-28 12:00:00", "2024-02-29 06:00:00", "2024-02-29 12:00:00"},
+			retention: Retention{KeepDaily: 2, KeepLast: 1, KeepMonthly: 2},
+			keep:      []string{"2024-02-29 12:00:00", "2024-02-28 12:00:00", "2024-01-31 12:00:00"},
+			remove:    []string{"2024-02-29 06:00:00", "2024-02-28 06:00:00", "2024-02-27 12:00:00"},
+		},
+		{
+			name:    "ignores objects that are not backups of the service",
+			backups: []string{"2024-01-01 00:00:00", "2024-01-02 00:00:00"},
+			extra: []string{
+				"postgres-gumdrop-2024-01-03-00-00-00.tgz",
+				"postgres-lollipop-old-2024-01-03-00-00-00.tgz",
+				"postgres-lollipop-2024-01-03-00-00-00x",
+				"README",
+			},
+			retention: Retention{KeepLast: 1},
+			keep:      []string{"2024-01-02 00:00:00"},
+			remove:    []string{"2024-01-01 00:00:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []Object{}
+			for _, name := range append(backupNames(t, tt.backups...), tt.extra...) {
+				objects = append(objects, Object{Name: name})
+			}
+
+			output, err := ApplyRetention(ApplyRetentionInput{
+				Objects:     objects,
+				Retention:   tt.retention,
+				ServiceName: "lollipop",
+				ServiceType: "postgres",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			keep, remove := []string{}, []string{}
+			for _, object := range output.Keep {
+				keep = append(keep, object.Name)
+			}
+			for _, object := range output.Remove {
+				remove = append(remove, object.Name)
+			}
+
+			if expected := backupNames(t, tt.keep...); !reflect.DeepEqual(keep, expected) {
+				t.Errorf("expected to keep %v, got %v", expected, keep)
+			}
+			if expected := backupNames(t, tt.remove...); !reflect.DeepEqual(remove, expected) {
+				t.Errorf("expected to remove %v, got %v", expected, remove)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionRequiresAPolicy(t *testing.T) {
+	_, err := ApplyRetention(ApplyRetentionInput{
+		Objects:     []Object{{Name: backupNames(t, "2024-01-01 00:00:00")[0]}},
+		ServiceName: "lollipop",
+		ServiceType: "postgres",
+	})
+	if err == nil {
+		t.Errorf("expected an empty policy to be rejected")
+	}
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		backup   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "parses a backup with an extension",
+			backup:   "postgres-lollipop-2024-02-29-13-14-15.tgz.age",
+			expected: "2024-02-29 13:14:15",
+			ok:       true,
+		},
+		{
+			name:     "parses a backup without an extension",
+			backup:   "postgres-lollipop-2024-02-29-13-14-15",
+			expected: "2024-02-29 13:14:15",
+			ok:       true,
+		},
+		{
+			name:   "rejects a backup of another service",
+			backup: "postgres-gumdrop-2024-02-29-13-14-15.tgz",
+		},
+		{
+			name:   "rejects a backup of a service sharing the name prefix",
+			backup: "postgres-lollipop-old-2024-02-29-13-14-15.tgz",
+		},
+		{
+			name:   "rejects a backup of another service type",
+			backup: "redis-lollipop-2024-02-29-13-14-15.tgz",
+		},
+		{
+			name:   "rejects a timestamp followed by something other than an extension",
+			backup: "postgres-lollipop-2024-02-29-13-14-15x",
+		},
+		{
+			name:   "rejects an invalid timestamp",
+			backup: "postgres-lollipop-2023-02-29-13-14-15.tgz",
+		},
+		{
+			name:   "rejects a truncated timestamp",
+			backup: "postgres-lollipop-2024-02-29.tgz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, ok := ParseName(ParseNameInput{
+				Name:        tt.backup,
+				ServiceName: "lollipop",
+				ServiceType: "postgres",
+			})
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %v, got %v", tt.ok, ok)
+			}
+			if tt.ok && parsed.Format(time.DateTime) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, parsed.Format(time.DateTime))
+			}
+		})
+	}
+}
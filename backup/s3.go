@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// s3PartSize is the size of each part of a multipart upload
+//
+// Backups are streamed so their size is unknown up front, and minio-go
+// will otherwise buffer parts sized for the maximum object size
+const s3PartSize = 16 * 1024 * 1024
+
+// S3Store stores backups in a bucket on an S3-compatible object store
+type S3Store struct {
+	// Bucket is the name of the bucket
+	Bucket string
+
+	// Client is the client used to talk to the object store
+	Client *minio.Client
+
+	// Prefix is the key prefix backups are stored under
+	Prefix string
+}
+
+// Delete removes a backup from the bucket
+func (s S3Store) Delete(ctx context.Context, name string) error {
+	if err := s.Client.RemoveObject(ctx, s.Bucket, s.key(name), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens a backup in the bucket for reading
+func (s S3Store) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	object, err := s.Client.GetObject(ctx, s.Bucket, s.key(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch backup: %w", err)
+	}
+
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to fetch backup: %w", err)
+	}
+
+	return object, nil
+}
+
+// List returns the backups in the bucket whose names start with prefix
+func (s S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+	for object := range s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{
+		Prefix: s.key(prefix),
+	}) {
+		if object.Err != nil {
+			return []Object{}, fmt.Errorf("failed to list backups: %w", object.Err)
+		}
+
+		name := strings.TrimPrefix(object.Key, s.key(""))
+		if name == "" || strings.Contains(name, "/") {
+			continue
+		}
+
+		objects = append(objects, Object{
+			ModTime: object.LastModified,
+			Name:    name,
+			Size:    object.Size,
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	return objects, nil
+}
+
+// Put uploads a backup to the bucket
+func (s S3Store) Put(ctx context.Context, name string, reader io.Reader) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, s.key(name), reader, -1, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		PartSize:    s3PartSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload backup: %w", err)
+	}
+
+	return nil
+}
+
+// String returns the s3 url backups are uploaded to
+func (s S3Store) String() string {
+	return fmt.Sprintf("s3://%s", path.Join(s.Bucket, s.Prefix))
+}
+
+// key returns the object key for a backup name
+func (s S3Store) key(name string) string {
+	if s.Prefix == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(s.Prefix, "/") + "/" + name
+}
@@ -0,0 +1,289 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3Stub is an in-process stand-in for an S3-compatible object store,
+// supporting the requests made by S3Store against a single bucket
+type s3Stub struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+}
+
+func newS3Stub(bucket string) *s3Stub {
+	return &s3Stub{
+		bucket:  bucket,
+		objects: map[string][]byte{},
+		uploads: map[string]map[int][]byte{},
+	}
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		s.error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && query.Has("location"):
+		s.xml(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+			Value   string   `xml:",chardata"`
+		}{Value: "us-east-1"})
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, query.Get("prefix"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[uploadID] = map[int][]byte{}
+		s.xml(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: uploadID})
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := s.uploads[query.Get("uploadId")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+
+		body, err := readS3Body(r)
+		if err != nil {
+			s.error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[partNumber] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, partNumber))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, ok := s.uploads[query.Get("uploadId")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+
+		partNumbers := []int{}
+		for partNumber := range parts {
+			partNumbers = append(partNumbers, partNumber)
+		}
+		sort.Ints(partNumbers)
+
+		object := []byte{}
+		for _, partNumber := range partNumbers {
+			object = append(object, parts[partNumber]...)
+		}
+		s.objects[key] = object
+		delete(s.uploads, query.Get("uploadId"))
+
+		s.xml(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: `"object"`})
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := s.objects[key]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		w.Header().Set("ETag", `"object"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(object)
+		}
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// list responds with the objects whose keys start with prefix, as a ListObjectsV2 result
+func (s *s3Stub) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+
+	contents := []content{}
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			contents = append(contents, content{
+				Key:          key,
+				LastModified: time.Now().UTC().Format(time.RFC3339),
+				ETag:         `"object"`,
+				Size:         len(object),
+			})
+		}
+	}
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].Key < contents[j].Key
+	})
+
+	s.xml(w, struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: s.bucket, Prefix: prefix, KeyCount: len(contents), MaxKeys: 1000, Contents: contents})
+}
+
+func (s *s3Stub) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+	}{Code: code})
+}
+
+func (s *s3Stub) xml(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(v)
+}
+
+// readS3Body reads a request body, decoding the aws-chunked encoding used by streaming signatures
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	body := bytes.Buffer{}
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return body.Bytes(), nil
+		}
+
+		if _, err := io.CopyN(&body, reader, n); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	stub := newS3Stub("backups")
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	ctx := context.Background()
+	store, err := NewStore(ctx, NewStoreInput{
+		ConfigOutput: ConfigOutput{
+			S3: S3Config{
+				AccessKeyID:     "access",
+				EndpointURL:     server.URL,
+				Region:          "us-east-1",
+				SecretAccessKey: "secret",
+			},
+		},
+		Destination: "s3://backups/dokku/",
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+	if store.String() != "s3://backups/dokku" {
+		t.Errorf("expected store location s3://backups/dokku, got %s", store.String())
+	}
+
+	backups := map[string]string{
+		"postgres-lollipop-2024-01-02-03-04-05.tgz": "second",
+		"postgres-lollipop-2024-01-01-03-04-05.tgz": "first",
+		"postgres-gumdrop-2024-01-01-03-04-05.tgz":  "other",
+	}
+	for name, contents := range backups {
+		if err := store.Put(ctx, name, strings.NewReader(contents)); err != nil {
+			t.Fatalf("failed to upload %s: %s", name, err)
+		}
+	}
+	if string(stub.objects["dokku/postgres-lollipop-2024-01-01-03-04-05.tgz"]) != "first" {
+		t.Errorf("expected backup to be uploaded under the prefix, got %v", stub.objects)
+	}
+	stub.objects["dokku/nested/postgres-lollipop-2024-01-03-03-04-05.tgz"] = []byte("nested")
+
+	objects, err := store.List(ctx, "postgres-lollipop-")
+	if err != nil {
+		t.Fatalf("failed to list backups: %s", err)
+	}
+	if len(objects) != 2 || objects[0].Name != "postgres-lollipop-2024-01-01-03-04-05.tgz" || objects[1].Name != "postgres-lollipop-2024-01-02-03-04-05.tgz" {
+		t.Fatalf("expected the two lollipop backups in order, got %+v", objects)
+	}
+	if objects[1].Size != int64(len("second")) {
+		t.Errorf("expected size %d, got %d", len("second"), objects[1].Size)
+	}
+
+	reader, err := store.Get(ctx, "postgres-lollipop-2024-01-02-03-04-05.tgz")
+	if err != nil {
+		t.Fatalf("failed to fetch backup: %s", err)
+	}
+	contents, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(contents) != "second" {
+		t.Errorf("expected backup contents second, got %q (%v)", contents, err)
+	}
+
+	if err := store.Delete(ctx, "postgres-lollipop-2024-01-01-03-04-05.tgz"); err != nil {
+		t.Fatalf("failed to delete backup: %s", err)
+	}
+	objects, err = store.List(ctx, "postgres-lollipop-")
+	if err != nil {
+		t.Fatalf("failed to list backups: %s", err)
+	}
+	if len(objects) != 1 || objects[0].Name != "postgres-lollipop-2024-01-02-03-04-05.tgz" {
+		t.Errorf("expected only the second backup to remain, got %+v", objects)
+	}
+
+	if _, err := store.Get(ctx, "postgres-lollipop-2024-01-01-03-04-05.tgz"); err == nil {
+		t.Errorf("expected fetching a deleted backup to fail")
+	}
+	if len(stub.uploads) != 0 {
+		t.Errorf("expected multipart uploads to be completed, got %d pending", len(stub.uploads))
+	}
+}
@@ -0,0 +1,37 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BackupStore is a destination that service backups are uploaded to
+type BackupStore interface {
+	// Delete removes a backup from the store
+	Delete(ctx context.Context, name string) error
+
+	// Get opens a backup for reading
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// List returns the backups in the store whose names start with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// Put uploads a backup to the store
+	Put(ctx context.Context, name string, reader io.Reader) error
+
+	// String returns a human readable location of the store
+	String() string
+}
+
+// Object represents a backup held by a store
+type Object struct {
+	// ModTime is the time the backup was last modified
+	ModTime time.Time
+
+	// Name is the name of the backup relative to the store
+	Name string
+
+	// Size is the size of the backup in bytes
+	Size int64
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/service"
+)
+
+type AppLinksCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *AppLinksCommand) Name() string {
+	return "app-links"
+}
+
+func (c *AppLinksCommand) Synopsis() string {
+	return "app-links command"
+}
+
+func (c *AppLinksCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *AppLinksCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"list all services linked to an app": fmt.Sprintf("%s %s playground", appName, c.Name()),
+	}
+}
+
+func (c *AppLinksCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "app",
+		Description: "the app to list links for",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *AppLinksCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AppLinksCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *AppLinksCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	return f
+}
+
+func (c *AppLinksCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *AppLinksCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	appName := arguments["app"].StringValue()
+	linkedServices, err := service.LinkedServices(c.Context, service.LinkedServicesInput{
+		AppName:  appName,
+		DataRoot: c.dataRoot,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch app links: %s", err.Error()))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("%s links", appName))
+	for _, linkedService := range linkedServices {
+		c.Ui.Output(fmt.Sprintf("%s %s", linkedService.ServiceType, linkedService.Name))
+	}
+
+	return 0
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppLinksCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		app    string
+		stdout []string
+		absent []string
+	}{
+		{
+			name:   "lists the services linked to an app",
+			app:    "playground",
+			stdout: []string{"postgres lollipop", "redis gumdrop"},
+		},
+		{
+			name:   "does not list services linked to other apps",
+			app:    "sandbox",
+			absent: []string{"lollipop", "gumdrop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.create("redis", "gumdrop")
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "redis", "gumdrop", "playground")
+
+			e.run(&AppLinksCommand{Meta: e.meta(), Context: e.ctx}, 0, tt.app)
+			for _, expected := range tt.stdout {
+				if !strings.Contains(e.stdout.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+				}
+			}
+			for _, unexpected := range tt.absent {
+				if strings.Contains(e.stdout.String(), unexpected) {
+					t.Errorf("expected output not to contain %q, got:\n%s", unexpected, e.stdout.String())
+				}
+			}
+		})
+	}
+}
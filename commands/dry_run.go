@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/rs/zerolog"
+
+	"dokku-service/argument"
+	"dokku-service/engine"
+	"dokku-service/plan"
+)
+
+// dryRunInput contains the input parameters for the dryRun function
+type dryRunInput struct {
+	// Context is the command context, replaced with a planning context while the command runs
+	Context *context.Context
+
+	// Format is the format to output the plan in: [text, json]
+	Format string
+
+	// Meta is the command meta, whose progress output is silenced while the command runs
+	Meta *command.Meta
+
+	// Run runs the command
+	Run func() int
+
+	// Title is the header shown above a text plan
+	Title string
+}
+
+// dryRun runs a command against a planning context that records changes instead of making them,
+// then outputs the plan if the command succeeded
+func dryRun(input dryRunInput) int {
+	logger, ok := input.Meta.Ui.(*command.ZerologUi)
+	if !ok {
+		input.Meta.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	p := plan.New()
+	ctx := *input.Context
+	runtime := engine.NewDryRun(engine.NewDryRunInput{
+		Plan:    p,
+		Runtime: engine.FromContext(ctx),
+	})
+
+	// errors still reach stderr, but progress headers would interleave with the plan
+	quietLogger := *logger
+	quietLogger.StdoutLogger = zerolog.Nop()
+
+	*input.Context = plan.WithPlan(engine.WithRuntime(ctx, runtime), p)
+	input.Meta.Ui = &quietLogger
+	exitCode := input.Run()
+	*input.Context = ctx
+	input.Meta.Ui = logger
+	if exitCode != 0 {
+		return exitCode
+	}
+
+	if input.Format == "json" {
+		data, err := p.JSON()
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to marshal plan: %s", err.Error()))
+			return 1
+		}
+
+		logger.Output(string(data))
+		return 0
+	}
+
+	logger.LogHeader1(input.Title)
+	for _, line := range p.Lines() {
+		logger.Output(line)
+	}
+
+	return 0
+}
+
+// redactSecretArguments hides the values of secret arguments from the plan being built, if any
+func redactSecretArguments(ctx context.Context, arguments map[string]argument.Argument) {
+	p := plan.FromContext(ctx)
+	if p == nil {
+		return
+	}
+
+	for _, argument := range arguments {
+		if argument.Secret || strings.HasSuffix(argument.Key, "_SECRET") {
+			p.Redact(argument.Value)
+		}
+	}
+}
@@ -0,0 +1,638 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"mvdan.cc/sh/v3/shell"
+
+	"dokku-service/ambassador"
+	"dokku-service/container"
+	"dokku-service/engine"
+	"dokku-service/healthcheck"
+	"dokku-service/hook"
+	"dokku-service/network"
+	"dokku-service/plan"
+	"dokku-service/service"
+	"dokku-service/template"
+	"dokku-service/volume"
+)
+
+// serviceContainerInput contains the input parameters for the service container lifecycle helpers
+type serviceContainerInput struct {
+	// Config is the config of the service
+	Config service.ConfigOutput
+
+	// ImageName is the name of the image to create the container from
+	ImageName string
+
+	// Logger is the logger to write progress to
+	Logger *command.ZerologUi
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// Template is the registry's template, used for hooks to avoid using the temp path from Config.Template
+	Template template.ServiceTemplate
+
+	// Trace controls whether to print the commands being executed
+	Trace bool
+}
+
+// createServiceContainer creates the volumes and container for a service, executing the pre-create and post-create hooks
+func createServiceContainer(ctx context.Context, input serviceContainerInput) ([]volume.Volume, error) {
+	config := input.Config
+	containerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+
+	input.Logger.LogHeader2("Creating volumes")
+	var createdVolumes []volume.Volume
+	for _, volumeDescriptor := range config.Template.Volumes {
+		volume, err := volume.Create(ctx, volume.CreateInput{
+			DataRoot:         config.Config.DataRoot,
+			ServiceName:      input.ServiceName,
+			Template:         config.Template,
+			Trace:            input.Trace,
+			UseVolumes:       config.Config.UseVolumes,
+			VolumeDescriptor: volumeDescriptor,
+		})
+		if err != nil {
+			return createdVolumes, fmt.Errorf("failed to run volume for service: %w", err)
+		}
+
+		createdVolumes = append(createdVolumes, volume)
+	}
+
+	input.Logger.LogHeader2("Executing pre-create hook")
+	if err := hook.Execute(ctx, hook.ExecuteInput{
+		DataRoot:    config.Config.DataRoot,
+		Exists:      config.Template.Hooks.PreCreate,
+		Name:        "pre-create",
+		ServiceName: input.ServiceName,
+		Template:    input.Template,
+		Volumes:     createdVolumes,
+		Trace:       input.Trace,
+	}); err != nil {
+		return createdVolumes, fmt.Errorf("failed to execute pre-create hook for service: %w", err)
+	}
+
+	configHash, err := service.ConfigHash(config)
+	if err != nil {
+		return createdVolumes, err
+	}
+
+	input.Logger.LogHeader2("Creating container")
+	envFile := fmt.Sprintf("%s/.env", config.Config.ServiceRoot)
+	if err := container.Create(ctx, container.CreateInput{
+		ConfigHash:    configHash,
+		CreateFlags:   config.Config.ContainerCreateFlags,
+		ContainerName: containerName,
+		EnvFile:       envFile,
+		ImageName:     input.ImageName,
+		ServiceRoot:   config.Config.ServiceRoot,
+		Trace:         input.Trace,
+		UseVolumes:    config.Config.UseVolumes,
+		Volumes:       createdVolumes,
+	}); err != nil {
+		return createdVolumes, fmt.Errorf("failed to create container for service: %w", err)
+	}
+
+	input.Logger.LogHeader2("Attaching container to post-create networks")
+	for _, networkName := range config.Config.PostCreateNetworks {
+		if err := network.Connect(ctx, network.ConnectInput{
+			ContainerName: containerName,
+			NetworkAlias:  networkAlias,
+			NetworkName:   networkName,
+			Trace:         input.Trace,
+		}); err != nil {
+			return createdVolumes, fmt.Errorf("failed to attach container to network: %w", err)
+		}
+	}
+
+	// todo: attach container to container-specific network
+	input.Logger.LogHeader2("Executing post-create hook")
+	if err := hook.Execute(ctx, hook.ExecuteInput{
+		DataRoot:    config.Config.DataRoot,
+		Exists:      config.Template.Hooks.PostCreate,
+		Name:        "post-create",
+		ServiceName: input.ServiceName,
+		Template:    input.Template,
+		Volumes:     createdVolumes,
+		Trace:       input.Trace,
+	}); err != nil {
+		return createdVolumes, fmt.Errorf("failed to execute post-create hook for service: %w", err)
+	}
+
+	return createdVolumes, nil
+}
+
+// startServiceContainer starts a created service container and waits for it to be ready,
+// then attaches networks, executes the post-start hook, starts the ambassador and starts the sidecars
+func startServiceContainer(ctx context.Context, input serviceContainerInput, createdVolumes []volume.Volume) error {
+	config := input.Config
+	containerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+
+	input.Logger.LogHeader2("Starting container")
+	if err := container.Start(ctx, container.StartInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	}); err != nil {
+		return err
+	}
+
+	input.Logger.LogHeader2("Waiting for service to be ready")
+	dockerContainer, err := container.Inspect(ctx, container.InspectInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return err
+	}
+	if err := healthcheck.ListeningCheck(ctx, healthcheck.ListeningCheckInput{
+		Container:      dockerContainer,
+		InitialNetwork: firstNetwork(resolvableNetworks(dockerContainer)),
+		NetworkAlias:   networkAlias,
+		Ports:          config.Template.Ports.Wait,
+		Timeout:        5,
+		Trace:          input.Trace,
+		Wait:           1,
+	}); err != nil {
+		return fmt.Errorf("failed to wait for service to be ready: %w", err)
+	}
+
+	// networks persist across container restarts, so only attach missing ones
+	connectedNetworks := map[string]bool{}
+	for networkName := range dockerContainer.Networks {
+		connectedNetworks[networkName] = true
+	}
+
+	input.Logger.LogHeader2("Attaching container to post-start networks")
+	for _, networkName := range config.Config.PostStartNetworks {
+		if connectedNetworks[networkName] {
+			continue
+		}
+
+		if err := network.Connect(ctx, network.ConnectInput{
+			ContainerName: containerName,
+			NetworkAlias:  networkAlias,
+			NetworkName:   networkName,
+			Trace:         input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to attach container to network: %w", err)
+		}
+		connectedNetworks[networkName] = true
+	}
+
+	input.Logger.LogHeader2("Attaching container to linked app networks")
+	links, err := service.Links(ctx, service.LinksInput{
+		DataRoot:    config.Config.DataRoot,
+		Name:        input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch service links: %w", err)
+	}
+
+	for _, link := range links {
+		for _, networkName := range link.Networks {
+			if connectedNetworks[networkName] {
+				continue
+			}
+
+			if err := network.Connect(ctx, network.ConnectInput{
+				ContainerName: containerName,
+				NetworkAlias:  networkAlias,
+				NetworkName:   networkName,
+				Trace:         input.Trace,
+			}); err != nil {
+				return fmt.Errorf("failed to attach container to network: %w", err)
+			}
+			connectedNetworks[networkName] = true
+		}
+	}
+
+	input.Logger.LogHeader2("Executing post-start hook")
+	if err := hook.Execute(ctx, hook.ExecuteInput{
+		DataRoot:    config.Config.DataRoot,
+		Exists:      config.Template.Hooks.PostStart,
+		Name:        "post-start",
+		ServiceName: input.ServiceName,
+		Template:    input.Template,
+		Volumes:     createdVolumes,
+		Trace:       input.Trace,
+	}); err != nil {
+		return fmt.Errorf("failed to execute post-start hook for service: %w", err)
+	}
+
+	if len(config.Config.Expose.Ports) > 0 {
+		input.Logger.LogHeader2("Starting ambassador for exposed ports")
+		if err := ensureAmbassador(ctx, ensureAmbassadorInput{
+			Config:        config,
+			ContainerName: containerName,
+			Recreate:      true,
+			ServiceName:   input.ServiceName,
+			Trace:         input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to start ambassador: %w", err)
+		}
+	}
+
+	return startSidecarContainers(ctx, input)
+}
+
+// removeServiceContainer removes the sidecars, ambassador and container for a service along with the container's ID file
+func removeServiceContainer(ctx context.Context, input serviceContainerInput) error {
+	containerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+
+	if err := removeSidecarContainers(ctx, input); err != nil {
+		return err
+	}
+
+	ambassadorExists, err := ambassador.Exists(ctx, ambassador.ExistsInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for ambassador existence: %w", err)
+	}
+
+	if ambassadorExists {
+		if err := destroyAmbassador(ctx, containerName, input.Trace); err != nil {
+			return err
+		}
+	}
+
+	containerExists, err := container.Exists(ctx, container.ExistsInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for container existence: %w", err)
+	}
+
+	if containerExists {
+		input.Logger.LogHeader2("Removing container")
+		if err := container.Stop(ctx, container.StopInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+
+		if err := container.Destroy(ctx, container.DestroyInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to remove container: %w", err)
+		}
+	}
+
+	if err := plan.RemoveAll(ctx, filepath.Join(input.Config.Config.ServiceRoot, "ID")); err != nil {
+		return fmt.Errorf("failed to remove ID file: %w", err)
+	}
+
+	return nil
+}
+
+// startSidecarContainers creates and starts the sidecar containers of a service in the order they are declared,
+// waiting for each to be ready before starting the next. Sidecars that are already running are left as is.
+func startSidecarContainers(ctx context.Context, input serviceContainerInput) error {
+	for _, sidecar := range input.Template.Sidecars {
+		if err := startSidecarContainer(ctx, input, sidecar); err != nil {
+			return fmt.Errorf("failed to start %s sidecar: %w", sidecar.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// startSidecarContainer creates a sidecar container if it does not exist, then starts it and attaches it to networks
+func startSidecarContainer(ctx context.Context, input serviceContainerInput, sidecar template.Sidecar) error {
+	config := input.Config
+	containerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+		Sidecar:     sidecar.Name,
+	})
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+		Sidecar:     sidecar.Name,
+	})
+
+	containerExists, err := container.Exists(ctx, container.ExistsInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for container existence: %w", err)
+	}
+
+	if !containerExists {
+		input.Logger.LogHeader2(fmt.Sprintf("Creating %s sidecar container", sidecar.Name))
+		if err := createSidecarContainer(ctx, input, sidecar); err != nil {
+			return err
+		}
+	}
+
+	dockerContainer, err := container.Inspect(ctx, container.InspectInput{
+		Name:  containerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !dockerContainer.Running {
+		input.Logger.LogHeader2(fmt.Sprintf("Starting %s sidecar container", sidecar.Name))
+		if err := container.Start(ctx, container.StartInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return err
+		}
+
+		dockerContainer, err = container.Inspect(ctx, container.InspectInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(sidecar.WaitPorts) > 0 {
+		input.Logger.LogHeader2(fmt.Sprintf("Waiting for %s sidecar to be ready", sidecar.Name))
+		if err := healthcheck.ListeningCheck(ctx, healthcheck.ListeningCheckInput{
+			Container:      dockerContainer,
+			InitialNetwork: firstNetwork(resolvableNetworks(dockerContainer)),
+			NetworkAlias:   networkAlias,
+			Ports:          sidecar.WaitPorts,
+			Timeout:        5,
+			Trace:          input.Trace,
+			Wait:           1,
+		}); err != nil {
+			return fmt.Errorf("failed to wait for sidecar to be ready: %w", err)
+		}
+	}
+
+	// sidecars are reachable on the same networks as the service container
+	links, err := service.Links(ctx, service.LinksInput{
+		DataRoot:    config.Config.DataRoot,
+		Name:        input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch service links: %w", err)
+	}
+
+	connectedNetworks := map[string]bool{}
+	for networkName := range dockerContainer.Networks {
+		connectedNetworks[networkName] = true
+	}
+
+	networkNames := append([]string{}, config.Config.PostStartNetworks...)
+	for _, link := range links {
+		networkNames = append(networkNames, link.Networks...)
+	}
+	for _, networkName := range networkNames {
+		if connectedNetworks[networkName] {
+			continue
+		}
+
+		if err := network.Connect(ctx, network.ConnectInput{
+			ContainerName: containerName,
+			NetworkAlias:  networkAlias,
+			NetworkName:   networkName,
+			Trace:         input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to attach container to network: %w", err)
+		}
+		connectedNetworks[networkName] = true
+	}
+
+	return nil
+}
+
+// createSidecarContainer creates the volumes and container for a sidecar on the networks of the service container
+func createSidecarContainer(ctx context.Context, input serviceContainerInput, sidecar template.Sidecar) error {
+	config := input.Config
+	containerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+		Sidecar:     sidecar.Name,
+	})
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+		Sidecar:     sidecar.Name,
+	})
+	serviceContainerName := container.Name(container.NameInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+	serviceNetworkAlias := network.Alias(network.AliasInput{
+		ServiceName: input.ServiceName,
+		ServiceType: input.Template.Name,
+	})
+
+	serviceContainer, err := container.Inspect(ctx, container.InspectInput{
+		Name:  serviceContainerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the sidecar reaches the service container by its alias on the service's own networks,
+	// falling back to a link when the service is only on a default network without name resolution
+	serviceNetworks := resolvableNetworks(serviceContainer)
+	initialNetwork := firstNetwork(serviceNetworks)
+	links := []string{}
+	if initialNetwork == "" {
+		links = append(links, fmt.Sprintf("%s:%s", serviceContainerName, serviceNetworkAlias))
+	}
+
+	var createdVolumes []volume.Volume
+	for _, volumeDescriptor := range sidecar.Volumes {
+		volume, err := volume.Create(ctx, volume.CreateInput{
+			DataRoot:         config.Config.DataRoot,
+			ServiceName:      input.ServiceName,
+			Template:         config.Template,
+			Trace:            input.Trace,
+			UseVolumes:       config.Config.UseVolumes,
+			VolumeDescriptor: volumeDescriptor,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to run volume for sidecar: %w", err)
+		}
+
+		createdVolumes = append(createdVolumes, volume)
+	}
+
+	rendered, err := service.RenderSidecar(ctx, service.RenderSidecarInput{
+		ConfigOutput: config,
+		Hostname:     serviceNetworkAlias,
+		Sidecar:      sidecar,
+	})
+	if err != nil {
+		return err
+	}
+
+	env := []string{}
+	for key, value := range rendered.Arguments {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(env)
+
+	command, err := shell.Fields(rendered.Command, func(key string) string {
+		return rendered.Arguments[key]
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse sidecar command: %w", err)
+	}
+
+	if err := container.Create(ctx, container.CreateInput{
+		Command:       command,
+		ContainerName: containerName,
+		Env:           env,
+		ImageName:     rendered.Image,
+		Links:         links,
+		Network:       initialNetwork,
+		Trace:         input.Trace,
+		UseVolumes:    config.Config.UseVolumes,
+		Volumes:       createdVolumes,
+	}); err != nil {
+		return fmt.Errorf("failed to create container for sidecar: %w", err)
+	}
+
+	for _, networkName := range serviceNetworks {
+		if networkName == initialNetwork {
+			continue
+		}
+
+		if err := network.Connect(ctx, network.ConnectInput{
+			ContainerName: containerName,
+			NetworkAlias:  networkAlias,
+			NetworkName:   networkName,
+			Trace:         input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to attach container to network: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resolvableNetworks returns the sorted networks of a container other than the runtime's default network,
+// on which other containers can reach it by name
+func resolvableNetworks(c engine.Container) []string {
+	networkNames := []string{}
+	for networkName := range c.Networks {
+		if !engine.IsDefaultNetwork(networkName) {
+			networkNames = append(networkNames, networkName)
+		}
+	}
+	sort.Strings(networkNames)
+
+	return networkNames
+}
+
+// firstNetwork returns the first of a list of networks, or an empty string for the default network
+func firstNetwork(networkNames []string) string {
+	if len(networkNames) == 0 {
+		return ""
+	}
+
+	return networkNames[0]
+}
+
+// stopSidecarContainers stops the sidecar containers of a service in the reverse order they are declared
+func stopSidecarContainers(ctx context.Context, input serviceContainerInput) error {
+	sidecars := input.Template.Sidecars
+	for i := len(sidecars) - 1; i >= 0; i-- {
+		containerName := container.Name(container.NameInput{
+			ServiceName: input.ServiceName,
+			ServiceType: input.Template.Name,
+			Sidecar:     sidecars[i].Name,
+		})
+		containerExists, err := container.Exists(ctx, container.ExistsInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check for container existence: %w", err)
+		}
+		if !containerExists {
+			continue
+		}
+
+		input.Logger.LogHeader2(fmt.Sprintf("Stopping %s sidecar container", sidecars[i].Name))
+		if err := container.Stop(ctx, container.StopInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to stop %s sidecar container: %w", sidecars[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// removeSidecarContainers stops and removes the sidecar containers of a service in the reverse order they are declared
+func removeSidecarContainers(ctx context.Context, input serviceContainerInput) error {
+	sidecars := input.Template.Sidecars
+	for i := len(sidecars) - 1; i >= 0; i-- {
+		containerName := container.Name(container.NameInput{
+			ServiceName: input.ServiceName,
+			ServiceType: input.Template.Name,
+			Sidecar:     sidecars[i].Name,
+		})
+		containerExists, err := container.Exists(ctx, container.ExistsInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check for container existence: %w", err)
+		}
+		if !containerExists {
+			continue
+		}
+
+		input.Logger.LogHeader2(fmt.Sprintf("Removing %s sidecar container", sidecars[i].Name))
+		if err := container.Stop(ctx, container.StopInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to stop %s sidecar container: %w", sidecars[i].Name, err)
+		}
+
+		if err := container.Destroy(ctx, container.DestroyInput{
+			Name:  containerName,
+			Trace: input.Trace,
+		}); err != nil {
+			return fmt.Errorf("failed to remove %s sidecar container: %w", sidecars[i].Name, err)
+		}
+	}
+
+	return nil
+}
@@ -11,29 +11,15 @@ import (
 type TemplateCleanupFunc func() error
 
 func fetchTemplateRegistry(ctx context.Context, registryPath string) (registry.Registry, TemplateCleanupFunc, error) {
-	vendoredRegistry := false
-	deferredFunction := func() error {
-		return nil
-	}
-	if registryPath == "" {
-		dir, err := os.MkdirTemp("", "dokku-service-registry-*")
-		if err != nil {
-			return registry.Registry{}, deferredFunction, fmt.Errorf("Failed to create temporary directory: %s", err.Error())
-		}
-		deferredFunction = func() error {
-			return os.RemoveAll(dir)
-		}
-
-		if _, err := registry.NewVendoredRegistry(ctx, dir); err != nil {
-			return registry.Registry{}, deferredFunction, fmt.Errorf("Failed to create vendored registry: %s", err.Error())
-		}
-		registryPath = dir
-		vendoredRegistry = true
+	registryPath, vendoredRegistryPath, deferredFunction, err := fetchRegistryPath(ctx, registryPath)
+	if err != nil {
+		return registry.Registry{}, deferredFunction, err
 	}
 
 	templateRegistry, err := registry.NewRegistry(ctx, registry.NewRegistryInput{
-		RegistryPath: registryPath,
-		Vendored:     vendoredRegistry,
+		RegistryPath:         registryPath,
+		Vendored:             registryPath == vendoredRegistryPath,
+		VendoredRegistryPath: vendoredRegistryPath,
 	})
 	if err != nil {
 		return registry.Registry{}, deferredFunction, fmt.Errorf("Failed to parse registry: %s", err.Error())
@@ -42,6 +28,33 @@ func fetchTemplateRegistry(ctx context.Context, registryPath string) (registry.R
 	return templateRegistry, deferredFunction, err
 }
 
+// fetchRegistryPath returns the path to the registry along with the path the vendored registry is extracted to,
+// which is also the registry path if no path is specified
+func fetchRegistryPath(ctx context.Context, registryPath string) (string, string, TemplateCleanupFunc, error) {
+	deferredFunction := func() error {
+		return nil
+	}
+
+	// the vendored registry is always extracted, as templates in other registries may extend its templates
+	dir, err := os.MkdirTemp("", "dokku-service-registry-*")
+	if err != nil {
+		return "", "", deferredFunction, fmt.Errorf("Failed to create temporary directory: %s", err.Error())
+	}
+	deferredFunction = func() error {
+		return os.RemoveAll(dir)
+	}
+
+	if _, err := registry.NewVendoredRegistry(ctx, dir); err != nil {
+		return "", "", deferredFunction, fmt.Errorf("Failed to create vendored registry: %s", err.Error())
+	}
+
+	if registryPath == "" {
+		registryPath = dir
+	}
+
+	return registryPath, dir, deferredFunction, nil
+}
+
 func fetchTemplate(templateRegistry registry.Registry, templateName string) (template.ServiceTemplate, error) {
 	serviceTemplate, ok := templateRegistry.Templates[templateName]
 	if !ok {
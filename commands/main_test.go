@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mitchellh/cli"
+	"github.com/rs/zerolog"
+
+	"dokku-service/backup"
+	"dokku-service/engine"
+	"dokku-service/service"
+)
+
+// testEnv runs commands against an in-memory runtime, a temporary data root and a fake dokku binary
+type testEnv struct {
+	// ctx carries the fake runtime
+	ctx context.Context
+
+	// dataRoot is the temporary root directory for service data
+	dataRoot string
+
+	// dokkuLog is the file the fake dokku binary appends its arguments to
+	dokkuLog string
+
+	// runtime is the fake runtime commands are run against
+	runtime *engine.Fake
+
+	// stderr holds the error output of the last command
+	stderr *bytes.Buffer
+
+	// stdout holds the output of the last command
+	stdout *bytes.Buffer
+
+	t *testing.T
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	binDir := t.TempDir()
+	dokkuLog := filepath.Join(binDir, "dokku.log")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", dokkuLog)
+	if err := os.WriteFile(filepath.Join(binDir, "dokku"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake dokku binary: %s", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	runtime := engine.NewFake()
+	return &testEnv{
+		ctx:      engine.WithRuntime(context.Background(), runtime),
+		dataRoot: t.TempDir(),
+		dokkuLog: dokkuLog,
+		runtime:  runtime,
+		stderr:   &bytes.Buffer{},
+		stdout:   &bytes.Buffer{},
+		t:        t,
+	}
+}
+
+// meta returns command meta writing to the env's output buffers, resetting them
+func (e *testEnv) meta() command.Meta {
+	e.stdout.Reset()
+	e.stderr.Reset()
+
+	return command.Meta{
+		Ui: &command.ZerologUi{
+			StderrLogger: zerolog.New(command.NewHumanWriter(func(w *command.HumanWriter) {
+				w.Out = e.stderr
+				w.NoColor = true
+			})),
+			StdoutLogger: zerolog.New(command.NewHumanWriter(func(w *command.HumanWriter) {
+				w.Out = e.stdout
+				w.NoColor = true
+			})),
+			Ui:                cli.NewMockUi(),
+			OutputIndentField: true,
+		},
+	}
+}
+
+// run runs a command with the data root flag, failing the test if the exit code does not match
+func (e *testEnv) run(c cli.Command, exitCode int, args ...string) {
+	e.t.Helper()
+
+	e.runWithoutDataRoot(c, exitCode, append([]string{"--data-root", e.dataRoot}, args...)...)
+}
+
+// runWithoutDataRoot runs a command, failing the test if the exit code does not match
+func (e *testEnv) runWithoutDataRoot(c cli.Command, exitCode int, args ...string) {
+	e.t.Helper()
+
+	if code := c.Run(args); code != exitCode {
+		e.t.Fatalf("%s %s: expected exit code %d, got %d\nstdout:\n%s\nstderr:\n%s", commandName(c), strings.Join(args, " "), exitCode, code, e.stdout.String(), e.stderr.String())
+	}
+}
+
+// create creates a service, failing the test on error
+func (e *testEnv) create(templateName string, serviceName string, args ...string) {
+	e.t.Helper()
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, append([]string{templateName, serviceName}, args...)...)
+}
+
+// config returns the config of a service, failing the test on error
+func (e *testEnv) config(templateName string, serviceName string) service.ConfigOutput {
+	e.t.Helper()
+
+	templateRegistry, cleanup, err := fetchTemplateRegistry(e.ctx, "")
+	if err != nil {
+		e.t.Fatalf("failed to fetch template registry: %s", err)
+	}
+	defer cleanup()
+
+	config, err := service.Config(e.ctx, service.ConfigInput{
+		DataRoot:    e.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: templateName,
+	})
+	if err != nil {
+		e.t.Fatalf("failed to read config for %s service %s: %s", templateName, serviceName, err)
+	}
+
+	return config
+}
+
+// backupConfig returns the backup config of a service, failing the test on error
+func (e *testEnv) backupConfig(templateName string, serviceName string) backup.ConfigOutput {
+	e.t.Helper()
+
+	config, err := backup.Config(e.ctx, backup.ConfigInput{
+		ServiceRoot: filepath.Join(e.dataRoot, templateName, serviceName),
+	})
+	if err != nil {
+		e.t.Fatalf("failed to read backup config for %s service %s: %s", templateName, serviceName, err)
+	}
+
+	return config
+}
+
+// dokkuCalls returns the arguments the fake dokku binary was called with, one call per line
+func (e *testEnv) dokkuCalls() []string {
+	e.t.Helper()
+
+	b, err := os.ReadFile(e.dokkuLog)
+	if os.IsNotExist(err) {
+		return []string{}
+	}
+	if err != nil {
+		e.t.Fatalf("failed to read fake dokku log: %s", err)
+	}
+
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+// container returns the state of a container in the fake runtime, failing the test if it does not exist
+func (e *testEnv) container(name string) *engine.FakeContainer {
+	e.t.Helper()
+
+	c, ok := e.runtime.Containers[name]
+	if !ok {
+		e.t.Fatalf("expected container %s to exist, found %v", name, e.runtime.ContainerNames())
+	}
+
+	return c
+}
+
+// identity writes a new age identity to a file, returning the file and its recipient
+func (e *testEnv) identity() (string, string) {
+	e.t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		e.t.Fatalf("failed to generate identity: %s", err)
+	}
+
+	identityFile := filepath.Join(e.t.TempDir(), "identity.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600); err != nil {
+		e.t.Fatalf("failed to write identity file: %s", err)
+	}
+
+	return identityFile, identity.Recipient().String()
+}
+
+// stdin replaces os.Stdin with a file containing data until the test ends
+func (e *testEnv) stdin(data []byte) {
+	e.t.Helper()
+
+	stdinFile := filepath.Join(e.t.TempDir(), "stdin")
+	if err := os.WriteFile(stdinFile, data, 0o600); err != nil {
+		e.t.Fatalf("failed to write stdin file: %s", err)
+	}
+
+	file, err := os.Open(stdinFile)
+	if err != nil {
+		e.t.Fatalf("failed to open stdin file: %s", err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = file
+	e.t.Cleanup(func() {
+		os.Stdin = stdin
+		file.Close()
+	})
+}
+
+// commandName returns the name of a command
+func commandName(c cli.Command) string {
+	if named, ok := c.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+
+	return fmt.Sprintf("%T", c)
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/backup"
+	"dokku-service/container"
+	"dokku-service/service"
+)
+
+type ServiceBackupCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceBackupCommand) Name() string {
+	return "service-backup"
+}
+
+func (c *ServiceBackupCommand) Synopsis() string {
+	return "service-backup command"
+}
+
+func (c *ServiceBackupCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceBackupCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"backup a service to a local directory": fmt.Sprintf("%s %s postgres lollipop /var/backups/postgres", appName, c.Name()),
+		"backup a service to an s3 bucket":      fmt.Sprintf("%s %s postgres lollipop s3://my-bucket/postgres", appName, c.Name()),
+	}
+}
+
+func (c *ServiceBackupCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "destination",
+		Description: "the destination to upload the backup to",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceBackupCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceBackupCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceBackupCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceBackupCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceBackupCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	if _, ok := config.Template.Commands["export"]; !ok {
+		c.Ui.Error(fmt.Sprintf("%s service %s does not support export command", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if !containerExists {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not running", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	backupConfig, err := backup.Config(c.Context, backup.ConfigInput{
+		ServiceRoot: config.Config.ServiceRoot,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	store, err := backup.NewStore(c.Context, backup.NewStoreInput{
+		ConfigOutput: backupConfig,
+		Destination:  arguments["destination"].StringValue(),
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	extension := ".gz"
+	if len(backupConfig.Encryption.Recipients) > 0 {
+		extension += backup.EncryptedExtension
+	}
+
+	backupName := backup.Name(backup.NameInput{
+		Extension:   extension,
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+		Time:        time.Now(),
+	})
+
+	logger.LogHeader1(fmt.Sprintf("Backing up %s service %s to %s", serviceTemplate.Name, serviceName, store.String()))
+	if err := backup.Create(c.Context, backup.CreateInput{
+		ConfigOutput:  config,
+		ContainerName: containerName,
+		Name:          backupName,
+		Recipients:    backupConfig.Encryption.Recipients,
+		Store:         store,
+		Trace:         c.trace,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to back up service: %s", err.Error()))
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("Backup %s complete", backupName))
+	return 0
+}
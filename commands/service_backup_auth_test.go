@@ -0,0 +1,53 @@
+package commands
+
+import "testing"
+
+func TestServiceBackupAuthCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		endpoint string
+		region   string
+	}{
+		{
+			name:   "records the credentials",
+			args:   []string{"postgres", "lollipop", "AKIAEXAMPLE", "secret"},
+			region: "us-east-1",
+		},
+		{
+			name:     "records a custom endpoint and region",
+			args:     []string{"--endpoint-url", "https://minio.example.com", "--region", "eu-west-1", "postgres", "lollipop", "AKIAEXAMPLE", "secret"},
+			endpoint: "https://minio.example.com",
+			region:   "eu-west-1",
+		},
+		{
+			name:     "fails for an invalid endpoint url",
+			args:     []string{"--endpoint-url", "minio", "postgres", "lollipop", "AKIAEXAMPLE", "secret"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			e.run(&ServiceBackupAuthCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+
+			config := e.backupConfig("postgres", "lollipop").S3
+			if tt.exitCode != 0 {
+				if config.AccessKeyID != "" {
+					t.Errorf("expected no credentials to be recorded, got %+v", config)
+				}
+				return
+			}
+			if config.AccessKeyID != "AKIAEXAMPLE" || config.SecretAccessKey != "secret" {
+				t.Errorf("expected credentials to be recorded, got %+v", config)
+			}
+			if config.EndpointURL != tt.endpoint || config.Region != tt.region {
+				t.Errorf("expected endpoint %q and region %q, got %+v", tt.endpoint, tt.region, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package commands
+
+import "testing"
+
+func TestServiceBackupDeauthCommand(t *testing.T) {
+	e := newTestEnv(t)
+	e.create("postgres", "lollipop")
+	e.run(&ServiceBackupAuthCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "AKIAEXAMPLE", "secret")
+
+	e.run(&ServiceBackupDeauthCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+	if config := e.backupConfig("postgres", "lollipop").S3; config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		t.Errorf("expected credentials to be removed, got %+v", config)
+	}
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/backup"
+	"dokku-service/service"
+)
+
+type ServiceBackupPruneCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// keepDaily specifies the number of days to keep the newest backup for
+	keepDaily int
+
+	// keepLast specifies the number of most recent backups to keep
+	keepLast int
+
+	// keepMonthly specifies the number of months to keep the newest backup for
+	keepMonthly int
+
+	// keepWeekly specifies the number of weeks to keep the newest backup for
+	keepWeekly int
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceBackupPruneCommand) Name() string {
+	return "service-backup-prune"
+}
+
+func (c *ServiceBackupPruneCommand) Synopsis() string {
+	return "service-backup-prune command"
+}
+
+func (c *ServiceBackupPruneCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceBackupPruneCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"prune the scheduled backup destination": fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+		"keep only the last 3 backups":           fmt.Sprintf("%s %s --keep-last 3 postgres lollipop s3://my-bucket/postgres", appName, c.Name()),
+	}
+}
+
+func (c *ServiceBackupPruneCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "destination",
+		Description: "the destination to prune, defaulting to the scheduled backup destination",
+		Optional:    true,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceBackupPruneCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceBackupPruneCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceBackupPruneCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	f.IntVar(&c.keepDaily, "keep-daily", 0, "the number of days to keep the newest backup for")
+	f.IntVar(&c.keepLast, "keep-last", 0, "the number of most recent backups to keep")
+	f.IntVar(&c.keepMonthly, "keep-monthly", 0, "the number of months to keep the newest backup for")
+	f.IntVar(&c.keepWeekly, "keep-weekly", 0, "the number of weeks to keep the newest backup for")
+	return f
+}
+
+func (c *ServiceBackupPruneCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceBackupPruneCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	backupConfig, err := backup.Config(c.Context, backup.ConfigInput{
+		ServiceRoot: config.Config.ServiceRoot,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	destination := arguments["destination"].StringValue()
+	if destination == "" {
+		destination = backupConfig.Schedule.Destination
+	}
+	if destination == "" {
+		c.Ui.Error(fmt.Sprintf("No destination specified and no backup schedule set for %s service %s", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	retention := backupConfig.Retention
+	retentionFlags := []string{"keep-daily", "keep-last", "keep-monthly", "keep-weekly"}
+	for _, name := range retentionFlags {
+		if flags.Changed(name) {
+			retention = backup.Retention{
+				KeepDaily:   c.keepDaily,
+				KeepLast:    c.keepLast,
+				KeepMonthly: c.keepMonthly,
+				KeepWeekly:  c.keepWeekly,
+			}
+			break
+		}
+	}
+
+	if retention.Empty() {
+		c.Ui.Error(fmt.Sprintf("No retention policy set for %s service %s", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	store, err := backup.NewStore(c.Context, backup.NewStoreInput{
+		ConfigOutput: backupConfig,
+		Destination:  destination,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	objects, err := store.List(c.Context, backup.NamePrefix(backup.NamePrefixInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	}))
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	result, err := backup.ApplyRetention(backup.ApplyRetentionInput{
+		Objects:     objects,
+		Retention:   retention,
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Pruning backups for %s service %s in %s", serviceTemplate.Name, serviceName, store.String()))
+	logger.Info(fmt.Sprintf("Keeping %d backup(s) with retention %s", len(result.Keep), retention.String()))
+	for _, object := range result.Remove {
+		logger.Info(fmt.Sprintf("Removing %s", object.Name))
+		if err := store.Delete(c.Context, object.Name); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"dokku-service/backup"
+)
+
+func TestServiceBackupPruneCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv, destination string)
+		keep     int
+	}{
+		{
+			name: "keeps the most recent backups",
+			args: []string{"--keep-last", "2"},
+			keep: 2,
+		},
+		{
+			name: "uses the scheduled retention and destination",
+			setup: func(e *testEnv, destination string) {
+				e.run(&ServiceBackupScheduleCommand{Meta: e.meta(), Context: e.ctx}, 0, "--schedule-dir", e.t.TempDir(), "--keep-last", "3", "postgres", "lollipop", "@daily", destination)
+			},
+			keep: 3,
+		},
+		{
+			name:     "fails without a retention policy",
+			exitCode: 1,
+			keep:     5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			destination := t.TempDir()
+			now := time.Now()
+			for i := 0; i < 5; i++ {
+				name := backup.Name(backup.NameInput{
+					Extension:   ".gz",
+					ServiceName: "lollipop",
+					ServiceType: "postgres",
+					Time:        now.Add(-time.Duration(i) * time.Hour),
+				})
+				if err := os.WriteFile(filepath.Join(destination, name), []byte("data"), 0o600); err != nil {
+					t.Fatalf("failed to write backup: %s", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(destination, "unrelated.gz"), []byte("data"), 0o600); err != nil {
+				t.Fatalf("failed to write unrelated file: %s", err)
+			}
+			if tt.setup != nil {
+				tt.setup(e, destination)
+			}
+
+			args := append([]string{}, tt.args...)
+			args = append(args, "postgres", "lollipop")
+			if tt.setup == nil {
+				args = append(args, destination)
+			}
+			e.run(&ServiceBackupPruneCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, args...)
+
+			entries, err := os.ReadDir(destination)
+			if err != nil {
+				t.Fatalf("failed to read destination: %s", err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			sort.Strings(names)
+			if len(names) != tt.keep+1 || names[len(names)-1] != "unrelated.gz" {
+				t.Errorf("expected %d backups and the unrelated file to be kept, got %v", tt.keep, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/backup"
+	"dokku-service/engine"
+	"dokku-service/schedule"
+	"dokku-service/service"
+)
+
+type ServiceBackupScheduleCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// keepDaily specifies the number of days to keep the newest backup for
+	keepDaily int
+
+	// keepLast specifies the number of most recent backups to keep
+	keepLast int
+
+	// keepMonthly specifies the number of months to keep the newest backup for
+	keepMonthly int
+
+	// keepWeekly specifies the number of weeks to keep the newest backup for
+	keepWeekly int
+
+	// scheduleDir specifies the directory to write the schedule entry to
+	scheduleDir string
+
+	// scheduler specifies the scheduler to write the schedule entry for
+	scheduler string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+
+	// user specifies the user to run scheduled backups as
+	user string
+}
+
+func (c *ServiceBackupScheduleCommand) Name() string {
+	return "service-backup-schedule"
+}
+
+func (c *ServiceBackupScheduleCommand) Synopsis() string {
+	return "service-backup-schedule command"
+}
+
+func (c *ServiceBackupScheduleCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceBackupScheduleCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"backup a service nightly to an s3 bucket":    fmt.Sprintf("%s %s postgres lollipop \"0 3 * * *\" s3://my-bucket/postgres", appName, c.Name()),
+		"backup a service hourly and keep a week":     fmt.Sprintf("%s %s --keep-last 24 --keep-daily 7 postgres lollipop @hourly /var/backups/postgres", appName, c.Name()),
+		"backup a service nightly via systemd timers": fmt.Sprintf("%s %s --scheduler systemd postgres lollipop @daily s3://my-bucket/postgres", appName, c.Name()),
+	}
+}
+
+func (c *ServiceBackupScheduleCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "schedule",
+		Description: "the cron expression to take backups on",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "destination",
+		Description: "the destination to upload the backup to",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceBackupScheduleCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceBackupScheduleCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceBackupScheduleCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	f.IntVar(&c.keepDaily, "keep-daily", 0, "the number of days to keep the newest backup for")
+	f.IntVar(&c.keepLast, "keep-last", 0, "the number of most recent backups to keep")
+	f.IntVar(&c.keepMonthly, "keep-monthly", 0, "the number of months to keep the newest backup for")
+	f.IntVar(&c.keepWeekly, "keep-weekly", 0, "the number of weeks to keep the newest backup for")
+	f.StringVar(&c.scheduleDir, "schedule-dir", "", "the directory to write the schedule entry to (default: /etc/cron.d or /etc/systemd/system)")
+	f.StringVar(&c.scheduler, "scheduler", schedule.SchedulerCron, "the scheduler to write the schedule entry for: [cron, systemd]")
+	f.StringVar(&c.user, "user", "", "the user to run scheduled backups as (default: the current user)")
+	return f
+}
+
+func (c *ServiceBackupScheduleCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{
+			"--scheduler": complete.PredictSet(schedule.SchedulerCron, schedule.SchedulerSystemd),
+		},
+	)
+}
+
+func (c *ServiceBackupScheduleCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	if _, ok := config.Template.Commands["export"]; !ok {
+		c.Ui.Error(fmt.Sprintf("%s service %s does not support export command", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	backupSchedule, err := schedule.Parse(arguments["schedule"].StringValue())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if c.scheduler != schedule.SchedulerCron && c.scheduler != schedule.SchedulerSystemd {
+		c.Ui.Error(fmt.Sprintf("Invalid scheduler specified: %s", c.scheduler))
+		return 1
+	}
+
+	scheduleDir := c.scheduleDir
+	if scheduleDir == "" {
+		scheduleDir = schedule.DefaultDirectory(c.scheduler)
+	}
+
+	scheduleUser := c.user
+	if scheduleUser == "" {
+		currentUser, err := user.Current()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to fetch current user: %s", err.Error()))
+			return 1
+		}
+		scheduleUser = currentUser.Username
+	}
+
+	backupConfig, err := backup.Config(c.Context, backup.ConfigInput{
+		ServiceRoot: config.Config.ServiceRoot,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// validate the destination and credentials now rather than on the first scheduled run
+	destination := arguments["destination"].StringValue()
+	if _, err := backup.NewStore(c.Context, backup.NewStoreInput{
+		ConfigOutput: backupConfig,
+		Destination:  destination,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	retentionFlags := []string{"keep-daily", "keep-last", "keep-monthly", "keep-weekly"}
+	for _, name := range retentionFlags {
+		if flags.Changed(name) {
+			backupConfig.Retention = backup.Retention{
+				KeepDaily:   c.keepDaily,
+				KeepLast:    c.keepLast,
+				KeepMonthly: c.keepMonthly,
+				KeepWeekly:  c.keepWeekly,
+			}
+			break
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch executable path: %s", err.Error()))
+		return 1
+	}
+
+	commonArgs := []string{"--data-root", c.dataRoot}
+	if c.registryPath != "" {
+		commonArgs = append(commonArgs, "--registry-path", c.registryPath)
+	}
+	commonArgs = append(commonArgs, serviceTemplate.Name, serviceName, destination)
+
+	commands := [][]string{
+		append([]string{executable, "service-backup"}, commonArgs...),
+	}
+	if !backupConfig.Retention.Empty() {
+		commands = append(commands, append([]string{executable, "service-backup-prune"}, commonArgs...))
+	}
+
+	scheduleName := backupScheduleName(serviceTemplate.Name, serviceName)
+	logger.LogHeader1(fmt.Sprintf("Scheduling backups for %s service %s", serviceTemplate.Name, serviceName))
+	paths, err := schedule.Write(c.Context, schedule.WriteInput{
+		Commands:    commands,
+		Description: fmt.Sprintf("backup %s service %s", serviceTemplate.Name, serviceName),
+		// cron and systemd start jobs without the current environment, so the runtime must be carried over
+		Environment: engine.Environment(engine.FromContext(c.Context)),
+		Directory:   scheduleDir,
+		Name:        scheduleName,
+		Schedule:    backupSchedule,
+		Scheduler:   c.scheduler,
+		User:        scheduleUser,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// remove the previous entry if it was written elsewhere or for another scheduler
+	previous := backupConfig.Schedule
+	if previous.Directory != "" && (previous.Directory != scheduleDir || previous.Scheduler != c.scheduler) {
+		if err := schedule.Remove(c.Context, schedule.RemoveInput{
+			Directory: previous.Directory,
+			Name:      scheduleName,
+			Scheduler: previous.Scheduler,
+		}); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	backupConfig.Schedule = backup.ScheduleConfig{
+		Destination: destination,
+		Directory:   scheduleDir,
+		Expression:  backupSchedule.String(),
+		Scheduler:   c.scheduler,
+	}
+	if err := backup.WriteConfig(c.Context, backup.WriteConfigInput{
+		ConfigOutput: backupConfig,
+		ServiceRoot:  config.Config.ServiceRoot,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	for _, path := range paths {
+		logger.Info(fmt.Sprintf("Wrote %s", path))
+	}
+	if !backupConfig.Retention.Empty() {
+		logger.Info(fmt.Sprintf("Pruning backups with retention %s", backupConfig.Retention.String()))
+	}
+	if c.scheduler == schedule.SchedulerSystemd {
+		logger.Info(fmt.Sprintf("Enable the timer with: systemctl daemon-reload && systemctl enable --now %s.timer", scheduleName))
+	}
+
+	return 0
+}
+
+// backupScheduleName returns the name of the schedule entry for a service
+func backupScheduleName(serviceType string, serviceName string) string {
+	return fmt.Sprintf("dokku-service-backup-%s-%s", serviceType, serviceName)
+}
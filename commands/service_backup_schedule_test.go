@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceBackupScheduleCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		files    []string
+		contents []string
+	}{
+		{
+			name:     "writes a cron entry",
+			args:     []string{"--user", "dokku", "postgres", "lollipop", "0 3 * * *", "/var/backups"},
+			files:    []string{"dokku-service-backup-postgres-lollipop"},
+			contents: []string{"0 3 * * * dokku ", "service-backup --data-root", "postgres lollipop /var/backups"},
+		},
+		{
+			name:     "prunes backups when a retention policy is set",
+			args:     []string{"--user", "dokku", "--keep-last", "7", "postgres", "lollipop", "@daily", "/var/backups"},
+			files:    []string{"dokku-service-backup-postgres-lollipop"},
+			contents: []string{"service-backup-prune --data-root"},
+		},
+		{
+			name:  "writes systemd units",
+			args:  []string{"--user", "dokku", "--scheduler", "systemd", "postgres", "lollipop", "@daily", "/var/backups"},
+			files: []string{"dokku-service-backup-postgres-lollipop.service", "dokku-service-backup-postgres-lollipop.timer"},
+		},
+		{
+			name:     "fails for an invalid schedule",
+			args:     []string{"postgres", "lollipop", "not a schedule", "/var/backups"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails for an unsupported destination",
+			args:     []string{"postgres", "lollipop", "@daily", "ftp://example.com/backups"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			scheduleDir := t.TempDir()
+			args := append([]string{"--schedule-dir", scheduleDir}, tt.args...)
+			e.run(&ServiceBackupScheduleCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, args...)
+
+			entries, err := os.ReadDir(scheduleDir)
+			if err != nil {
+				t.Fatalf("failed to read schedule dir: %s", err)
+			}
+			if len(entries) != len(tt.files) {
+				t.Fatalf("expected %d schedule files, got %d", len(tt.files), len(entries))
+			}
+			for _, name := range tt.files {
+				data, err := os.ReadFile(filepath.Join(scheduleDir, name))
+				if err != nil {
+					t.Fatalf("expected schedule file %s: %s", name, err)
+				}
+				for _, expected := range tt.contents {
+					if !strings.Contains(string(data), expected) {
+						t.Errorf("expected %s to contain %q, got:\n%s", name, expected, data)
+					}
+				}
+			}
+
+			if tt.exitCode == 0 {
+				config := e.backupConfig("postgres", "lollipop")
+				if config.Schedule.Destination != "/var/backups" || config.Schedule.Directory != scheduleDir {
+					t.Errorf("expected schedule to be recorded, got %+v", config.Schedule)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceBackupScheduleRuntime(t *testing.T) {
+	tests := []struct {
+		name      string
+		scheduler string
+		file      string
+		contents  []string
+	}{
+		{
+			name:      "writes the runtime to the cron entry",
+			scheduler: "cron",
+			file:      "dokku-service-backup-postgres-lollipop",
+			contents: []string{
+				"CONTAINER_HOST=unix:///run/podman/podman.sock\n",
+				"DOKKU_SERVICE_RUNTIME=podman\n",
+			},
+		},
+		{
+			name:      "writes the runtime to the systemd unit",
+			scheduler: "systemd",
+			file:      "dokku-service-backup-postgres-lollipop.service",
+			contents: []string{
+				"Environment=\"CONTAINER_HOST=unix:///run/podman/podman.sock\"\n",
+				"Environment=\"DOKKU_SERVICE_RUNTIME=podman\"\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			// the command only reads the runtime's settings, so the podman service need not be running
+			ctx := engine.WithRuntime(e.ctx, engine.NewPodman(engine.NewPodmanInput{Host: "unix:///run/podman/podman.sock"}))
+			scheduleDir := t.TempDir()
+			e.run(&ServiceBackupScheduleCommand{Meta: e.meta(), Context: ctx}, 0,
+				"--schedule-dir", scheduleDir, "--scheduler", tt.scheduler, "--user", "dokku", "postgres", "lollipop", "@daily", "/var/backups")
+
+			data, err := os.ReadFile(filepath.Join(scheduleDir, tt.file))
+			if err != nil {
+				t.Fatalf("expected schedule file %s: %s", tt.file, err)
+			}
+			for _, expected := range tt.contents {
+				if !strings.Contains(string(data), expected) {
+					t.Errorf("expected %s to contain %q, got:\n%s", tt.file, expected, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/backup"
+	"dokku-service/service"
+)
+
+type ServiceBackupSetEncryptionCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Name() string {
+	return "service-backup-set-encryption"
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Synopsis() string {
+	return "service-backup-set-encryption command"
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"encrypt backups to a recipient":     fmt.Sprintf("%s %s postgres lollipop age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p", appName, c.Name()),
+		"encrypt backups to many recipients": fmt.Sprintf("%s %s postgres lollipop age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p age1lggyhqrw2nlhcxprm67z43rta597azn8gknawjehu9d9dl0jq3yqqvfafg", appName, c.Name()),
+	}
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "recipients",
+		Description: "the age public keys to encrypt backups to",
+		Optional:    false,
+		Type:        command.ArgumentList,
+	})
+	return args
+}
+
+func (c *ServiceBackupSetEncryptionCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceBackupSetEncryptionCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceBackupSetEncryptionCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceBackupSetEncryptionCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceBackupSetEncryptionCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	backupConfig, err := backup.Config(c.Context, backup.ConfigInput{
+		ServiceRoot: config.Config.ServiceRoot,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	recipients := arguments["recipients"].ListValue()
+	if _, err := backup.ParseRecipients(backup.ParseRecipientsInput{
+		Recipients: recipients,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	backupConfig.Encryption.Recipients = recipients
+	if err := backup.WriteConfig(c.Context, backup.WriteConfigInput{
+		ConfigOutput: backupConfig,
+		ServiceRoot:  config.Config.ServiceRoot,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("Backup encryption set for %s service %s", serviceTemplate.Name, serviceName))
+	return 0
+}
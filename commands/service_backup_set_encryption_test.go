@@ -0,0 +1,41 @@
+package commands
+
+import "testing"
+
+func TestServiceBackupSetEncryptionCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		valid    bool
+	}{
+		{
+			name:  "records the recipients",
+			valid: true,
+		},
+		{
+			name:     "fails for an invalid recipient",
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			recipient := "not-a-recipient"
+			if tt.valid {
+				_, recipient = e.identity()
+			}
+
+			e.run(&ServiceBackupSetEncryptionCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop", recipient)
+			recipients := e.backupConfig("postgres", "lollipop").Encryption.Recipients
+			if tt.valid && (len(recipients) != 1 || recipients[0] != recipient) {
+				t.Errorf("expected recipient to be recorded, got %v", recipients)
+			}
+			if !tt.valid && len(recipients) != 0 {
+				t.Errorf("expected no recipients to be recorded, got %v", recipients)
+			}
+		})
+	}
+}
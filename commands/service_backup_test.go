@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceBackupCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitCode  int
+		setup     func(e *testEnv)
+		extension string
+	}{
+		{
+			name:      "writes a compressed backup to the destination",
+			extension: ".gz",
+		},
+		{
+			name: "encrypts the backup to the recipients",
+			setup: func(e *testEnv) {
+				_, recipient := e.identity()
+				e.run(&ServiceBackupSetEncryptionCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", recipient)
+			},
+			extension: ".gz.age",
+		},
+		{
+			name:     "fails when the service is not running",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+				_, err := io.WriteString(options.Stdout, "lollipop data")
+				return err
+			}
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			destination := t.TempDir()
+			e.run(&ServiceBackupCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop", destination)
+
+			entries, err := os.ReadDir(destination)
+			if err != nil {
+				t.Fatalf("failed to read destination: %s", err)
+			}
+			if tt.extension == "" {
+				if len(entries) != 0 {
+					t.Errorf("expected no backups, got %d", len(entries))
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected one backup, got %d", len(entries))
+			}
+
+			backupName := entries[0].Name()
+			if !strings.HasPrefix(backupName, "postgres-lollipop-") || !strings.HasSuffix(backupName, tt.extension) {
+				t.Errorf("unexpected backup name %s", backupName)
+			}
+			if tt.extension != ".gz" {
+				return
+			}
+
+			file, err := os.Open(filepath.Join(destination, backupName))
+			if err != nil {
+				t.Fatalf("failed to open backup: %s", err)
+			}
+			defer file.Close()
+			reader, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatalf("failed to decompress backup: %s", err)
+			}
+			data, _ := io.ReadAll(reader)
+			if string(data) != "lollipop data" {
+				t.Errorf("expected backup to contain lollipop data, got %q", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestServiceBackupUnscheduleCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		schedule []string
+	}{
+		{
+			name:     "removes a cron entry",
+			schedule: []string{"postgres", "lollipop", "@daily", "/var/backups"},
+		},
+		{
+			name:     "removes systemd units",
+			schedule: []string{"--scheduler", "systemd", "postgres", "lollipop", "@daily", "/var/backups"},
+		},
+		{
+			name:     "fails when no schedule is set",
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			scheduleDir := t.TempDir()
+			if len(tt.schedule) > 0 {
+				args := append([]string{"--schedule-dir", scheduleDir, "--user", "dokku"}, tt.schedule...)
+				e.run(&ServiceBackupScheduleCommand{Meta: e.meta(), Context: e.ctx}, 0, args...)
+			}
+
+			e.run(&ServiceBackupUnscheduleCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop")
+
+			entries, err := os.ReadDir(scheduleDir)
+			if err != nil {
+				t.Fatalf("failed to read schedule dir: %s", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("expected schedule files to be removed, got %d", len(entries))
+			}
+			if config := e.backupConfig("postgres", "lollipop"); config.Schedule.Directory != "" {
+				t.Errorf("expected schedule to be cleared, got %+v", config.Schedule)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package commands
+
+import "testing"
+
+func TestServiceBackupUnsetEncryptionCommand(t *testing.T) {
+	e := newTestEnv(t)
+	e.create("postgres", "lollipop")
+	_, recipient := e.identity()
+	e.run(&ServiceBackupSetEncryptionCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", recipient)
+
+	e.run(&ServiceBackupUnsetEncryptionCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+	if recipients := e.backupConfig("postgres", "lollipop").Encryption.Recipients; len(recipients) != 0 {
+		t.Errorf("expected recipients to be removed, got %v", recipients)
+	}
+}
@@ -0,0 +1,376 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/container"
+	"dokku-service/service"
+	"dokku-service/template"
+)
+
+type ServiceCloneCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceCloneCommand) Name() string {
+	return "service-clone"
+}
+
+func (c *ServiceCloneCommand) Synopsis() string {
+	return "service-clone command"
+}
+
+func (c *ServiceCloneCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceCloneCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"clone a service": fmt.Sprintf("%s %s postgres lollipop lollipop-copy", appName, c.Name()),
+	}
+}
+
+func (c *ServiceCloneCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the service to clone",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "new-name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceCloneCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceCloneCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceCloneCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceCloneCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceCloneCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	newServiceName := arguments["new-name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	for _, commandName := range []string{"export", "import"} {
+		if _, ok := config.Template.Commands[commandName]; !ok {
+			c.Ui.Error(fmt.Sprintf("%s service %s does not support %s command", serviceTemplate.Name, serviceName, commandName))
+			return 1
+		}
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if !containerExists {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not running", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Cloning %s service %s to %s", serviceTemplate.Name, serviceName, newServiceName))
+	createCommand := &ServiceCreateCommand{Meta: c.Meta, Context: c.Context}
+	createArgs := c.createArgs(config, serviceTemplate, newServiceName)
+	if exitCode := createCommand.Run(createArgs); exitCode != 0 {
+		c.Ui.Error(fmt.Sprintf("Failed to create %s service %s", serviceTemplate.Name, newServiceName))
+		return exitCode
+	}
+
+	newConfig, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        newServiceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	logger.LogHeader2(fmt.Sprintf("Copying data from %s to %s", serviceName, newServiceName))
+	transferred, err := c.copyData(config, containerName, newConfig, container.Name(container.NameInput{
+		ServiceName: newServiceName,
+		ServiceType: serviceTemplate.Name,
+	}))
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to copy data: %s", err.Error()))
+		c.destroyClone(serviceTemplate, newServiceName)
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("Copied %s", units.HumanSize(float64(transferred))))
+	return 0
+}
+
+// destroyClone removes a service whose data could not be copied, so the clone can be retried
+func (c *ServiceCloneCommand) destroyClone(serviceTemplate template.ServiceTemplate, newServiceName string) {
+	args := []string{"--data-root", c.dataRoot, "--force"}
+	if c.registryPath != "" {
+		args = append(args, "--registry-path", c.registryPath)
+	}
+	if c.trace {
+		args = append(args, "--trace")
+	}
+
+	destroyCommand := &ServiceDestroyCommand{Meta: c.Meta, Context: c.Context}
+	if exitCode := destroyCommand.Run(append(args, serviceTemplate.Name, newServiceName)); exitCode != 0 {
+		c.Ui.Error(fmt.Sprintf("Failed to remove %s service %s, destroy it before retrying the clone", serviceTemplate.Name, newServiceName))
+	}
+}
+
+// createArgs returns the service-create arguments that recreate a service's run config under a new name
+//
+// Secret arguments are omitted so that they are regenerated for the new service,
+// and the mapped name argument is set to the new name by service-create
+func (c *ServiceCloneCommand) createArgs(config service.ConfigOutput, serviceTemplate template.ServiceTemplate, newServiceName string) []string {
+	args := []string{"--data-root", c.dataRoot}
+	if c.registryPath != "" {
+		args = append(args, "--registry-path", c.registryPath)
+	}
+	if c.trace {
+		args = append(args, "--trace")
+	}
+
+	argumentKeys := map[string]bool{}
+	keys := []string{}
+	for key, argument := range config.Config.Arguments {
+		argumentKeys[strings.TrimSuffix(key, "_SECRET")] = true
+		if !argument.Override || argument.Secret || key == "IMAGE" || strings.HasSuffix(key, "_SECRET") {
+			continue
+		}
+		if key == serviceTemplate.MappedVariables[template.LABEL_MAPPED_NAME] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--argument", stringToStringValue(key, config.Config.Arguments[key].Value))
+	}
+
+	keys = []string{}
+	for key := range config.Config.EnvironmentVariables {
+		// variables derived from arguments are written by service-create itself
+		if !argumentKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--env", stringToStringValue(key, config.Config.EnvironmentVariables[key]))
+	}
+
+	for _, createFlag := range config.Config.ContainerCreateFlags {
+		args = append(args, "--container-create-flags", createFlag)
+	}
+	for _, buildFlag := range config.Config.ImageBuildFlags {
+		args = append(args, "--image-build-flags", buildFlag)
+	}
+	if config.Config.Image.Name != "" {
+		args = append(args, "--image-name", config.Config.Image.Name)
+	}
+	if config.Config.Image.Tag != "" {
+		args = append(args, "--image-tag", config.Config.Image.Tag)
+	}
+	for _, networkName := range config.Config.PostCreateNetworks {
+		args = append(args, "--post-create-network", networkName)
+	}
+	for _, networkName := range config.Config.PostStartNetworks {
+		args = append(args, "--post-start-network", networkName)
+	}
+	if config.Config.UseVolumes {
+		args = append(args, "--use-volumes")
+	}
+
+	return append(args, serviceTemplate.Name, newServiceName)
+}
+
+// copyData streams the export of one service container into the import of another
+func (c *ServiceCloneCommand) copyData(sourceConfig service.ConfigOutput, sourceContainerName string, destinationConfig service.ConfigOutput, destinationContainerName string) (int64, error) {
+	reader, writer := io.Pipe()
+	counter := &byteCounter{}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.Ui.Info(fmt.Sprintf("Copied %s", units.HumanSize(float64(counter.Count()))))
+			}
+		}
+	}()
+	defer close(done)
+
+	exportErr := make(chan error, 1)
+	go func() {
+		err := container.Execute(c.Context, container.ExecuteInput{
+			Name:         sourceContainerName,
+			CommandName:  "export",
+			ConfigOutput: sourceConfig,
+			StdOutWriter: io.MultiWriter(writer, counter),
+			Trace:        c.trace,
+		})
+		writer.CloseWithError(err)
+		exportErr <- err
+	}()
+
+	err := container.Execute(c.Context, container.ExecuteInput{
+		Name:         destinationContainerName,
+		CommandName:  "import",
+		ConfigOutput: destinationConfig,
+		Stdin:        reader,
+		Trace:        c.trace,
+	})
+	// unblock the export if the import exited without reading everything
+	reader.CloseWithError(io.ErrClosedPipe)
+	sourceErr := <-exportErr
+	// an export cut short by a failed import is reported as the import failure
+	if sourceErr != nil && (err == nil || !errors.Is(sourceErr, io.ErrClosedPipe)) {
+		return counter.Count(), fmt.Errorf("export failed: %w", sourceErr)
+	}
+	if err != nil {
+		return counter.Count(), fmt.Errorf("import failed: %w", err)
+	}
+
+	return counter.Count(), nil
+}
+
+// byteCounter is a writer that counts the bytes written to it
+type byteCounter struct {
+	count atomic.Int64
+}
+
+// Write records the number of bytes written
+func (b *byteCounter) Write(p []byte) (int, error) {
+	b.count.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Count returns the number of bytes written
+func (b *byteCounter) Count() int64 {
+	return b.count.Load()
+}
+
+// stringToStringValue formats a key and value for a pflag string-to-string flag
+//
+// Values are parsed as csv, so values containing commas or quotes must be quoted
+func stringToStringValue(key string, value string) string {
+	pair := fmt.Sprintf("%s=%s", key, value)
+	if !strings.ContainsAny(pair, `,"`) {
+		return pair
+	}
+
+	return `"` + strings.ReplaceAll(pair, `"`, `""`) + `"`
+}
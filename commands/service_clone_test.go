@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceCloneCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv, imported string)
+	}{
+		{
+			name: "creates a new service and copies the data",
+			args: []string{"postgres", "lollipop", "gumdrop"},
+			check: func(t *testing.T, e *testEnv, imported string) {
+				if !e.container("dokku.postgres.gumdrop").Running {
+					t.Errorf("expected cloned container to be running")
+				}
+				if imported != "lollipop data" {
+					t.Errorf("expected exported data to be imported, got %q", imported)
+				}
+
+				source := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+				clone := e.config("postgres", "gumdrop").Config.EnvironmentVariables
+				if clone["POSTGRES_PASSWORD"] == "" || clone["POSTGRES_PASSWORD"] == source {
+					t.Errorf("expected a new POSTGRES_PASSWORD for the clone")
+				}
+				if clone["POSTGRES_DB"] != "gumdrop" {
+					t.Errorf("expected POSTGRES_DB to be gumdrop, got %q", clone["POSTGRES_DB"])
+				}
+				if clone["POSTGRES_INITDB_ARGS"] != "--data-checksums" {
+					t.Errorf("expected environment variables to be copied, got %v", clone)
+				}
+			},
+		},
+		{
+			name:     "fails when the new service exists",
+			args:     []string{"postgres", "lollipop", "gumdrop"},
+			exitCode: 2,
+			setup: func(e *testEnv) {
+				e.create("postgres", "gumdrop")
+			},
+		},
+		{
+			name:     "fails when the service is not running",
+			args:     []string{"postgres", "lollipop", "gumdrop"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+			check: func(t *testing.T, e *testEnv, imported string) {
+				if _, ok := e.runtime.Containers["dokku.postgres.gumdrop"]; ok {
+					t.Errorf("expected no clone to be created")
+				}
+			},
+		},
+		{
+			name:     "reports a failed import and removes the clone",
+			args:     []string{"postgres", "lollipop", "gumdrop"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					switch options.Command[0] {
+					case "pg_dump":
+						// blocks until the import side of the pipe is closed
+						_, err := io.WriteString(options.Stdout, "lollipop data")
+						return err
+					case "pg_restore":
+						return &engine.ExitError{Code: 1, Stderr: "pg_restore: invalid archive"}
+					}
+					return nil
+				}
+			},
+			check: func(t *testing.T, e *testEnv, imported string) {
+				if !strings.Contains(e.stderr.String(), "import failed") || strings.Contains(e.stderr.String(), "export failed") {
+					t.Errorf("expected the import failure to be reported, got %s", e.stderr.String())
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.gumdrop"]; ok {
+					t.Errorf("expected the clone container to be removed")
+				}
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "gumdrop")); !os.IsNotExist(err) {
+					t.Errorf("expected the clone service to be removed, got %v", err)
+				}
+			},
+		},
+		{
+			name:     "reports a failed export",
+			args:     []string{"postgres", "lollipop", "gumdrop"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					switch options.Command[0] {
+					case "pg_dump":
+						return errors.New("connection lost")
+					case "pg_restore":
+						_, err := io.ReadAll(options.Stdin)
+						return err
+					}
+					return nil
+				}
+			},
+			check: func(t *testing.T, e *testEnv, imported string) {
+				if !strings.Contains(e.stderr.String(), "export failed: exec into container failed: connection lost") {
+					t.Errorf("expected the export failure to be reported, got %s", e.stderr.String())
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop", "--env", "POSTGRES_INITDB_ARGS=--data-checksums")
+
+			var mu sync.Mutex
+			imported := ""
+			e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+				switch options.Command[0] {
+				case "pg_dump":
+					_, err := io.WriteString(options.Stdout, "lollipop data")
+					return err
+				case "pg_restore":
+					b, err := io.ReadAll(options.Stdin)
+					mu.Lock()
+					imported = string(b)
+					mu.Unlock()
+					return err
+				}
+				return nil
+			}
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceCloneCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				tt.check(t, e, strings.TrimSpace(imported))
+			}
+		})
+	}
+}
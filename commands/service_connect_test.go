@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceConnectCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		exitCode int
+		setup    func(e *testEnv)
+		call     string
+	}{
+		{
+			name:     "executes the rendered connect command",
+			template: "postgres",
+			call:     "ContainerExec dokku.postgres.lollipop psql -h localhost -U postgres lollipop",
+		},
+		{
+			name:     "fails when the connect command fails",
+			template: "postgres",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					return &engine.ExitError{Code: 2}
+				}
+			},
+		},
+		{
+			name:     "fails when the container cannot be inspected",
+			template: "postgres",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Errors["ContainerInspect"] = errors.New("daemon unavailable")
+			},
+		},
+		{
+			name:     "fails when the container does not exist",
+			template: "postgres",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create(tt.template, "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceConnectCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.template, "lollipop")
+			if tt.call != "" && !e.runtime.HasCall(tt.call) {
+				t.Errorf("expected call %q, calls: %v", tt.call, e.runtime.Calls)
+			}
+		})
+	}
+}
+
+func TestServiceConnectUserPassword(t *testing.T) {
+	registryPath := t.TempDir()
+	writeTemplate(t, registryPath, "mariadb", strings.Join([]string{
+		"ARG IMAGE=mariadb:11.4",
+		"FROM ${IMAGE}",
+		"LABEL com.dokku.template.name=mariadb",
+		`LABEL com.dokku.template.description="A template for managing mariadb"`,
+		`LABEL com.dokku.template.config.commands.connect="mariadb -u root \"--password={{ .MARIADB_ROOT_PASSWORD }}\""`,
+		"LABEL com.dokku.template.config.ports.wait=3306",
+		"LABEL com.dokku.template.config.variables.mapped.root-password=MARIADB_ROOT_PASSWORD",
+		`ARG MARIADB_ROOT_PASSWORD_SECRET="{{ randAlphaNum 32 }}"`,
+		"",
+	}, "\n"))
+
+	e := newTestEnv(t)
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "mariadb", "lollipop", "--root-password", "a&b+c'd<e")
+	e.run(&ServiceConnectCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "mariadb", "lollipop")
+
+	call := "ContainerExec dokku.mariadb.lollipop mariadb -u root --password=a&b+c'd<e"
+	if !e.runtime.HasCall(call) {
+		t.Errorf("expected call %q, calls: %v", call, e.runtime.Calls)
+	}
+}
@@ -2,23 +2,20 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/josegonzalez/cli-skeleton/command"
-	"github.com/moby/moby/client"
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
 
 	"dokku-service/argument"
 	"dokku-service/container"
-	"dokku-service/healthcheck"
-	"dokku-service/hook"
 	"dokku-service/image"
 	"dokku-service/network"
+	"dokku-service/plan"
 	"dokku-service/service"
 	"dokku-service/template"
 	"dokku-service/volume"
@@ -41,9 +38,15 @@ type ServiceCreateCommand struct {
 	// dataRoot specifies the root directory for service data
 	dataRoot string
 
+	// dryRun specifies whether to output the planned changes instead of making them
+	dryRun bool
+
 	// env specifies the environment variables to pass to the service
 	env map[string]string
 
+	// format specifies the output format of the planned changes
+	format string
+
 	// imageName specifies the name to use when building the image
 	imageName string
 
@@ -53,6 +56,12 @@ type ServiceCreateCommand struct {
 	// imageBuildFlags specifies the flags to pass to the image build command
 	imageBuildFlags []string
 
+	// keepOnFailure specifies whether to keep the resources created by a failed create
+	keepOnFailure bool
+
+	// password specifies the user-level password for the service
+	password string
+
 	// postCreateNetwork specifies the network to attach to the container after creation
 	postCreateNetwork []string
 
@@ -62,6 +71,9 @@ type ServiceCreateCommand struct {
 	// registryPath specifies an override path to the registry
 	registryPath string
 
+	// rootPassword specifies the root-level password for the service
+	rootPassword string
+
 	// trace specifies whether to output trace information
 	trace bool
 
@@ -117,14 +129,19 @@ func (c *ServiceCreateCommand) FlagSet() *flag.FlagSet {
 	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
 	f.StringToStringVar(&c.arguments, "argument", map[string]string{}, "arguments to set when creating the service")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.dryRun, "dry-run", false, "output the planned changes instead of making them")
 	f.StringToStringVar(&c.env, "env", map[string]string{}, "env variables to set when creating the service")
+	f.StringVar(&c.format, "format", "text", "the output format to use for planned changes: [text, json]")
 	f.StringArrayVar(&c.containerCreateFlags, "container-create-flags", []string{}, "flags to pass to the container create command")
 	f.StringVar(&c.imageName, "image-name", "", "the name to use when building the image")
 	f.StringVar(&c.imageTag, "image-tag", "", "the tag to use when building the image")
 	f.StringArrayVar(&c.imageBuildFlags, "image-build-flags", []string{}, "flags to pass to the image build command")
+	f.BoolVar(&c.keepOnFailure, "keep-on-failure", false, "keep the resources created by a failed create for debugging")
+	f.StringVar(&c.password, "password", "", "override the user-level service password")
 	f.StringSliceVar(&c.postCreateNetwork, "post-create-network", []string{}, "network to attach to the container after creation")
 	f.StringSliceVar(&c.postStartNetwork, "post-start-network", []string{}, "network to attach to the container after start")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	f.StringVar(&c.rootPassword, "root-password", "", "override the root-level service password")
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.BoolVar(&c.useVolumes, "use-volumes", false, "use volumes instead of a directory on disk for data")
 	return f
@@ -133,7 +150,9 @@ func (c *ServiceCreateCommand) FlagSet() *flag.FlagSet {
 func (c *ServiceCreateCommand) AutocompleteFlags() complete.Flags {
 	return command.MergeAutocompleteFlags(
 		c.Meta.AutocompleteFlags(command.FlagSetClient),
-		complete.Flags{},
+		complete.Flags{
+			"--format": complete.PredictSet("text", "json"),
+		},
 	)
 }
 
@@ -153,6 +172,30 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != "text" && c.format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid format specified: %s", c.format))
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.dryRun {
+		return dryRun(dryRunInput{
+			Context: &c.Context,
+			Format:  c.format,
+			Meta:    &c.Meta,
+			Run: func() int {
+				return c.createService(arguments)
+			},
+			Title: fmt.Sprintf("Planned changes to create %s service %s", arguments["template"].StringValue(), arguments["name"].StringValue()),
+		})
+	}
+
+	return c.createService(arguments)
+}
+
+// createService creates a service from parsed arguments, returning the exit code.
+// Resources created before a failure are removed unless --keep-on-failure is specified.
+func (c *ServiceCreateCommand) createService(arguments map[string]command.Argument) (exitCode int) {
 	logger, ok := c.Ui.(*command.ZerologUi)
 	if !ok {
 		c.Ui.Error("Unable to fetch logger from cli")
@@ -175,18 +218,12 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		return 1
 	}
 
-	// todo: ensure the service doesn't exist at the specified path
-
 	logger.LogHeader1(fmt.Sprintf("Creating %s service %s", serviceTemplate.Name, serviceName))
 
 	containerName := container.Name(container.NameInput{
 		ServiceName: serviceName,
 		ServiceType: serviceTemplate.Name,
 	})
-	networkAlias := network.Alias(network.AliasInput{
-		ServiceName: serviceName,
-		ServiceType: serviceTemplate.Name,
-	})
 	ok, err = c.containerExists(containerName)
 	if err != nil {
 		c.Ui.Error("Failed to check for existing service: " + err.Error())
@@ -220,6 +257,7 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		})
 		if err != nil {
 			c.Ui.Error("Failed to check for network existence: " + err.Error())
+			return 1
 		}
 		if !ok {
 			c.Ui.Error(fmt.Sprintf("Missing post-start network: %s", networkName))
@@ -228,9 +266,19 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 	}
 
 	serviceRoot := fmt.Sprintf("%s/%s/%s", c.dataRoot, serviceTemplate.Name, serviceName)
-	if _, err := os.Stat(serviceRoot); err != nil && !errors.Is(err, os.ErrNotExist) {
-		c.Ui.Error("Service directory already exists but container is not running")
-		return 3
+	_, err = os.Stat(serviceRoot)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.Ui.Error("Failed to check for existing service directory: " + err.Error())
+		return 1
+	}
+	serviceRootExists := err == nil
+
+	// a service root is only reused when it holds nothing but data kept by service-destroy --keep-data
+	for _, settingsFile := range []string{".env", "config.json"} {
+		if _, err := os.Stat(fmt.Sprintf("%s/%s", serviceRoot, settingsFile)); err == nil {
+			c.Ui.Error("Service directory already exists but container is not running")
+			return 3
+		}
 	}
 
 	containerArgs, err := c.collectContainerArgs(serviceTemplate, serviceName)
@@ -238,6 +286,7 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		c.Ui.Error("Failed to collect arguments for service: " + err.Error())
 		return 1
 	}
+	redactSecretArguments(c.Context, containerArgs)
 
 	// todo: refactor to force-set the IMAGE argument or drop completely
 	if c.imageName != "" {
@@ -256,11 +305,50 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		ServiceName: serviceName,
 		ServiceType: serviceTemplate.Name,
 	})
+	creation := &serviceCreation{
+		containerName: containerName,
+		imageName:     imageName,
+		serviceRoot:   serviceRoot,
+		trace:         c.trace,
+	}
+	for _, sidecar := range serviceTemplate.Sidecars {
+		creation.sidecarContainerNames = append(creation.sidecarContainerNames, container.Name(container.NameInput{
+			ServiceName: serviceName,
+			ServiceType: serviceTemplate.Name,
+			Sidecar:     sidecar.Name,
+		}))
+	}
+	defer func() {
+		if exitCode == 0 {
+			return
+		}
+
+		if c.keepOnFailure {
+			logger.Warn(fmt.Sprintf("Keeping resources created for %s service %s, destroy the service to remove them", serviceTemplate.Name, serviceName))
+			return
+		}
+
+		logger.LogHeader1("Rolling back service create")
+		if err := creation.rollback(c.Context); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to roll back service create: %s", err.Error()))
+		}
+	}()
+
+	imageExists, err := image.Exists(c.Context, image.ExistsInput{
+		Name:  imageName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error("Failed to check for existing service image: " + err.Error())
+		return 1
+	}
+
 	logger.LogHeader2("Building base image from template")
 	if err := c.buildImage(imageName, containerArgs, serviceTemplate); err != nil {
 		c.Ui.Error("Failed to build image for service: " + err.Error())
 		return 1
 	}
+	creation.builtImage = !imageExists
 
 	logger.LogHeader2("Writing settings for service")
 	envFile := fmt.Sprintf("%s/.env", serviceRoot)
@@ -281,22 +369,23 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		envLines = append(envLines, fmt.Sprintf(`%s=%s`, key, value))
 	}
 
-	if err := os.MkdirAll(fmt.Sprintf("%s/%s", c.dataRoot, serviceTemplate.Name), os.ModePerm); err != nil {
+	if err := plan.MkdirAll(c.Context, fmt.Sprintf("%s/%s", c.dataRoot, serviceTemplate.Name), os.ModePerm); err != nil {
 		c.Ui.Error("Failed to create service directory: " + err.Error())
 		return 1
 	}
 
-	if err := os.MkdirAll(serviceRoot, os.ModePerm); err != nil {
+	if err := plan.MkdirAll(c.Context, serviceRoot, os.ModePerm); err != nil {
 		c.Ui.Error("Failed to create service directory: " + err.Error())
 		return 1
 	}
+	creation.createdServiceRoot = !serviceRootExists
 
-	if err := os.WriteFile(envFile, []byte(strings.Join(envLines, "\n")+"\n"), 0o666); err != nil {
+	creation.trackFiles(envFile, fmt.Sprintf("%s/config.json", serviceRoot), fmt.Sprintf("%s/ID", serviceRoot))
+	if err := plan.WriteFile(c.Context, envFile, []byte(strings.Join(envLines, "\n")+"\n"), 0o666); err != nil {
 		c.Ui.Error("Failed to write settings for service: " + err.Error())
 		return 1
 	}
 
-	configFile := fmt.Sprintf("%s/config.json", serviceRoot)
 	createConfig := service.ConfigOutput{
 		Config: service.RunConfig{
 			Arguments:            containerArgs,
@@ -315,125 +404,178 @@ func (c *ServiceCreateCommand) Run(args []string) int {
 		},
 		Template: serviceTemplate,
 	}
-	data, err := json.MarshalIndent(createConfig, "", "  ")
-	if err != nil {
-		c.Ui.Error("Failed to marshal create settings for service: " + err.Error())
-		return 1
-	}
-
-	if err := os.WriteFile(configFile, data, 0o666); err != nil {
+	if err := service.WriteConfig(c.Context, service.WriteConfigInput{
+		ConfigOutput: createConfig,
+	}); err != nil {
 		c.Ui.Error("Failed to write create settings for service: " + err.Error())
 		return 1
 	}
 
-	// todo: ensure volumes dont exist
-	logger.LogHeader2("Creating volumes")
-	var createdVolumes []volume.Volume
-	for _, volumeDescriptor := range serviceTemplate.Volumes {
-		volume, err := c.createVolume(serviceName, serviceTemplate, volumeDescriptor)
+	// volumes that already exist may hold data kept by service-destroy --keep-data, so only missing ones are rolled back
+	templateVolumes := append(append([]template.Volume{}, serviceTemplate.Volumes...), serviceTemplate.SidecarVolumes()...)
+	for _, volumeDescriptor := range templateVolumes {
+		volumeExists, err := c.volumeExists(serviceName, serviceTemplate, volumeDescriptor)
 		if err != nil {
-			c.Ui.Error("Failed to run volume for service: " + err.Error())
+			c.Ui.Error("Failed to check for volume existence: " + err.Error())
 			return 1
 		}
 
-		createdVolumes = append(createdVolumes, volume)
+		if c.useVolumes && !volumeExists {
+			creation.volumes = append(creation.volumes, c.resolveVolume(serviceName, serviceTemplate, volumeDescriptor).Source)
+		}
 	}
 
-	logger.LogHeader2("Executing pre-create hook")
-	if err := c.executeHook("pre-create", serviceTemplate.Hooks.PreCreate, serviceName, createdVolumes, serviceTemplate); err != nil {
-		c.Ui.Error("Failed to execute pre-create hook for service: " + err.Error())
-		return 1
+	containerInput := serviceContainerInput{
+		Config:      createConfig,
+		ImageName:   imageName,
+		Logger:      logger,
+		ServiceName: serviceName,
+		Template:    serviceTemplate,
+		Trace:       c.trace,
 	}
 
-	logger.LogHeader2("Creating container")
-	err = container.Create(c.Context, container.CreateInput{
-		CreateFlags:   c.containerCreateFlags,
-		ContainerName: containerName,
-		EnvFile:       envFile,
-		ImageName:     imageName,
-		ServiceRoot:   serviceRoot,
-		Trace:         c.trace,
-		UseVolumes:    c.useVolumes,
-		Volumes:       createdVolumes,
-	})
+	creation.createdContainer = true
+	createdVolumes, err := createServiceContainer(c.Context, containerInput)
 	if err != nil {
-		c.Ui.Error("Failed to create container for service: " + err.Error())
-		return 1
-	}
-
-	logger.LogHeader2("Attaching container to post-create networks")
-	for _, networkName := range c.postCreateNetwork {
-		if err := network.Connect(c.Context, network.ConnectInput{
-			ContainerName: containerName,
-			NetworkAlias:  networkAlias,
-			NetworkName:   networkName,
-			Trace:         c.trace,
-		}); err != nil {
-			c.Ui.Error("Failed to attach container to network: " + err.Error())
-			return 1
-		}
-	}
-
-	// todo: attach container to container-specific network
-	logger.LogHeader2("Executing post-create hook")
-	if err := c.executeHook("post-create", serviceTemplate.Hooks.PostCreate, serviceName, createdVolumes, serviceTemplate); err != nil {
-		c.Ui.Error("Failed to execute post-create hook for service: " + err.Error())
+		c.Ui.Error(err.Error())
 		return 1
 	}
 
-	logger.LogHeader2("Starting container")
-	if err := c.startContainer(containerName); err != nil {
+	if err := startServiceContainer(c.Context, containerInput, createdVolumes); err != nil {
 		c.Ui.Error(err.Error())
 		return 1
 	}
 
-	logger.LogHeader2("Waiting for service to be ready")
-	cli, err := client.NewClientWithOpts(
-		client.FromEnv,
-		client.WithAPIVersionNegotiation(),
-	)
-	if err != nil {
-		logger.Error(err.Error())
-		return 1
-	}
+	return 0
+}
 
-	container, err := cli.ContainerInspect(c.Context, containerName)
-	if err != nil {
-		logger.Error(err.Error())
-		return 1
+// serviceCreation tracks the resources created during a service create so they can be removed on failure
+type serviceCreation struct {
+	// builtImage specifies whether the service image was built rather than already existing
+	builtImage bool
+
+	// containerName is the name of the service container
+	containerName string
+
+	// createdContainer specifies whether the service container may have been created
+	createdContainer bool
+
+	// createdServiceRoot specifies whether the service root was created rather than reused
+	createdServiceRoot bool
+
+	// files are the files written to the service root that did not exist beforehand
+	files []string
+
+	// imageName is the name of the service image
+	imageName string
+
+	// serviceRoot is the directory the service data is stored in
+	serviceRoot string
+
+	// sidecarContainerNames are the names of the sidecar containers, in the order they are started
+	sidecarContainerNames []string
+
+	// trace specifies whether to output trace information
+	trace bool
+
+	// volumes are the names of the volumes that did not exist before the create
+	volumes []string
+}
+
+// trackFiles records the files that do not exist yet, so existing files are never removed on failure
+func (s *serviceCreation) trackFiles(paths ...string) {
+	for _, path := range paths {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			s.files = append(s.files, path)
+		}
 	}
-	if err := healthcheck.ListeningCheck(c.Context, healthcheck.ListeningCheckInput{
-		Container:    container,
-		NetworkAlias: networkAlias,
-		Ports:        serviceTemplate.Ports.Wait,
-		Timeout:      5,
-		Trace:        c.trace,
-		Wait:         1,
-	}); err != nil {
-		c.Ui.Error("Failed to wait for service to be ready: " + err.Error())
-		return 1
+}
+
+// rollback removes the created resources in the reverse order they were created,
+// continuing past failures so as much as possible is cleaned up
+func (s *serviceCreation) rollback(ctx context.Context) error {
+	var errs []error
+	if s.createdContainer {
+		containerNames := []string{}
+		for i := len(s.sidecarContainerNames) - 1; i >= 0; i-- {
+			containerNames = append(containerNames, s.sidecarContainerNames[i])
+		}
+		containerNames = append(containerNames, s.containerName)
+
+		for _, containerName := range containerNames {
+			exists, err := container.Exists(ctx, container.ExistsInput{
+				Name:  containerName,
+				Trace: s.trace,
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to check for container existence: %w", err))
+			}
+
+			if !exists {
+				continue
+			}
+
+			if err := container.Stop(ctx, container.StopInput{
+				Name:  containerName,
+				Trace: s.trace,
+			}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to stop container: %w", err))
+			}
+
+			if err := container.Destroy(ctx, container.DestroyInput{
+				Name:  containerName,
+				Trace: s.trace,
+			}); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
 
-	logger.LogHeader2("Attaching container to post-start networks")
-	for _, networkName := range c.postStartNetwork {
-		if err := network.Connect(c.Context, network.ConnectInput{
-			ContainerName: containerName,
-			NetworkAlias:  networkAlias,
-			NetworkName:   networkName,
-			Trace:         c.trace,
+	for i := len(s.volumes) - 1; i >= 0; i-- {
+		// volumes are tracked before they are created, so a failure may leave some uncreated
+		exists, err := volume.Exists(ctx, volume.ExistsInput{
+			Name:  s.volumes[i],
+			Trace: s.trace,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check for volume existence: %w", err))
+			continue
+		}
+
+		if !exists {
+			continue
+		}
+
+		if err := volume.Remove(ctx, volume.RemoveInput{
+			Name:  s.volumes[i],
+			Trace: s.trace,
 		}); err != nil {
-			c.Ui.Error("Failed to attach container to network: " + err.Error())
-			return 1
+			errs = append(errs, err)
 		}
 	}
 
-	logger.LogHeader2("Executing post-start hook")
-	if err := c.executeHook("post-start", serviceTemplate.Hooks.PostStart, serviceName, createdVolumes, serviceTemplate); err != nil {
-		c.Ui.Error("Failed to execute post-start hook for service: " + err.Error())
-		return 1
+	if s.createdServiceRoot {
+		if err := plan.RemoveAll(ctx, s.serviceRoot); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove service directory: %w", err))
+		}
+	} else {
+		for i := len(s.files) - 1; i >= 0; i-- {
+			if err := plan.RemoveAll(ctx, s.files[i]); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove service file: %w", err))
+			}
+		}
 	}
 
-	return 0
+	if s.builtImage {
+		if err := image.Remove(ctx, image.RemoveInput{
+			Name:  s.imageName,
+			Trace: s.trace,
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (c *ServiceCreateCommand) containerExists(containerName string) (bool, error) {
@@ -453,36 +595,27 @@ func (c *ServiceCreateCommand) buildImage(imageName string, containerArgs map[st
 	})
 }
 
-func (c *ServiceCreateCommand) executeHook(name string, hookExists bool, serviceName string, volumes []volume.Volume, template template.ServiceTemplate) error {
-	return hook.Execute(c.Context, hook.ExecuteInput{
-		DataRoot:    c.dataRoot,
-		Exists:      hookExists,
-		Name:        name,
-		ServiceName: serviceName,
-		Template:    template,
-		Volumes:     volumes,
-		Trace:       c.trace,
-	})
-}
-
-func (c *ServiceCreateCommand) startContainer(containerName string) error {
-	return container.Start(c.Context, container.StartInput{
-		Name:  containerName,
-		Trace: c.trace,
-	})
-}
-
-func (c *ServiceCreateCommand) createVolume(serviceName string, template template.ServiceTemplate, volumeDescriptor template.Volume) (v volume.Volume, err error) {
-	return volume.Create(c.Context, volume.CreateInput{
+func (c *ServiceCreateCommand) resolveVolume(serviceName string, template template.ServiceTemplate, volumeDescriptor template.Volume) volume.Volume {
+	return volume.Resolve(volume.ResolveInput{
 		DataRoot:         c.dataRoot,
 		ServiceName:      serviceName,
 		Template:         template,
-		Trace:            c.trace,
 		UseVolumes:       c.useVolumes,
 		VolumeDescriptor: volumeDescriptor,
 	})
 }
 
+func (c *ServiceCreateCommand) volumeExists(serviceName string, template template.ServiceTemplate, volumeDescriptor template.Volume) (bool, error) {
+	if !c.useVolumes {
+		return false, nil
+	}
+
+	return volume.Exists(c.Context, volume.ExistsInput{
+		Name:  c.resolveVolume(serviceName, template, volumeDescriptor).Source,
+		Trace: c.trace,
+	})
+}
+
 func (c *ServiceCreateCommand) collectContainerArgs(serviceTemplate template.ServiceTemplate, serviceName string) (map[string]argument.Argument, error) {
 	arguments := map[string]argument.Argument{}
 
@@ -506,6 +639,37 @@ func (c *ServiceCreateCommand) collectContainerArgs(serviceTemplate template.Ser
 		}
 	}
 
+	// passwords are routed to whichever argument the template maps them to
+	mappedPasswords := []struct {
+		flag  string
+		label string
+		value string
+	}{
+		{flag: "password", label: template.LABEL_MAPPED_PASSWORD, value: c.password},
+		{flag: "root-password", label: template.LABEL_MAPPED_ROOT_PASSWORD, value: c.rootPassword},
+	}
+	for _, mappedPassword := range mappedPasswords {
+		if mappedPassword.value == "" {
+			continue
+		}
+
+		key, err := mappedArgumentKey(serviceTemplate, mappedPassword.label, arguments)
+		if err != nil {
+			return arguments, fmt.Errorf("unable to use --%s: %w", mappedPassword.flag, err)
+		}
+
+		if arguments[key].Override {
+			return arguments, fmt.Errorf("--%s cannot be combined with --argument %s", mappedPassword.flag, key)
+		}
+
+		arguments[key] = argument.Argument{
+			Key:      key,
+			Value:    mappedPassword.value,
+			Override: true,
+			Secret:   true,
+		}
+	}
+
 	for key, argument := range arguments {
 		// special case any "name" argument
 		if argument.Key == serviceTemplate.MappedVariables[template.LABEL_MAPPED_NAME] {
@@ -518,5 +682,34 @@ func (c *ServiceCreateCommand) collectContainerArgs(serviceTemplate template.Ser
 		}
 	}
 
+	for _, templateArg := range serviceTemplate.Arguments {
+		argument := arguments[templateArg.Name]
+		// generated values are not user-supplied, so are left to the template
+		if templateArg.IsVariable && !argument.Override {
+			continue
+		}
+
+		if err := templateArg.ValidateValue(argument.Value); err != nil {
+			return arguments, fmt.Errorf("invalid value for service argument %s: %w", argument.Key, err)
+		}
+	}
+
 	return arguments, nil
 }
+
+// mappedArgumentKey returns the key of the argument a template maps a label to,
+// accepting arguments with or without the _SECRET suffix
+func mappedArgumentKey(serviceTemplate template.ServiceTemplate, label string, arguments map[string]argument.Argument) (string, error) {
+	mappedVariable := serviceTemplate.MappedVariables[label]
+	if mappedVariable == "" {
+		return "", fmt.Errorf("%s template does not map %s", serviceTemplate.Name, label)
+	}
+
+	for _, key := range []string{mappedVariable, mappedVariable + "_SECRET"} {
+		if _, ok := arguments[key]; ok {
+			return key, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s template maps %s to missing argument %s", serviceTemplate.Name, label, mappedVariable)
+}
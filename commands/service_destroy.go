@@ -4,12 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/josegonzalez/cli-skeleton/command"
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
+	"golang.org/x/term"
 
+	"dokku-service/ambassador"
 	"dokku-service/container"
+	"dokku-service/image"
+	"dokku-service/network"
+	"dokku-service/plan"
+	"dokku-service/service"
+	"dokku-service/volume"
 )
 
 type ServiceDestroyCommand struct {
@@ -21,6 +31,18 @@ type ServiceDestroyCommand struct {
 	// dataRoot specifies the root directory for service data
 	dataRoot string
 
+	// dryRun specifies whether to output the planned changes instead of making them
+	dryRun bool
+
+	// force specifies whether to destroy without confirmation, even if apps are linked
+	force bool
+
+	// format specifies the output format of the planned changes
+	format string
+
+	// keepData specifies whether to keep the service data
+	keepData bool
+
 	// registryPath specifies an override path to the registry
 	registryPath string
 
@@ -46,7 +68,9 @@ func (c *ServiceDestroyCommand) Help() string {
 func (c *ServiceDestroyCommand) Examples() map[string]string {
 	appName := os.Getenv("CLI_APP_NAME")
 	return map[string]string{
-		"Does nothing": fmt.Sprintf("%s %s", appName, c.Name()),
+		"destroy a service":                      fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+		"destroy a service without confirmation": fmt.Sprintf("%s %s --force postgres lollipop", appName, c.Name()),
+		"destroy a service but keep its data":    fmt.Sprintf("%s %s --keep-data postgres lollipop", appName, c.Name()),
 	}
 }
 
@@ -78,6 +102,10 @@ func (c *ServiceDestroyCommand) ParsedArguments(args []string) (map[string]comma
 func (c *ServiceDestroyCommand) FlagSet() *flag.FlagSet {
 	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.dryRun, "dry-run", false, "output the planned changes instead of making them")
+	f.BoolVarP(&c.force, "force", "f", false, "destroy without confirmation, even if apps are linked")
+	f.StringVar(&c.format, "format", "text", "the output format to use for planned changes: [text, json]")
+	f.BoolVar(&c.keepData, "keep-data", false, "keep the service data volumes or directories")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.BoolVar(&c.useVolumes, "use-volumes", false, "use volumes instead of a directory on disk for data")
@@ -87,7 +115,9 @@ func (c *ServiceDestroyCommand) FlagSet() *flag.FlagSet {
 func (c *ServiceDestroyCommand) AutocompleteFlags() complete.Flags {
 	return command.MergeAutocompleteFlags(
 		c.Meta.AutocompleteFlags(command.FlagSetClient),
-		complete.Flags{},
+		complete.Flags{
+			"--format": complete.PredictSet("text", "json"),
+		},
 	)
 }
 
@@ -107,6 +137,29 @@ func (c *ServiceDestroyCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != "text" && c.format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid format specified: %s", c.format))
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.dryRun {
+		return dryRun(dryRunInput{
+			Context: &c.Context,
+			Format:  c.format,
+			Meta:    &c.Meta,
+			Run: func() int {
+				return c.destroyService(arguments)
+			},
+			Title: fmt.Sprintf("Planned changes to destroy %s service %s", arguments["template"].StringValue(), arguments["name"].StringValue()),
+		})
+	}
+
+	return c.destroyService(arguments)
+}
+
+// destroyService destroys a service from parsed arguments, returning the exit code
+func (c *ServiceDestroyCommand) destroyService(arguments map[string]command.Argument) int {
 	logger, ok := c.Ui.(*command.ZerologUi)
 	if !ok {
 		c.Ui.Error("Unable to fetch logger from cli")
@@ -128,7 +181,80 @@ func (c *ServiceDestroyCommand) Run(args []string) int {
 	}
 
 	serviceName := arguments["name"].StringValue()
+	serviceRoot := fmt.Sprintf("%s/%s/%s", c.dataRoot, serviceTemplate.Name, serviceName)
+	if _, err := os.Stat(serviceRoot); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for service data existence: %s", err.Error()))
+		containerName := container.Name(container.NameInput{
+			ServiceName: serviceName,
+			ServiceType: serviceTemplate.Name,
+		})
+		if exists, _ := container.Exists(c.Context, container.ExistsInput{
+			Name:  containerName,
+			Trace: c.trace,
+		}); exists {
+			c.Ui.Error(fmt.Sprintf("Please manually cleanup the service container: %s", containerName))
+		}
+
+		return 1
+	}
+
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service links: %s", err.Error()))
+		return 1
+	}
+
+	linkedApps := []string{}
+	for _, link := range links {
+		linkedApps = append(linkedApps, link.App)
+	}
+	if len(linkedApps) > 0 && !c.force {
+		c.Ui.Error(fmt.Sprintf("%s service %s is linked to %s, unlink the apps or specify --force", serviceTemplate.Name, serviceName, strings.Join(linkedApps, ", ")))
+		return 1
+	}
+
+	if !c.force && !c.dryRun && stdinIsTerminal() {
+		answer, err := c.Ui.Ask(fmt.Sprintf("This will permanently destroy %s service %s. To proceed, type %q:", serviceTemplate.Name, serviceName, serviceName))
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read confirmation: %s", err.Error()))
+			return 1
+		}
+		if strings.TrimSpace(answer) != serviceName {
+			c.Ui.Error("Confirmation did not match the service name, aborting")
+			return 1
+		}
+	}
+
 	logger.LogHeader1(fmt.Sprintf("Destroying %s service %s", serviceTemplate.Name, serviceName))
+	if len(linkedApps) > 0 {
+		logger.Warn(fmt.Sprintf("Exported variables remain set on linked apps: %s", strings.Join(linkedApps, ", ")))
+	}
+
+	if err := removeSidecarContainers(c.Context, serviceContainerInput{
+		Config:      config,
+		Logger:      logger,
+		ServiceName: serviceName,
+		Template:    serviceTemplate,
+		Trace:       c.trace,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
 
 	containerName := container.Name(container.NameInput{
 		ServiceName: serviceName,
@@ -143,45 +269,188 @@ func (c *ServiceDestroyCommand) Run(args []string) int {
 		return 1
 	}
 
-	serviceRoot := fmt.Sprintf("%s/%s/%s", c.dataRoot, serviceTemplate.Name, serviceName)
-	if _, err := os.Stat(serviceRoot); err != nil {
-		// todo: handle deleting the service container
-		c.Ui.Error(fmt.Sprintf("Failed to check for service data existence: %s", err.Error()))
-		if containerExists {
-			c.Ui.Error(fmt.Sprintf("Please manually cleanup the service container: %s", containerName))
-		}
-
+	ambassadorExists, err := ambassador.Exists(c.Context, ambassador.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for ambassador existence: %s", err.Error()))
 		return 1
 	}
 
-	var destroyErr error
+	if ambassadorExists {
+		logger.LogHeader2("Removing ambassador")
+		if err := destroyAmbassador(c.Context, containerName, c.trace); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
 	if containerExists {
-		stopErr := container.Stop(c.Context, container.StopInput{
+		dockerContainer, err := container.Inspect(c.Context, container.InspectInput{
 			Name:  containerName,
 			Trace: c.trace,
 		})
-		if stopErr != nil {
-			c.Ui.Error(fmt.Sprintf("Failed to stop service container: %s", stopErr.Error()))
+		if err != nil {
+			c.Ui.Error(err.Error())
 			return 1
 		}
 
-		destroyErr = container.Destroy(c.Context, container.DestroyInput{
+		logger.LogHeader2("Detaching container from networks")
+		attachedNetworks := serviceNetworks(config, links, "")
+		networkNames := []string{}
+		for networkName := range attachedNetworks {
+			networkNames = append(networkNames, networkName)
+		}
+		sort.Strings(networkNames)
+		for _, networkName := range networkNames {
+			if _, ok := dockerContainer.Networks[networkName]; !ok {
+				continue
+			}
+
+			if err := network.Disconnect(c.Context, network.DisconnectInput{
+				ContainerName: containerName,
+				NetworkName:   networkName,
+				Trace:         c.trace,
+			}); err != nil {
+				c.Ui.Error("Failed to detach container from network: " + err.Error())
+				return 1
+			}
+		}
+
+		logger.LogHeader2("Removing container")
+		if err := container.Stop(c.Context, container.StopInput{
 			Name:  containerName,
 			Trace: c.trace,
-		})
+		}); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to stop service container: %s", err.Error()))
+			return 1
+		}
+
+		if err := container.Destroy(c.Context, container.DestroyInput{
+			Name:  containerName,
+			Trace: c.trace,
+		}); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to destroy service container: %s", err.Error()))
+			return 1
+		}
 	}
 
-	// todo: remove any attached volumes
-	removeErr := os.RemoveAll(serviceRoot)
-	if removeErr != nil {
-		c.Ui.Error(fmt.Sprintf("Failed to remove service data: %s", removeErr.Error()))
+	useVolumes := config.Config.UseVolumes || c.useVolumes
+	volumes := []volume.Volume{}
+	for _, volumeDescriptor := range append(config.Template.Volumes, config.Template.SidecarVolumes()...) {
+		volumes = append(volumes, volume.Resolve(volume.ResolveInput{
+			DataRoot:         c.dataRoot,
+			ServiceName:      serviceName,
+			Template:         config.Template,
+			UseVolumes:       useVolumes,
+			VolumeDescriptor: volumeDescriptor,
+		}))
 	}
-	if destroyErr != nil {
-		c.Ui.Error(fmt.Sprintf("Failed to destroy service container: %s", destroyErr.Error()))
+
+	if useVolumes && !c.keepData {
+		logger.LogHeader2("Removing volumes")
+		for _, v := range volumes {
+			exists, err := volume.Exists(c.Context, volume.ExistsInput{
+				Name:  v.Source,
+				Trace: c.trace,
+			})
+			if err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+			if !exists {
+				continue
+			}
+
+			if err := volume.Remove(c.Context, volume.RemoveInput{
+				Name:  v.Source,
+				Trace: c.trace,
+			}); err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+		}
 	}
-	if removeErr != nil || destroyErr != nil {
+
+	imageName := image.Name(image.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	imageExists, err := image.Exists(c.Context, image.ExistsInput{
+		Name:  imageName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
 		return 1
 	}
 
+	if imageExists {
+		logger.LogHeader2("Removing image")
+		if err := image.Remove(c.Context, image.RemoveInput{
+			Name:  imageName,
+			Trace: c.trace,
+		}); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if !c.keepData {
+		logger.LogHeader2("Removing service data")
+		if err := plan.RemoveAll(c.Context, serviceRoot); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to remove service data: %s", err.Error()))
+			return 1
+		}
+
+		return 0
+	}
+
+	// bind mounted volumes live in the service root, so only the service settings are removed
+	logger.LogHeader2("Removing service settings")
+	keep := map[string]bool{}
+	if !useVolumes {
+		for _, v := range volumes {
+			keep[filepath.Clean(v.Source)] = true
+		}
+	}
+
+	entries, err := os.ReadDir(serviceRoot)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read service data: %s", err.Error()))
+		return 1
+	}
+	for _, entry := range entries {
+		path := filepath.Join(serviceRoot, entry.Name())
+		if keep[path] {
+			continue
+		}
+
+		if err := plan.RemoveAll(c.Context, path); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to remove service data: %s", err.Error()))
+			return 1
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Kept data for %s service %s in %s", serviceTemplate.Name, serviceName, keptDataLocation(serviceRoot, volumes, useVolumes)))
 	return 0
 }
+
+// keptDataLocation returns where the data of a destroyed service was kept
+func keptDataLocation(serviceRoot string, volumes []volume.Volume, useVolumes bool) string {
+	if !useVolumes {
+		return serviceRoot
+	}
+
+	names := []string{}
+	for _, v := range volumes {
+		names = append(names, v.Source)
+	}
+	return strings.Join(names, ", ")
+}
+
+// stdinIsTerminal returns whether stdin is attached to a terminal, in which case destructive actions are confirmed
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
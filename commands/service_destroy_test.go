@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mitchellh/cli"
+
+	"dokku-service/engine"
+)
+
+func TestServiceDestroyCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		input    string
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "removes the service container and data",
+			args: []string{"postgres", "lollipop"},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; ok {
+					t.Errorf("expected container to be removed")
+				}
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "lollipop")); !os.IsNotExist(err) {
+					t.Errorf("expected service data to be removed, got %v", err)
+				}
+				if e.runtime.Images["dokku/service-postgres:lollipop"] {
+					t.Errorf("expected service image to be removed")
+				}
+			},
+		},
+		{
+			name: "removes named volumes",
+			args: []string{"postgres", "volumed"},
+			setup: func(e *testEnv) {
+				e.create("postgres", "volumed", "--use-volumes")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.runtime.Volumes) != 0 {
+					t.Errorf("expected volumes to be removed, got %v", e.runtime.Volumes)
+				}
+			},
+		},
+		{
+			name: "keeps named volumes with --keep-data",
+			args: []string{"postgres", "volumed", "--keep-data"},
+			setup: func(e *testEnv) {
+				e.create("postgres", "volumed", "--use-volumes")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Volumes["dokku.postgres.volumed.var_lib_postgresql_data"]; !ok {
+					t.Errorf("expected volume to be kept, got %v", e.runtime.Volumes)
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.volumed"]; ok {
+					t.Errorf("expected container to be removed")
+				}
+			},
+		},
+		{
+			name: "keeps bind mounted data with --keep-data",
+			args: []string{"postgres", "lollipop", "--keep-data"},
+			check: func(t *testing.T, e *testEnv) {
+				serviceRoot := filepath.Join(e.dataRoot, "postgres", "lollipop")
+				if _, err := os.Stat(filepath.Join(serviceRoot, "VAR_LIB_POSTGRESQL_DATA")); err != nil {
+					t.Errorf("expected data directory to be kept: %s", err)
+				}
+				if _, err := os.Stat(filepath.Join(serviceRoot, "config.json")); !os.IsNotExist(err) {
+					t.Errorf("expected service config to be removed, got %v", err)
+				}
+
+				e.create("postgres", "lollipop")
+			},
+		},
+		{
+			name: "removes the ambassador",
+			args: []string{"postgres", "lollipop"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "15432")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.runtime.Containers) != 0 {
+					t.Errorf("expected no containers, got %v", e.runtime.ContainerNames())
+				}
+			},
+		},
+		{
+			name: "detaches the container from service networks",
+			args: []string{"postgres", "networked"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["backend"] = true
+				e.create("postgres", "networked", "--post-create-network", "backend")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("NetworkDisconnect backend dokku.postgres.networked") {
+					t.Errorf("expected container to be detached from backend, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+		{
+			name:     "refuses to destroy a linked service",
+			args:     []string{"postgres", "lollipop"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stderr.String(), "is linked to playground") {
+					t.Errorf("expected linked apps error, got %s", e.stderr.String())
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; !ok {
+					t.Errorf("expected container to be kept")
+				}
+			},
+		},
+		{
+			name: "destroys a linked service with --force",
+			args: []string{"postgres", "lollipop", "--force"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; ok {
+					t.Errorf("expected container to be removed")
+				}
+			},
+		},
+		{
+			name:  "destroys when the confirmation matches the service name",
+			args:  []string{"postgres", "lollipop"},
+			input: "lollipop\n",
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; ok {
+					t.Errorf("expected container to be removed")
+				}
+			},
+		},
+		{
+			name:     "aborts when the confirmation does not match",
+			args:     []string{"postgres", "lollipop"},
+			exitCode: 1,
+			input:    "gumdrop\n",
+			check: func(t *testing.T, e *testEnv) {
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "lollipop")); err != nil {
+					t.Errorf("expected service data to be kept: %s", err)
+				}
+			},
+		},
+		{
+			name: "removes the service data when the container is missing",
+			args: []string{"postgres", "lollipop"},
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "lollipop")); !os.IsNotExist(err) {
+					t.Errorf("expected service data to be removed, got %v", err)
+				}
+			},
+		},
+		{
+			name:     "fails for an unknown service",
+			args:     []string{"postgres", "unknown"},
+			exitCode: 1,
+		},
+		{
+			name: "outputs a plan without removing anything with --dry-run",
+			args: []string{"postgres", "lollipop", "--dry-run", "--format", "json"},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to keep running")
+				}
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "lollipop")); err != nil {
+					t.Errorf("expected service data to be kept: %s", err)
+				}
+
+				stdout := e.stdout.String()
+				for _, action := range []string{`"action": "container stop"`, `"action": "container rm"`, `"action": "path remove"`} {
+					if !strings.Contains(stdout, action) {
+						t.Errorf("expected plan to contain %s, got %s", action, stdout)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			meta := e.meta()
+			if tt.input != "" {
+				stdinIsTerminal = func() bool { return true }
+				t.Cleanup(func() {
+					stdinIsTerminal = func() bool { return false }
+				})
+				meta.Ui.(*command.ZerologUi).Ui.(*cli.MockUi).InputReader = strings.NewReader(tt.input)
+			}
+
+			e.run(&ServiceDestroyCommand{Meta: meta, Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceEnterCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		exitCode int
+		setup    func(e *testEnv)
+		call     string
+	}{
+		{
+			name:     "executes the default shell",
+			template: "postgres",
+			call:     "ContainerExec dokku.postgres.lollipop /bin/bash",
+		},
+		{
+			name:     "executes the template enter command",
+			template: "redis",
+			call:     "ContainerExec dokku.redis.lollipop /bin/sh",
+		},
+		{
+			name:     "succeeds when the shell exits non-zero",
+			template: "postgres",
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					return &engine.ExitError{Code: 130}
+				}
+			},
+			call: "ContainerExec dokku.postgres.lollipop /bin/bash",
+		},
+		{
+			name:     "fails when the container is not running",
+			template: "postgres",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServicePauseCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+		},
+		{
+			name:     "fails when the container does not exist",
+			template: "postgres",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create(tt.template, "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceEnterCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.template, "lollipop")
+			if tt.call != "" && !e.runtime.HasCall(tt.call) {
+				t.Errorf("expected call %q, calls: %v", tt.call, e.runtime.Calls)
+			}
+		})
+	}
+}
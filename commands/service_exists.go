@@ -77,7 +77,6 @@ func (c *ServiceExistsCommand) FlagSet() *flag.FlagSet {
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
-	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	return f
 }
 
@@ -0,0 +1,34 @@
+package commands
+
+import "testing"
+
+func TestServiceExistsCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{
+			name: "succeeds for an existing service",
+			args: []string{"postgres", "lollipop"},
+		},
+		{
+			name:     "fails for an unknown service",
+			args:     []string{"postgres", "unknown"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails for a service of another template",
+			args:     []string{"redis", "lollipop"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.run(&ServiceExistsCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
 
+	"dokku-service/backup"
 	"dokku-service/container"
 	"dokku-service/service"
 )
@@ -23,6 +24,9 @@ type ServiceExportCommand struct {
 	// dataRoot specifies the root directory for service data
 	dataRoot string
 
+	// encrypt specifies whether to encrypt the exported data to the backup recipients
+	encrypt bool
+
 	// fileHandle specifies the file handle for the exported data
 	fileHandle string
 
@@ -82,6 +86,7 @@ func (c *ServiceExportCommand) FlagSet() *flag.FlagSet {
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	f.BoolVar(&c.encrypt, "encrypt", false, "encrypt the exported data to the backup recipients")
 	f.StringVarP(&c.fileHandle, "file", "f", "", "the file handle for the exported data")
 	return f
 }
@@ -173,6 +178,32 @@ func (c *ServiceExportCommand) Run(args []string) int {
 		stdoutWriter = file
 	}
 
+	var encryptWriter io.WriteCloser
+	if c.encrypt {
+		backupConfig, err := backup.Config(c.Context, backup.ConfigInput{
+			ServiceRoot: config.Config.ServiceRoot,
+		})
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		if len(backupConfig.Encryption.Recipients) == 0 {
+			c.Ui.Error(fmt.Sprintf("No encryption recipients set for %s service %s", serviceTemplate.Name, serviceName))
+			return 1
+		}
+
+		encryptWriter, err = backup.Encrypt(backup.EncryptInput{
+			Recipients: backupConfig.Encryption.Recipients,
+			Writer:     stdoutWriter,
+		})
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		stdoutWriter = encryptWriter
+	}
+
 	err = container.Execute(c.Context, container.ExecuteInput{
 		Name:         containerName,
 		CommandName:  "export",
@@ -185,5 +216,12 @@ func (c *ServiceExportCommand) Run(args []string) int {
 		return 1
 	}
 
+	if encryptWriter != nil {
+		if err := encryptWriter.Close(); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to encrypt data: %s", err.Error()))
+			return 1
+		}
+	}
+
 	return 0
 }
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+
+	"dokku-service/engine"
+)
+
+func TestServiceExportCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		encrypt  bool
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv, output string, identityFile string)
+	}{
+		{
+			name: "writes the exported data to a file",
+			check: func(t *testing.T, e *testEnv, output string, identityFile string) {
+				data, err := os.ReadFile(output)
+				if err != nil || string(data) != "lollipop data" {
+					t.Errorf("expected exported data in %s, got %q (%v)", output, data, err)
+				}
+				if !e.runtime.HasCall("ContainerExec dokku.postgres.lollipop pg_dump -Fc --no-acl --no-owner -h localhost -U postgres -w lollipop") {
+					t.Errorf("expected export command to run, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+		{
+			name:    "encrypts the exported data to the backup recipients",
+			args:    []string{"--encrypt"},
+			encrypt: true,
+			check: func(t *testing.T, e *testEnv, output string, identityFile string) {
+				data, err := os.ReadFile(output)
+				if err != nil {
+					t.Fatalf("failed to read export: %s", err)
+				}
+				if bytes.Contains(data, []byte("lollipop data")) {
+					t.Fatalf("expected exported data to be encrypted")
+				}
+
+				file, err := os.Open(identityFile)
+				if err != nil {
+					t.Fatalf("failed to open identity: %s", err)
+				}
+				defer file.Close()
+				identities, err := age.ParseIdentities(file)
+				if err != nil {
+					t.Fatalf("failed to parse identity: %s", err)
+				}
+				reader, err := age.Decrypt(bytes.NewReader(data), identities...)
+				if err != nil {
+					t.Fatalf("failed to decrypt export: %s", err)
+				}
+				decrypted, _ := io.ReadAll(reader)
+				if string(decrypted) != "lollipop data" {
+					t.Errorf("expected decrypted export to be lollipop data, got %q", decrypted)
+				}
+			},
+		},
+		{
+			name:     "fails to encrypt without recipients",
+			args:     []string{"--encrypt"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails when the export command fails",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					return &engine.ExitError{Code: 1}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+				_, err := io.WriteString(options.Stdout, "lollipop data")
+				return err
+			}
+
+			identityFile := ""
+			if tt.encrypt {
+				var recipient string
+				identityFile, recipient = e.identity()
+				e.run(&ServiceBackupSetEncryptionCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", recipient)
+			}
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			output := filepath.Join(t.TempDir(), "export.dump")
+			args := append([]string{"--file", output}, tt.args...)
+			e.run(&ServiceExportCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, append(args, "postgres", "lollipop")...)
+			if tt.check != nil {
+				tt.check(t, e, output, identityFile)
+			}
+		})
+	}
+}
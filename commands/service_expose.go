@@ -0,0 +1,341 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/ambassador"
+	"dokku-service/container"
+	"dokku-service/service"
+)
+
+type ServiceExposeCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// bindIP specifies the host ip to bind exposed ports to
+	bindIP string
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceExposeCommand) Name() string {
+	return "service-expose"
+}
+
+func (c *ServiceExposeCommand) Synopsis() string {
+	return "service-expose command"
+}
+
+func (c *ServiceExposeCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceExposeCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"expose a service on random host ports":    fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+		"expose a service on a specific host port": fmt.Sprintf("%s %s postgres lollipop 15432", appName, c.Name()),
+		"expose a service on localhost only":       fmt.Sprintf("%s %s --bind-ip 127.0.0.1 postgres lollipop 15432", appName, c.Name()),
+	}
+}
+
+func (c *ServiceExposeCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "ports",
+		Description: "host ports to publish, one per template expose port",
+		Optional:    true,
+		Type:        command.ArgumentList,
+	})
+	return args
+}
+
+func (c *ServiceExposeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceExposeCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceExposeCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.bindIP, "bind-ip", "", "the host ip to bind exposed ports to")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceExposeCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceExposeCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	if len(config.Config.Expose.Ports) > 0 {
+		c.Ui.Error(fmt.Sprintf("%s service %s is already exposed on %s", serviceTemplate.Name, serviceName, exposedPortsString(config.Config.Expose)))
+		return 1
+	}
+
+	exposePorts := config.Template.Ports.Expose
+	if len(exposePorts) == 0 {
+		c.Ui.Error(fmt.Sprintf("%s service %s does not define any ports to expose", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	hostPorts := arguments["ports"].ListValue()
+	if len(hostPorts) > 0 && len(hostPorts) != len(exposePorts) {
+		c.Ui.Error(fmt.Sprintf("Expected %d port(s) to expose, %d given", len(exposePorts), len(hostPorts)))
+		return 1
+	}
+
+	expose := service.ExposeConfig{
+		BindIP: c.bindIP,
+		Ports:  []service.ExposedPort{},
+	}
+	for i, containerPort := range exposePorts {
+		var hostPort int
+		if len(hostPorts) > 0 {
+			hostPort, err = strconv.Atoi(hostPorts[i])
+			if err != nil || hostPort <= 0 || hostPort > 65535 {
+				c.Ui.Error(fmt.Sprintf("Invalid port specified: %s", hostPorts[i]))
+				return 1
+			}
+		} else {
+			hostPort, err = ambassador.FreePort(c.bindIP)
+			if err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+		}
+
+		expose.Ports = append(expose.Ports, service.ExposedPort{
+			ContainerPort: containerPort,
+			HostPort:      hostPort,
+		})
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if !containerExists {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not running", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	dockerContainer, err := container.Inspect(c.Context, container.InspectInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if !dockerContainer.Running {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not running", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Exposing %s service %s on %s", serviceTemplate.Name, serviceName, exposedPortsString(expose)))
+	config.Config.Expose = expose
+	if err := ensureAmbassador(c.Context, ensureAmbassadorInput{
+		Config:        config,
+		ContainerName: containerName,
+		Recreate:      true,
+		ServiceName:   serviceName,
+		Trace:         c.trace,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to start ambassador: %s", err.Error()))
+		return 1
+	}
+
+	if err := service.WriteConfig(c.Context, service.WriteConfigInput{
+		ConfigOutput: config,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write service config: %s", err.Error()))
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s exposed", serviceTemplate.Name, serviceName))
+	return 0
+}
+
+// ensureAmbassadorInput contains the input parameters for the ensureAmbassador function
+type ensureAmbassadorInput struct {
+	// Config is the service config containing the expose state
+	Config service.ConfigOutput
+
+	// ContainerName is the name of the service container
+	ContainerName string
+
+	// Recreate specifies whether to replace an existing ambassador container
+	Recreate bool
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// ensureAmbassador starts the ambassador container for a service when the service has exposed ports
+func ensureAmbassador(ctx context.Context, input ensureAmbassadorInput) error {
+	if len(input.Config.Config.Expose.Ports) == 0 {
+		return nil
+	}
+
+	exists, err := ambassador.Exists(ctx, ambassador.ExistsInput{
+		Name:  input.ContainerName,
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for ambassador existence: %w", err)
+	}
+
+	// ambassadors are linked to a specific service container, so they
+	// must be replaced whenever the service container is recreated
+	if exists && input.Recreate {
+		if err := destroyAmbassador(ctx, input.ContainerName, input.Trace); err != nil {
+			return err
+		}
+		exists = false
+	}
+
+	if exists {
+		return ambassador.Start(ctx, ambassador.StartInput{
+			Name:  input.ContainerName,
+			Trace: input.Trace,
+		})
+	}
+
+	return ambassador.Create(ctx, ambassador.CreateInput{
+		Expose:      input.Config.Config.Expose,
+		Name:        input.ContainerName,
+		ServiceName: input.ServiceName,
+		ServiceType: input.Config.Template.Name,
+		Trace:       input.Trace,
+	})
+}
+
+// destroyAmbassador stops and removes the ambassador container for a service container
+func destroyAmbassador(ctx context.Context, containerName string, trace bool) error {
+	if err := ambassador.Stop(ctx, ambassador.StopInput{
+		Name:  containerName,
+		Trace: trace,
+	}); err != nil {
+		return fmt.Errorf("failed to stop ambassador: %w", err)
+	}
+
+	if err := ambassador.Destroy(ctx, ambassador.DestroyInput{
+		Name:  containerName,
+		Trace: trace,
+	}); err != nil {
+		return fmt.Errorf("failed to destroy ambassador: %w", err)
+	}
+
+	return nil
+}
+
+// exposedPortsString returns a human readable list of exposed port mappings
+func exposedPortsString(expose service.ExposeConfig) string {
+	mappings := []string{}
+	for _, port := range expose.Ports {
+		mapping := fmt.Sprintf("%d->%d", port.ContainerPort, port.HostPort)
+		if expose.BindIP != "" {
+			mapping = fmt.Sprintf("%d->%s:%d", port.ContainerPort, expose.BindIP, port.HostPort)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return strings.Join(mappings, " ")
+}
@@ -0,0 +1,83 @@
+package commands
+
+import "testing"
+
+func TestServiceExposeCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "starts an ambassador publishing the given port",
+			args: []string{"postgres", "lollipop", "15432"},
+			check: func(t *testing.T, e *testEnv) {
+				a := e.container("dokku.postgres.lollipop.ambassador")
+				if !a.Running {
+					t.Errorf("expected ambassador to be running")
+				}
+				if len(a.Spec.Ports) != 1 || a.Spec.Ports[0].HostPort != 15432 || a.Spec.Ports[0].ContainerPort != 5432 {
+					t.Errorf("expected 15432->5432 port binding, got %+v", a.Spec.Ports)
+				}
+
+				expose := e.config("postgres", "lollipop").Config.Expose
+				if len(expose.Ports) != 1 || expose.Ports[0].HostPort != 15432 {
+					t.Errorf("expected exposed port to be recorded, got %+v", expose)
+				}
+			},
+		},
+		{
+			name: "binds to the given host ip",
+			args: []string{"postgres", "lollipop", "15432", "--bind-ip", "127.0.0.1"},
+			check: func(t *testing.T, e *testEnv) {
+				a := e.container("dokku.postgres.lollipop.ambassador")
+				if len(a.Spec.Ports) != 1 || a.Spec.Ports[0].HostIP != "127.0.0.1" {
+					t.Errorf("expected binding on 127.0.0.1, got %+v", a.Spec.Ports)
+				}
+			},
+		},
+		{
+			name:     "fails when already exposed",
+			args:     []string{"postgres", "lollipop", "15433"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "15432")
+			},
+		},
+		{
+			name:     "fails for an invalid port",
+			args:     []string{"postgres", "lollipop", "99999"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails when the service is not running",
+			args:     []string{"postgres", "lollipop", "15432"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServicePauseCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.config("postgres", "lollipop").Config.Expose.Ports) != 0 {
+					t.Errorf("expected no exposed ports to be recorded")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
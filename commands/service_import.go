@@ -1,14 +1,17 @@
 package commands
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/josegonzalez/cli-skeleton/command"
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
 
+	"dokku-service/backup"
 	"dokku-service/container"
 	"dokku-service/service"
 )
@@ -22,6 +25,15 @@ type ServiceImportCommand struct {
 	// dataRoot specifies the root directory for service data
 	dataRoot string
 
+	// decompress specifies whether to gunzip the imported data
+	decompress bool
+
+	// decrypt specifies whether to decrypt the imported data
+	decrypt bool
+
+	// identityFile specifies the age identity file used to decrypt the imported data
+	identityFile string
+
 	// registryPath specifies an override path to the registry
 	registryPath string
 
@@ -77,6 +89,9 @@ func (c *ServiceImportCommand) FlagSet() *flag.FlagSet {
 	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.decompress, "decompress", false, "gunzip the imported data")
+	f.BoolVar(&c.decrypt, "decrypt", false, "decrypt the imported data")
+	f.StringVar(&c.identityFile, "identity-file", "", "the age identity file used to decrypt the imported data")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
 	return f
 }
@@ -154,10 +169,33 @@ func (c *ServiceImportCommand) Run(args []string) int {
 		return 1
 	}
 
+	stdin := io.Reader(os.Stdin)
+	if c.decrypt {
+		stdin, err = backup.Decrypt(backup.DecryptInput{
+			IdentityFile: c.identityFile,
+			Reader:       stdin,
+		})
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if c.decompress {
+		gzipReader, err := gzip.NewReader(stdin)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to decompress data: %s", err.Error()))
+			return 1
+		}
+		defer gzipReader.Close()
+		stdin = gzipReader
+	}
+
 	err = container.Execute(c.Context, container.ExecuteInput{
 		Name:         containerName,
 		CommandName:  "import",
 		ConfigOutput: config,
+		Stdin:        stdin,
 		Trace:        c.trace,
 	})
 	if err != nil {
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"testing"
+
+	"filippo.io/age"
+
+	"dokku-service/engine"
+)
+
+func TestServiceImportCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdin    func(e *testEnv, recipient string) []byte
+		imported string
+	}{
+		{
+			name: "imports data from stdin",
+			stdin: func(e *testEnv, recipient string) []byte {
+				return []byte("lollipop data")
+			},
+			imported: "lollipop data",
+		},
+		{
+			name: "decompresses gzipped data",
+			args: []string{"--decompress"},
+			stdin: func(e *testEnv, recipient string) []byte {
+				buf := &bytes.Buffer{}
+				w := gzip.NewWriter(buf)
+				io.WriteString(w, "lollipop data")
+				w.Close()
+				return buf.Bytes()
+			},
+			imported: "lollipop data",
+		},
+		{
+			name: "decrypts encrypted data",
+			args: []string{"--decrypt"},
+			stdin: func(e *testEnv, recipient string) []byte {
+				parsed, err := age.ParseX25519Recipient(recipient)
+				if err != nil {
+					e.t.Fatalf("failed to parse recipient: %s", err)
+				}
+
+				buf := &bytes.Buffer{}
+				w, err := age.Encrypt(buf, parsed)
+				if err != nil {
+					e.t.Fatalf("failed to encrypt data: %s", err)
+				}
+				io.WriteString(w, "lollipop data")
+				w.Close()
+				return buf.Bytes()
+			},
+			imported: "lollipop data",
+		},
+		{
+			name:     "fails to decompress data that is not gzipped",
+			args:     []string{"--decompress"},
+			exitCode: 1,
+			stdin: func(e *testEnv, recipient string) []byte {
+				return []byte("lollipop data")
+			},
+		},
+		{
+			name:     "fails to decrypt data that is not encrypted",
+			args:     []string{"--decrypt"},
+			exitCode: 1,
+			stdin: func(e *testEnv, recipient string) []byte {
+				return []byte("lollipop data")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+
+			var mu sync.Mutex
+			imported := ""
+			e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+				b, err := io.ReadAll(options.Stdin)
+				mu.Lock()
+				defer mu.Unlock()
+				imported = string(b)
+				return err
+			}
+
+			identityFile, recipient := e.identity()
+			e.stdin(tt.stdin(e, recipient))
+
+			args := append([]string{"--identity-file", identityFile}, tt.args...)
+			e.run(&ServiceImportCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, append(args, "postgres", "lollipop")...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if imported != tt.imported {
+				t.Errorf("expected imported data %q, got %q", tt.imported, imported)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/container"
+	"dokku-service/image"
+	"dokku-service/network"
+	"dokku-service/service"
+	"dokku-service/volume"
+)
+
+type ServiceInfoCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// field specifies a single field to output
+	field string
+
+	// format specifies the output format
+	format string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+// serviceInfo contains the reported information for a service
+type serviceInfo struct {
+	// Config is the run configuration for the service
+	Config service.RunConfig `json:"config"`
+
+	// ContainerName is the name of the service container
+	ContainerName string `json:"container_name"`
+
+	// Dsn is the primary connection string for the service
+	Dsn string `json:"dsn"`
+
+	// ExportedVariables are the rendered exported variables for the service
+	ExportedVariables map[string]string `json:"exported_variables"`
+
+	// Image is the name of the image built for the service
+	Image string `json:"image"`
+
+	// IPAddress is the ip address of the service container
+	IPAddress string `json:"ip_address"`
+
+	// Links are the apps linked to the service
+	Links []string `json:"links"`
+
+	// Name is the name of the service
+	Name string `json:"name"`
+
+	// NetworkAlias is the network alias of the service container
+	NetworkAlias string `json:"network_alias"`
+
+	// RestartCount is the number of times the service container has been restarted
+	RestartCount int `json:"restart_count"`
+
+	// Running specifies whether the service container is running
+	Running bool `json:"running"`
+
+	// Status is the status of the service container
+	Status string `json:"status"`
+
+	// Template is the name of the service template
+	Template string `json:"template"`
+
+	// Volumes are the volumes attached to the service container
+	Volumes []serviceInfoVolume `json:"volumes"`
+}
+
+// serviceInfoVolume contains the reported information for a service volume
+type serviceInfoVolume struct {
+	// Alias is the alias of the volume
+	Alias string `json:"alias"`
+
+	// ContainerPath is the path the volume is mounted at in the container
+	ContainerPath string `json:"container_path"`
+
+	// MountType is the type of mount used for the volume
+	MountType string `json:"mount_type"`
+
+	// Source is the volume name or host directory
+	Source string `json:"source"`
+}
+
+func (c *ServiceInfoCommand) Name() string {
+	return "service-info"
+}
+
+func (c *ServiceInfoCommand) Synopsis() string {
+	return "service-info command"
+}
+
+func (c *ServiceInfoCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceInfoCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"show info about a service":                fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+		"show info about a service as json":        fmt.Sprintf("%s %s --format json postgres lollipop", appName, c.Name()),
+		"show the connection string for a service": fmt.Sprintf("%s %s --field dsn postgres lollipop", appName, c.Name()),
+	}
+}
+
+func (c *ServiceInfoCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceInfoCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceInfoCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceInfoCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.field, "field", "", "output a single field, such as dsn, status or an exported variable name")
+	f.StringVar(&c.format, "format", "text", "the output format to use: [text, json]")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceInfoCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{
+			"--format": complete.PredictSet("text", "json"),
+		},
+	)
+}
+
+func (c *ServiceInfoCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.format != "text" && c.format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid format specified: %s", c.format))
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	info, err := c.collectInfo(serviceName, config)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if c.field != "" {
+		value, ok := info.fields()[c.field]
+		if !ok {
+			value, ok = info.ExportedVariables[c.field]
+		}
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Invalid field specified: %s", c.field))
+			return 1
+		}
+
+		c.Ui.Output(value)
+		return 0
+	}
+
+	if c.format == "json" {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to marshal service info: %s", err.Error()))
+			return 1
+		}
+
+		c.Ui.Output(string(data))
+		return 0
+	}
+
+	logger.LogHeader1(fmt.Sprintf("%s service %s info", serviceTemplate.Name, serviceName))
+	fields := info.fields()
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.Ui.Output(fmt.Sprintf("%-22s %s", key+":", fields[key]))
+	}
+
+	return 0
+}
+
+func (c *ServiceInfoCommand) collectInfo(serviceName string, config service.ConfigOutput) (serviceInfo, error) {
+	serviceType := config.Template.Name
+	info := serviceInfo{
+		Config: config.Config,
+		ContainerName: container.Name(container.NameInput{
+			ServiceName: serviceName,
+			ServiceType: serviceType,
+		}),
+		Image: image.Name(image.NameInput{
+			ServiceName: serviceName,
+			ServiceType: serviceType,
+		}),
+		Links: []string{},
+		Name:  serviceName,
+		NetworkAlias: network.Alias(network.AliasInput{
+			ServiceName: serviceName,
+			ServiceType: serviceType,
+		}),
+		Status:   "missing",
+		Template: serviceType,
+		Volumes:  []serviceInfoVolume{},
+	}
+
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  info.ContainerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		return info, fmt.Errorf("Failed to check for container existence: %s", err.Error())
+	}
+
+	if containerExists {
+		dockerContainer, err := container.Inspect(c.Context, container.InspectInput{
+			Name:  info.ContainerName,
+			Trace: c.trace,
+		})
+		if err != nil {
+			return info, err
+		}
+
+		info.RestartCount = dockerContainer.RestartCount
+		info.Running = dockerContainer.Running
+		info.Status = dockerContainer.Status
+		info.IPAddress = dockerContainer.IPAddress
+		networkNames := []string{}
+		for networkName := range dockerContainer.Networks {
+			networkNames = append(networkNames, networkName)
+		}
+		sort.Strings(networkNames)
+		for _, networkName := range networkNames {
+			if info.IPAddress != "" {
+				break
+			}
+			info.IPAddress = dockerContainer.Networks[networkName]
+		}
+	}
+
+	for _, volumeDescriptor := range config.Template.Volumes {
+		v := volume.Resolve(volume.ResolveInput{
+			DataRoot:         config.Config.DataRoot,
+			ServiceName:      serviceName,
+			Template:         config.Template,
+			VolumeDescriptor: volumeDescriptor,
+			UseVolumes:       config.Config.UseVolumes,
+		})
+		info.Volumes = append(info.Volumes, serviceInfoVolume{
+			Alias:         v.Alias,
+			ContainerPath: v.ContainerPath,
+			MountType:     v.MountType,
+			Source:        v.Source,
+		})
+	}
+
+	info.ExportedVariables, err = service.ExportedVariables(c.Context, service.ExportedVariablesInput{
+		ConfigOutput: config,
+		Hostname:     info.NetworkAlias,
+	})
+	if err != nil {
+		return info, fmt.Errorf("Failed to render exported variables: %s", err.Error())
+	}
+
+	// the dsn is the first exported url, which is what apps will usually connect with
+	exportedNames := []string{}
+	for name := range info.ExportedVariables {
+		exportedNames = append(exportedNames, name)
+	}
+	sort.Strings(exportedNames)
+	for _, name := range exportedNames {
+		if strings.HasSuffix(name, "_URL") {
+			info.Dsn = info.ExportedVariables[name]
+			break
+		}
+	}
+
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceType,
+	})
+	if err != nil {
+		return info, fmt.Errorf("Failed to fetch service links: %s", err.Error())
+	}
+	for _, link := range links {
+		info.Links = append(info.Links, link.App)
+	}
+
+	return info, nil
+}
+
+// fields returns the single-value fields that can be selected via --field
+func (i serviceInfo) fields() map[string]string {
+	volumes := []string{}
+	for _, v := range i.Volumes {
+		volumes = append(volumes, fmt.Sprintf("%s:%s", v.Source, v.ContainerPath))
+	}
+
+	exportedNames := []string{}
+	for name := range i.ExportedVariables {
+		exportedNames = append(exportedNames, name)
+	}
+	sort.Strings(exportedNames)
+
+	return map[string]string{
+		"base-image":           i.Config.Arguments["IMAGE"].Value,
+		"container-name":       i.ContainerName,
+		"dsn":                  i.Dsn,
+		"exported-variables":   strings.Join(exportedNames, " "),
+		"image":                i.Image,
+		"ip":                   i.IPAddress,
+		"links":                strings.Join(i.Links, " "),
+		"name":                 i.Name,
+		"network-alias":        i.NetworkAlias,
+		"post-create-networks": strings.Join(i.Config.PostCreateNetworks, " "),
+		"post-start-networks":  strings.Join(i.Config.PostStartNetworks, " "),
+		"restart-count":        strconv.Itoa(i.RestartCount),
+		"running":              strconv.FormatBool(i.Running),
+		"service-root":         i.Config.ServiceRoot,
+		"status":               i.Status,
+		"template":             i.Template,
+		"volumes":              strings.Join(volumes, " "),
+	}
+}
@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/app"
+	"dokku-service/container"
+	"dokku-service/network"
+	"dokku-service/service"
+)
+
+type ServiceLinkCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// networks specifies the app networks to attach the service container to
+	networks []string
+
+	// noRestart specifies whether to skip restarting the app
+	noRestart bool
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceLinkCommand) Name() string {
+	return "service-link"
+}
+
+func (c *ServiceLinkCommand) Synopsis() string {
+	return "service-link command"
+}
+
+func (c *ServiceLinkCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceLinkCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"link a service to an app":                     fmt.Sprintf("%s %s postgres lollipop playground", appName, c.Name()),
+		"link a service to an app on a custom network": fmt.Sprintf("%s %s --network app-net postgres lollipop playground", appName, c.Name()),
+	}
+}
+
+func (c *ServiceLinkCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "app",
+		Description: "the app to link the service to",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceLinkCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceLinkCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceLinkCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.BoolVar(&c.noRestart, "no-restart", false, "do not restart the app after linking")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringSliceVar(&c.networks, "network", []string{}, "app network to attach the service container to")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceLinkCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceLinkCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	appName := arguments["app"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service links: %s", err.Error()))
+		return 1
+	}
+
+	for _, link := range links {
+		if link.App == appName {
+			c.Ui.Error(fmt.Sprintf("%s service %s is already linked to %s", serviceTemplate.Name, serviceName, appName))
+			return 1
+		}
+	}
+
+	for _, networkName := range c.networks {
+		ok, err = network.Exists(c.Context, network.ExistsInput{
+			Name:  networkName,
+			Trace: c.trace,
+		})
+		if err != nil {
+			c.Ui.Error("Failed to check for network existence: " + err.Error())
+			return 1
+		}
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Missing app network: %s", networkName))
+			return 1
+		}
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Linking %s service %s to %s", serviceTemplate.Name, serviceName, appName))
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	exportedVariables, err := service.ExportedVariables(c.Context, service.ExportedVariablesInput{
+		ConfigOutput: config,
+		Hostname:     networkAlias,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to render exported variables: %s", err.Error()))
+		return 1
+	}
+
+	// only the first service of a given type linked to an app gets the unprefixed variables
+	linkedServices, err := service.LinkedServices(c.Context, service.LinkedServicesInput{
+		AppName:     appName,
+		DataRoot:    c.dataRoot,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch app links: %s", err.Error()))
+		return 1
+	}
+
+	primary := true
+	for _, linkedService := range linkedServices {
+		if linkedService.Link.Primary {
+			primary = false
+		}
+	}
+
+	appVariables := linkVariables(serviceName, serviceTemplate.Name, exportedVariables, primary)
+
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if containerExists {
+		logger.LogHeader2("Attaching container to app networks")
+		attachedNetworks := serviceNetworks(config, links, "")
+		for _, networkName := range c.networks {
+			if attachedNetworks[networkName] {
+				continue
+			}
+
+			if err := network.Connect(c.Context, network.ConnectInput{
+				ContainerName: containerName,
+				NetworkAlias:  networkAlias,
+				NetworkName:   networkName,
+				Trace:         c.trace,
+			}); err != nil {
+				c.Ui.Error("Failed to attach container to network: " + err.Error())
+				return 1
+			}
+			attachedNetworks[networkName] = true
+		}
+	}
+
+	// the exported hostname is a network alias, which is only resolvable by apps sharing a network with the service
+	if len(c.networks) == 0 && len(serviceNetworks(config, links, "")) == 0 {
+		logger.Warn(fmt.Sprintf("%s service %s is not attached to any network, use --network to attach it to a network of %s so %s is resolvable", serviceTemplate.Name, serviceName, appName, networkAlias))
+	}
+
+	logger.LogHeader2("Setting exported variables on app")
+	if err := app.SetConfig(c.Context, app.SetConfigInput{
+		AppName:   appName,
+		NoRestart: c.noRestart,
+		Trace:     c.trace,
+		Variables: appVariables,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to set exported variables on app: %s", err.Error()))
+		return 1
+	}
+
+	links = append(links, service.Link{
+		App:      appName,
+		Networks: c.networks,
+		Primary:  primary,
+	})
+	if err := service.WriteLinks(c.Context, service.WriteLinksInput{
+		DataRoot:    c.dataRoot,
+		Links:       links,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to record service link: %s", err.Error()))
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s linked to %s", serviceTemplate.Name, serviceName, appName))
+	return 0
+}
+
+// linkVariables returns the variables to set on an app for a linked service
+func linkVariables(serviceName string, serviceType string, exportedVariables map[string]string, primary bool) map[string]string {
+	variables := map[string]string{}
+	for name, value := range exportedVariables {
+		variables[service.AliasVariable(service.AliasVariableInput{
+			ServiceName: serviceName,
+			ServiceType: serviceType,
+			Variable:    name,
+		})] = value
+
+		if primary {
+			variables[name] = value
+		}
+	}
+
+	return variables
+}
+
+// serviceNetworks returns the networks a service container is attached to,
+// ignoring the networks attached on behalf of the excluded app
+func serviceNetworks(config service.ConfigOutput, links []service.Link, excludeApp string) map[string]bool {
+	networks := map[string]bool{}
+	for _, networkName := range config.Config.PostCreateNetworks {
+		networks[networkName] = true
+	}
+	for _, networkName := range config.Config.PostStartNetworks {
+		networks[networkName] = true
+	}
+	for _, link := range links {
+		if link.App == excludeApp {
+			continue
+		}
+
+		for _, networkName := range link.Networks {
+			networks[networkName] = true
+		}
+	}
+
+	return networks
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceLinkCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "sets the exported variables on the app",
+			args: []string{"postgres", "lollipop", "playground"},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				if len(calls) != 1 || !strings.HasPrefix(calls[0], "config:set playground DATABASE_URL=postgres://") {
+					t.Fatalf("expected config:set call with the primary variable, got %v", calls)
+				}
+				if !strings.Contains(calls[0], "DOKKU_POSTGRES_LOLLIPOP_URL=postgres://") {
+					t.Errorf("expected aliased variable to be set, got %s", calls[0])
+				}
+			},
+		},
+		{
+			name: "only sets aliased variables for a second service of the same type",
+			args: []string{"postgres", "gumdrop", "playground", "--no-restart"},
+			setup: func(e *testEnv) {
+				e.create("postgres", "gumdrop")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				last := calls[len(calls)-1]
+				if !strings.HasPrefix(last, "config:set --no-restart playground DOKKU_POSTGRES_GUMDROP_URL=") {
+					t.Errorf("expected only the aliased variable to be set, got %s", last)
+				}
+				if strings.Contains(last, " DATABASE_URL=") {
+					t.Errorf("expected primary variable not to be overwritten, got %s", last)
+				}
+			},
+		},
+		{
+			name: "warns when the service is not attached to any network",
+			args: []string{"postgres", "lollipop", "playground"},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stderr.String(), "use --network to attach it to a network of playground so dokku.postgres.lollipop is resolvable") {
+					t.Errorf("expected network warning, got %s", e.stderr.String())
+				}
+			},
+		},
+		{
+			name: "does not warn when the service shares a network",
+			args: []string{"postgres", "lollipop", "playground", "--network", "app-net"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["app-net"] = true
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if strings.Contains(e.stderr.String(), "use --network") {
+					t.Errorf("expected no network warning, got %s", e.stderr.String())
+				}
+			},
+		},
+		{
+			name: "attaches the container to app networks",
+			args: []string{"postgres", "lollipop", "playground", "--network", "app-net"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["app-net"] = true
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.container("dokku.postgres.lollipop").Networks["app-net"]; !ok {
+					t.Errorf("expected container to be attached to app-net")
+				}
+			},
+		},
+		{
+			name:     "fails when an app network is missing",
+			args:     []string{"postgres", "lollipop", "playground", "--network", "app-net"},
+			exitCode: 1,
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.dokkuCalls()) != 0 {
+					t.Errorf("expected app config to be left untouched, got %v", e.dokkuCalls())
+				}
+			},
+		},
+		{
+			name:     "fails when already linked",
+			args:     []string{"postgres", "lollipop", "playground"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
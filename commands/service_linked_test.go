@@ -0,0 +1,36 @@
+package commands
+
+import "testing"
+
+func TestServiceLinkedCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{
+			name: "succeeds for a linked app",
+			args: []string{"postgres", "lollipop", "playground"},
+		},
+		{
+			name:     "fails for an unlinked app",
+			args:     []string{"postgres", "lollipop", "sandbox"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails for an unknown service",
+			args:     []string{"postgres", "unknown", "playground"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+
+			e.run(&ServiceLinkedCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+		})
+	}
+}
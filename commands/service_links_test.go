@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceLinksCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		apps     []string
+		stdout   []string
+	}{
+		{
+			name:   "lists the linked apps",
+			args:   []string{"postgres", "lollipop"},
+			apps:   []string{"playground", "sandbox"},
+			stdout: []string{"playground", "sandbox"},
+		},
+		{
+			name: "succeeds when no apps are linked",
+			args: []string{"postgres", "lollipop"},
+		},
+		{
+			name:     "fails for an unknown service",
+			args:     []string{"postgres", "unknown"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			for _, appName := range tt.apps {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", appName)
+			}
+
+			e.run(&ServiceLinksCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			for _, expected := range tt.stdout {
+				if !strings.Contains(e.stdout.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+				}
+			}
+		})
+	}
+}
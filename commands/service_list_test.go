@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceListCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		services []string
+		stdout   []string
+		absent   []string
+	}{
+		{
+			name:     "lists the services of a template",
+			args:     []string{"postgres"},
+			services: []string{"lollipop", "gumdrop"},
+			stdout:   []string{"lollipop", "gumdrop"},
+		},
+		{
+			name:     "does not list services of other templates",
+			args:     []string{"redis"},
+			services: []string{"lollipop"},
+			absent:   []string{"lollipop"},
+		},
+		{
+			name: "succeeds when no services exist",
+			args: []string{"postgres"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			for _, serviceName := range tt.services {
+				e.create("postgres", serviceName)
+			}
+
+			e.run(&ServiceListCommand{Meta: e.meta(), Context: e.ctx}, 0, tt.args...)
+			for _, expected := range tt.stdout {
+				if !strings.Contains(e.stdout.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+				}
+			}
+			for _, unexpected := range tt.absent {
+				if strings.Contains(e.stdout.String(), unexpected) {
+					t.Errorf("expected output not to contain %q, got:\n%s", unexpected, e.stdout.String())
+				}
+			}
+		})
+	}
+}
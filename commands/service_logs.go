@@ -8,6 +8,7 @@ import (
 	"github.com/josegonzalez/cli-skeleton/command"
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
 
 	"dokku-service/container"
 )
@@ -27,6 +28,9 @@ type ServiceLogsCommand struct {
 	// registryPath specifies an override path to the registry
 	registryPath string
 
+	// sidecar specifies the name of a sidecar to show the logs of
+	sidecar string
+
 	// tail specifies the number of lines to show from the end of the logs
 	tail int
 
@@ -50,6 +54,7 @@ func (c *ServiceLogsCommand) Examples() map[string]string {
 	appName := os.Getenv("CLI_APP_NAME")
 	return map[string]string{
 		"print the most recent log(s) for this service": fmt.Sprintf("%s %s postgres test-postgres", appName, c.Name()),
+		"print the log(s) of a sidecar of this service": fmt.Sprintf("%s %s postgres test-postgres --sidecar pgbouncer", appName, c.Name()),
 	}
 }
 
@@ -85,6 +90,7 @@ func (c *ServiceLogsCommand) FlagSet() *flag.FlagSet {
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	f.StringVar(&c.sidecar, "sidecar", "", "only show the logs of the named sidecar container")
 	return f
 }
 
@@ -150,16 +156,84 @@ func (c *ServiceLogsCommand) Run(args []string) int {
 		return 1
 	}
 
-	err = container.Logs(c.Context, container.LogsInput{
-		Follow: c.follow,
-		Name:   containerName,
-		Tail:   c.tail,
-		Trace:  c.trace,
-	})
-	if err != nil {
+	logContainers := []serviceLogContainer{}
+	if c.sidecar == "" {
+		logContainers = append(logContainers, serviceLogContainer{
+			label: serviceTemplate.Name,
+			name:  containerName,
+		})
+	}
+
+	for _, sidecar := range serviceTemplate.Sidecars {
+		if c.sidecar != "" && c.sidecar != sidecar.Name {
+			continue
+		}
+
+		sidecarContainerName := container.Name(container.NameInput{
+			ServiceName: serviceName,
+			ServiceType: serviceTemplate.Name,
+			Sidecar:     sidecar.Name,
+		})
+		sidecarExists, err := container.Exists(c.Context, container.ExistsInput{
+			Name:  sidecarContainerName,
+			Trace: c.trace,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+			return 1
+		}
+		if !sidecarExists {
+			continue
+		}
+
+		logContainers = append(logContainers, serviceLogContainer{
+			label: sidecar.Name,
+			name:  sidecarContainerName,
+		})
+	}
+
+	if len(logContainers) == 0 {
+		c.Ui.Error(fmt.Sprintf("%s sidecar of %s service %s does not exist", c.sidecar, serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	// logs are only prefixed with the container they come from when there is more than one
+	width := 0
+	for _, logContainer := range logContainers {
+		width = max(width, len(logContainer.label))
+	}
+
+	g, ctx := errgroup.WithContext(c.Context)
+	for _, logContainer := range logContainers {
+		prefix := ""
+		if len(logContainers) > 1 {
+			prefix = fmt.Sprintf("%-*s | ", width, logContainer.label)
+		}
+
+		g.Go(func() error {
+			return container.Logs(ctx, container.LogsInput{
+				Follow: c.follow,
+				Name:   logContainer.name,
+				Prefix: prefix,
+				Tail:   c.tail,
+				Trace:  c.trace,
+			})
+		})
+	}
+
+	if err := g.Wait(); err != nil {
 		c.Ui.Error(err.Error())
 		return 1
 	}
 
 	return 0
 }
+
+// serviceLogContainer is a container of a service to show the logs of
+type serviceLogContainer struct {
+	// label identifies the container in prefixed logs
+	label string
+
+	// name is the name of the container
+	name string
+}
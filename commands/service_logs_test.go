@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceLogsCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "reads the service container logs",
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerLogs dokku.postgres.lollipop") {
+					t.Errorf("expected container logs to be read, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+		{
+			name:     "fails when the container does not exist",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+		},
+		{
+			name:     "fails when the logs cannot be read",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Errors["ContainerLogs"] = errors.New("logs unavailable")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceLogsCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop")
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
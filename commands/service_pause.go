@@ -144,6 +144,16 @@ func (c *ServicePauseCommand) Run(args []string) int {
 	}
 
 	logger.LogHeader1("Pausing service")
+	if err := stopSidecarContainers(c.Context, serviceContainerInput{
+		Logger:      logger,
+		ServiceName: serviceName,
+		Template:    serviceTemplate,
+		Trace:       c.trace,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
 	exists, err := ambassador.Exists(c.Context, ambassador.ExistsInput{
 		Name:  containerName,
 		Trace: c.trace,
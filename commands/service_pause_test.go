@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServicePauseCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "stops the service container without removing it",
+			check: func(t *testing.T, e *testEnv) {
+				if e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to be stopped")
+				}
+			},
+		},
+		{
+			name: "stops the ambassador of an exposed service",
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "5432")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if e.container("dokku.postgres.lollipop.ambassador").Running {
+					t.Errorf("expected ambassador to be stopped")
+				}
+			},
+		},
+		{
+			name:     "fails when the container does not exist",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServicePauseCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop")
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
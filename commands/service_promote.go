@@ -0,0 +1,280 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/app"
+	"dokku-service/network"
+	"dokku-service/registry"
+	"dokku-service/service"
+)
+
+type ServicePromoteCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// noRestart specifies whether to skip restarting the app
+	noRestart bool
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServicePromoteCommand) Name() string {
+	return "service-promote"
+}
+
+func (c *ServicePromoteCommand) Synopsis() string {
+	return "service-promote command"
+}
+
+func (c *ServicePromoteCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServicePromoteCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"promote a linked service to the primary service of an app": fmt.Sprintf("%s %s postgres lollipop-v2 playground", appName, c.Name()),
+	}
+}
+
+func (c *ServicePromoteCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the service to promote",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "app",
+		Description: "the linked app to promote the service for",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServicePromoteCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServicePromoteCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServicePromoteCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.BoolVar(&c.noRestart, "no-restart", false, "do not restart the app after promoting")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServicePromoteCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServicePromoteCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	appName := arguments["app"].StringValue()
+	linkedServices, err := service.LinkedServices(c.Context, service.LinkedServicesInput{
+		AppName:     appName,
+		DataRoot:    c.dataRoot,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch app links: %s", err.Error()))
+		return 1
+	}
+
+	linked := false
+	previousPrimaries := []string{}
+	for _, linkedService := range linkedServices {
+		if linkedService.Name == serviceName {
+			linked = true
+			if linkedService.Link.Primary {
+				logger.Info(fmt.Sprintf("%s service %s is already the primary service for %s", serviceTemplate.Name, serviceName, appName))
+				return 0
+			}
+			continue
+		}
+
+		if linkedService.Link.Primary {
+			previousPrimaries = append(previousPrimaries, linkedService.Name)
+		}
+	}
+
+	if !linked {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not linked to %s", serviceTemplate.Name, serviceName, appName))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Promoting %s service %s for %s", serviceTemplate.Name, serviceName, appName))
+
+	appVariables := map[string]string{}
+	for _, previousName := range previousPrimaries {
+		// re-render the previous primary's variables so its alias keeps the value the app was using
+		exportedVariables, err := c.exportedVariables(templateRegistry, serviceTemplate.Name, previousName)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		for name, value := range linkVariables(previousName, serviceTemplate.Name, exportedVariables, false) {
+			appVariables[name] = value
+		}
+	}
+
+	exportedVariables, err := c.exportedVariables(templateRegistry, serviceTemplate.Name, serviceName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	for name, value := range linkVariables(serviceName, serviceTemplate.Name, exportedVariables, true) {
+		appVariables[name] = value
+	}
+
+	logger.LogHeader2("Setting exported variables on app")
+	if err := app.SetConfig(c.Context, app.SetConfigInput{
+		AppName:   appName,
+		NoRestart: c.noRestart,
+		Trace:     c.trace,
+		Variables: appVariables,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to set exported variables on app: %s", err.Error()))
+		return 1
+	}
+
+	logger.LogHeader2("Updating service links")
+	for _, previousName := range previousPrimaries {
+		if err := c.setPrimary(serviceTemplate.Name, previousName, appName, false); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if err := c.setPrimary(serviceTemplate.Name, serviceName, appName, true); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s promoted for %s", serviceTemplate.Name, serviceName, appName))
+	return 0
+}
+
+// exportedVariables renders the exported variables of a service
+func (c *ServicePromoteCommand) exportedVariables(templateRegistry registry.Registry, serviceType string, serviceName string) (map[string]string, error) {
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch service config: %s", err.Error())
+	}
+
+	exportedVariables, err := service.ExportedVariables(c.Context, service.ExportedVariablesInput{
+		ConfigOutput: config,
+		Hostname: network.Alias(network.AliasInput{
+			ServiceName: serviceName,
+			ServiceType: serviceType,
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to render exported variables: %s", err.Error())
+	}
+
+	return exportedVariables, nil
+}
+
+// setPrimary updates whether the link between a service and an app is primary
+func (c *ServicePromoteCommand) setPrimary(serviceType string, serviceName string, appName string, primary bool) error {
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceType,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to fetch service links: %s", err.Error())
+	}
+
+	for i, link := range links {
+		if link.App == appName {
+			links[i].Primary = primary
+		}
+	}
+
+	if err := service.WriteLinks(c.Context, service.WriteLinksInput{
+		DataRoot:    c.dataRoot,
+		Links:       links,
+		Name:        serviceName,
+		ServiceType: serviceType,
+	}); err != nil {
+		return fmt.Errorf("Failed to record service link: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServicePromoteCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "sets the unprefixed variables to the promoted service",
+			args: []string{"postgres", "gumdrop", "playground"},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				last := calls[len(calls)-1]
+				if !strings.Contains(last, "DATABASE_URL=postgres://postgres:") || !strings.Contains(last, "@dokku.postgres.gumdrop:5432/gumdrop") {
+					t.Errorf("expected DATABASE_URL to point at gumdrop, got %s", last)
+				}
+				if !strings.Contains(last, "DOKKU_POSTGRES_LOLLIPOP_URL=") {
+					t.Errorf("expected the previous primary's alias to be kept, got %s", last)
+				}
+			},
+		},
+		{
+			name: "does nothing for the primary service",
+			args: []string{"postgres", "lollipop", "playground"},
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.dokkuCalls()) != 2 {
+					t.Errorf("expected no further dokku calls, got %v", e.dokkuCalls())
+				}
+			},
+		},
+		{
+			name:     "fails for an unlinked app",
+			args:     []string{"postgres", "gumdrop", "sandbox"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.create("postgres", "gumdrop")
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "gumdrop", "playground")
+
+			e.run(&ServicePromoteCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
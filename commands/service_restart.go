@@ -0,0 +1,299 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/container"
+	"dokku-service/engine"
+	"dokku-service/image"
+	"dokku-service/network"
+	"dokku-service/service"
+	"dokku-service/volume"
+)
+
+type ServiceRestartCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// recreate specifies whether to recreate the container from the service config
+	recreate bool
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceRestartCommand) Name() string {
+	return "service-restart"
+}
+
+func (c *ServiceRestartCommand) Synopsis() string {
+	return "service-restart command"
+}
+
+func (c *ServiceRestartCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceRestartCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"restart the service container":  fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+		"recreate the service container": fmt.Sprintf("%s %s --recreate postgres lollipop", appName, c.Name()),
+	}
+}
+
+func (c *ServiceRestartCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceRestartCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceRestartCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceRestartCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.recreate, "recreate", false, "recreate the container from the service config")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceRestartCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceRestartCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	// configs written by older versions may hold flags the engine api does not support,
+	// which must be rejected before the existing container is removed
+	if err := engine.ValidateCreateFlags(config.Config.ContainerCreateFlags); err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid container create flags in service config, update them with service-set: %s", err.Error()))
+		return 1
+	}
+
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	imageName := image.Name(image.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerInput := serviceContainerInput{
+		Config:      config,
+		ImageName:   imageName,
+		Logger:      logger,
+		ServiceName: serviceName,
+		Template:    serviceTemplate, // use the registry's template to avoid using the temp path from config.Template
+		Trace:       c.trace,
+	}
+
+	recreate := c.recreate
+	if !containerExists {
+		logger.Info(fmt.Sprintf("Service %s container does not exist, recreating container", serviceName))
+		recreate = true
+	}
+
+	var dockerContainer engine.Container
+	if containerExists {
+		dockerContainer, err = container.Inspect(c.Context, container.InspectInput{
+			Name:  containerName,
+			Trace: c.trace,
+		})
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		configHash, err := service.ConfigHash(config)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		// containers created before the hash label existed are restarted in place
+		containerHash := dockerContainer.Labels[service.ConfigHashLabel]
+		if !recreate && containerHash != "" && containerHash != configHash {
+			logger.Info(fmt.Sprintf("Service %s config has changed, recreating container", serviceName))
+			recreate = true
+		}
+	}
+
+	logger.LogHeader1("Restarting service")
+	if recreate {
+		// the image is built before the existing container is removed,
+		// so a failed build leaves the service running
+		logger.LogHeader2("Building base image from template")
+		if err := image.Build(c.Context, image.BuildInput{
+			Arguments:  config.Config.Arguments,
+			BuildFlags: config.Config.ImageBuildFlags,
+			Name:       imageName,
+			Template:   serviceTemplate,
+			Trace:      c.trace,
+		}); err != nil {
+			c.Ui.Error("Failed to build image for service: " + err.Error())
+			return 1
+		}
+
+		if err := removeServiceContainer(c.Context, containerInput); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		createdVolumes, err := createServiceContainer(c.Context, containerInput)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		if err := startServiceContainer(c.Context, containerInput, createdVolumes); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		return 0
+	}
+
+	if err := stopSidecarContainers(c.Context, containerInput); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	logger.LogHeader2("Stopping container")
+	if err := container.Stop(c.Context, container.StopInput{
+		Name:  containerName,
+		Trace: c.trace,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to stop container: %s", err.Error()))
+		return 1
+	}
+
+	logger.LogHeader2("Attaching container to post-create networks")
+	networkAlias := network.Alias(network.AliasInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	for _, networkName := range config.Config.PostCreateNetworks {
+		if _, ok := dockerContainer.Networks[networkName]; ok {
+			continue
+		}
+
+		if err := network.Connect(c.Context, network.ConnectInput{
+			ContainerName: containerName,
+			NetworkAlias:  networkAlias,
+			NetworkName:   networkName,
+			Trace:         c.trace,
+		}); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to attach container to network: %s", err.Error()))
+			return 1
+		}
+	}
+
+	var serviceVolumes []volume.Volume
+	for _, volumeDescriptor := range config.Template.Volumes {
+		serviceVolumes = append(serviceVolumes, volume.Resolve(volume.ResolveInput{
+			DataRoot:         config.Config.DataRoot,
+			ServiceName:      serviceName,
+			Template:         config.Template,
+			UseVolumes:       config.Config.UseVolumes,
+			VolumeDescriptor: volumeDescriptor,
+		}))
+	}
+
+	if err := startServiceContainer(c.Context, containerInput, serviceVolumes); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
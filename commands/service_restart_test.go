@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"dokku-service/service"
+)
+
+func TestServiceRestartCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "restarts the container in place",
+			args: []string{"postgres", "lollipop"},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerStop dokku.postgres.lollipop") {
+					t.Errorf("expected container to be stopped, calls: %v", e.runtime.Calls)
+				}
+				if e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") {
+					t.Errorf("expected container not to be recreated")
+				}
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to be running")
+				}
+			},
+		},
+		{
+			name: "recreates the container with --recreate",
+			args: []string{"postgres", "lollipop", "--recreate"},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") {
+					t.Errorf("expected container to be recreated, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+		{
+			name: "recreates the container when the config has changed",
+			args: []string{"postgres", "lollipop"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "env", "FOO=bar")
+				e.runtime.Calls = nil
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") {
+					t.Errorf("expected container to be recreated, calls: %v", e.runtime.Calls)
+				}
+
+				c := e.container("dokku.postgres.lollipop")
+				if !c.Running {
+					t.Errorf("expected container to be running")
+				}
+				if !slices.Contains(c.Spec.Env, "FOO=bar") {
+					t.Errorf("expected recreated container env to contain FOO=bar, got %v", c.Spec.Env)
+				}
+			},
+		},
+		{
+			name:     "keeps the container when the image fails to build",
+			args:     []string{"postgres", "lollipop"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "env", "FOO=bar")
+				e.runtime.Errors["ImageBuild"] = errors.New("registry unavailable")
+				e.runtime.Calls = nil
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stderr.String(), "Failed to build image for service") {
+					t.Errorf("expected build error, got %s", e.stderr.String())
+				}
+				if e.runtime.HasCall("ContainerRemove dokku.postgres.lollipop") {
+					t.Errorf("expected container not to be removed, calls: %v", e.runtime.Calls)
+				}
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to be kept running")
+				}
+			},
+		},
+		{
+			name: "recreates a missing container",
+			args: []string{"postgres", "lollipop"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to be running")
+				}
+			},
+		},
+		{
+			name: "attaches newly configured post-create networks to unlabeled containers",
+			args: []string{"postgres", "lollipop"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["backend"] = true
+				e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "post-create-networks", "backend")
+				// containers created before the config hash label existed are restarted in place
+				delete(e.container("dokku.postgres.lollipop").Labels, service.ConfigHashLabel)
+				e.runtime.Calls = nil
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") {
+					t.Errorf("expected container without a config hash to be restarted in place")
+				}
+				if _, ok := e.container("dokku.postgres.lollipop").Networks["backend"]; !ok {
+					t.Errorf("expected container to be attached to backend")
+				}
+			},
+		},
+		{
+			name: "recreates the container with stored docker cli flags",
+			args: []string{"postgres", "lollipop", "--recreate"},
+			setup: func(e *testEnv) {
+				writeCreateFlags(e, "--publish 127.0.0.1:15432:5432 --hostname db", "--device /dev/fuse --mount type=tmpfs,target=/cache")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				c := e.container("dokku.postgres.lollipop")
+				if !c.Running || len(c.Spec.CreateFlags) != 2 {
+					t.Errorf("expected container to be recreated with the stored flags, got %v", c.Spec.CreateFlags)
+				}
+			},
+		},
+		{
+			name:     "rejects unsupported stored flags before removing the container",
+			args:     []string{"postgres", "lollipop", "--recreate"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				writeCreateFlags(e, "--gpus all")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stderr.String(), "unknown flag: --gpus") {
+					t.Errorf("expected error naming the unsupported flag, got %s", e.stderr.String())
+				}
+				if e.runtime.HasCall("ContainerRemove dokku.postgres.lollipop") || !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected container to be kept running, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.runtime.Calls = nil
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
+
+// writeCreateFlags stores container create flags in the lollipop service config as-is,
+// as a config written before the flags were validated would hold them
+func writeCreateFlags(e *testEnv, createFlags ...string) {
+	e.t.Helper()
+
+	config := e.config("postgres", "lollipop")
+	config.Config.ContainerCreateFlags = createFlags
+	if err := service.WriteConfig(e.ctx, service.WriteConfigInput{
+		ConfigOutput: config,
+	}); err != nil {
+		e.t.Fatalf("failed to write service config: %s", err)
+	}
+}
+
+func TestServiceRestartPostStartHook(t *testing.T) {
+	registryPath := t.TempDir()
+	writeTemplate(t, registryPath, "hooked", strings.Join([]string{
+		"ARG IMAGE=postgres:16.0",
+		"FROM ${IMAGE}",
+		"LABEL com.dokku.template.name=hooked",
+		`LABEL com.dokku.template.description="A template with a post-start hook"`,
+		"LABEL com.dokku.template.config.hooks.image=bash:5.2",
+		"LABEL com.dokku.template.config.hooks.post-start=true",
+		"LABEL com.dokku.template.config.ports.wait=5432",
+		"",
+	}, "\n"), "post-start")
+
+	e := newTestEnv(t)
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "hooked", "lollipop")
+	e.runtime.Calls = nil
+
+	e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "hooked", "lollipop")
+	if !e.runtime.HasCall("ContainerRun bash:5.2 /usr/local/bin/hook") {
+		t.Errorf("expected post-start hook to run again, calls: %v", e.runtime.Calls)
+	}
+}
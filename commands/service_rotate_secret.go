@@ -0,0 +1,322 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/app"
+	"dokku-service/argument"
+	"dokku-service/container"
+	"dokku-service/network"
+	"dokku-service/service"
+	"dokku-service/template"
+)
+
+type ServiceRotateSecretCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// noRestart specifies whether to skip restarting linked apps
+	noRestart bool
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceRotateSecretCommand) Name() string {
+	return "service-rotate-secret"
+}
+
+func (c *ServiceRotateSecretCommand) Synopsis() string {
+	return "service-rotate-secret command"
+}
+
+func (c *ServiceRotateSecretCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceRotateSecretCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"rotate the password of a service": fmt.Sprintf("%s %s postgres lollipop POSTGRES_PASSWORD", appName, c.Name()),
+	}
+}
+
+func (c *ServiceRotateSecretCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "argument",
+		Description: "the _SECRET argument to rotate, with or without the _SECRET suffix",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceRotateSecretCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceRotateSecretCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceRotateSecretCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.BoolVar(&c.noRestart, "no-restart", false, "do not restart linked apps after rotating")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceRotateSecretCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceRotateSecretCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	argumentKey := strings.TrimSuffix(arguments["argument"].StringValue(), "_SECRET") + "_SECRET"
+	currentArgument, ok := config.Config.Arguments[argumentKey]
+	if !ok {
+		c.Ui.Error(fmt.Sprintf("%s service %s has no secret argument %s", serviceTemplate.Name, serviceName, argumentKey))
+		return 1
+	}
+
+	var templateArgument *template.Argument
+	for _, arg := range serviceTemplate.Arguments {
+		if arg.Name == argumentKey {
+			templateArgument = &arg
+			break
+		}
+	}
+	if templateArgument == nil || !templateArgument.IsVariable {
+		c.Ui.Error(fmt.Sprintf("Argument %s has no template to generate a new value from", argumentKey))
+		return 1
+	}
+
+	if _, ok := serviceTemplate.Commands["rotate-secret"]; !ok {
+		c.Ui.Error(fmt.Sprintf("%s service %s does not support rotate-secret command", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	containerRunning := false
+	if containerExists {
+		dockerContainer, err := container.Inspect(c.Context, container.InspectInput{
+			Name:  containerName,
+			Trace: c.trace,
+		})
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		containerRunning = dockerContainer.Running
+	}
+
+	if !containerRunning {
+		c.Ui.Error(fmt.Sprintf("%s service %s must be running to rotate a secret", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	newValue, err := template.RenderArgument(*templateArgument)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to generate new value for %s: %s", argumentKey, err.Error()))
+		return 1
+	}
+
+	envKey := strings.TrimSuffix(argumentKey, "_SECRET")
+	newConfig := config
+	newConfig.Config.Arguments = map[string]argument.Argument{}
+	for key, value := range config.Config.Arguments {
+		newConfig.Config.Arguments[key] = value
+	}
+	newArgument := currentArgument
+	newArgument.Value = newValue
+	newConfig.Config.Arguments[argumentKey] = newArgument
+	newConfig.Config.EnvironmentVariables = copyEnvironmentVariables(config.Config.EnvironmentVariables)
+	newConfig.Config.EnvironmentVariables[envKey] = newValue
+
+	logger.LogHeader1(fmt.Sprintf("Rotating %s for %s service %s", envKey, serviceTemplate.Name, serviceName))
+
+	// the rotation command sees the new value under the usual key and the old one prefixed with PREVIOUS_
+	rotateConfig := newConfig
+	rotateConfig.Template.Commands = serviceTemplate.Commands
+	rotateConfig.Config.EnvironmentVariables = copyEnvironmentVariables(newConfig.Config.EnvironmentVariables)
+	rotateConfig.Config.EnvironmentVariables["PREVIOUS_"+envKey] = currentArgument.Value
+
+	// the new value is persisted before it is applied so it is never only known to the running service
+	logger.LogHeader2("Writing settings for service")
+	if err := writeServiceSettings(c.Context, newConfig); err != nil {
+		c.Ui.Error(err.Error())
+		c.restoreServiceSettings(logger, config)
+		return 1
+	}
+
+	logger.LogHeader2("Applying new value to service")
+	if err := container.Execute(c.Context, container.ExecuteInput{
+		Name:         containerName,
+		CommandName:  "rotate-secret",
+		ConfigOutput: rotateConfig,
+		StdOutWriter: io.Discard,
+		Trace:        c.trace,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to apply new value to service: %s", err.Error()))
+		c.restoreServiceSettings(logger, config)
+		return 1
+	}
+
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service links: %s", err.Error()))
+		return 1
+	}
+
+	if len(links) > 0 {
+		exportedVariables, err := service.ExportedVariables(c.Context, service.ExportedVariablesInput{
+			ConfigOutput: newConfig,
+			Hostname: network.Alias(network.AliasInput{
+				ServiceName: serviceName,
+				ServiceType: serviceTemplate.Name,
+			}),
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to render exported variables: %s", err.Error()))
+			return 1
+		}
+
+		logger.LogHeader2("Updating exported variables on linked apps")
+		for _, link := range links {
+			if err := app.SetConfig(c.Context, app.SetConfigInput{
+				AppName:   link.App,
+				NoRestart: c.noRestart,
+				Trace:     c.trace,
+				Variables: linkVariables(serviceName, serviceTemplate.Name, exportedVariables, link.Primary),
+			}); err != nil {
+				c.Ui.Error(fmt.Sprintf("Failed to set exported variables on app %s: %s", link.App, err.Error()))
+				return 1
+			}
+		}
+	}
+
+	logger.Info(fmt.Sprintf("%s rotated, run service-restart to recreate the container with the new environment", envKey))
+	return 0
+}
+
+// restoreServiceSettings writes back the settings a service had before the rotation
+func (c *ServiceRotateSecretCommand) restoreServiceSettings(logger *command.ZerologUi, config service.ConfigOutput) {
+	logger.LogHeader2("Restoring previous settings for service")
+
+	if err := writeServiceSettings(c.Context, config); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to restore previous settings for service: %s", err.Error()))
+	}
+}
+
+// writeServiceSettings writes the config and env file of a service
+func writeServiceSettings(ctx context.Context, config service.ConfigOutput) error {
+	if err := service.WriteConfig(ctx, service.WriteConfigInput{
+		ConfigOutput: config,
+	}); err != nil {
+		return fmt.Errorf("failed to write service config: %s", err.Error())
+	}
+
+	if err := service.WriteEnvFile(ctx, service.WriteEnvFileInput{
+		ConfigOutput: config,
+	}); err != nil {
+		return fmt.Errorf("failed to write service env file: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceRotateSecretCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		createArgs []string
+		exitCode   int
+		setup      func(e *testEnv)
+		check      func(t *testing.T, e *testEnv, previous string)
+	}{
+		{
+			name: "applies and records a new secret",
+			args: []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				current := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+				if current == "" || current == previous {
+					t.Errorf("expected a new POSTGRES_PASSWORD, got %q", current)
+				}
+				if !e.runtime.HasCall("ContainerExec dokku.postgres.lollipop psql -h localhost -U postgres -w -c ALTER USER postgres WITH PASSWORD '" + current + "'") {
+					t.Errorf("expected new password to be applied, calls: %v", e.runtime.Calls)
+				}
+			},
+		},
+		{
+			name: "records the new secret before applying it",
+			args: []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					recorded := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+					if !strings.Contains(strings.Join(options.Command, " "), "'"+recorded+"'") {
+						return fmt.Errorf("expected the applied secret to be recorded, got %q", recorded)
+					}
+					return nil
+				}
+			},
+		},
+		{
+			name:       "keeps the argument marked as secret",
+			args:       []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			createArgs: []string{"--password", "hunter2"},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				argument := e.config("postgres", "lollipop").Config.Arguments["POSTGRES_PASSWORD_SECRET"]
+				if argument.Value == previous || !argument.Secret || !argument.Override {
+					t.Errorf("expected only the argument value to change, got %+v", argument)
+				}
+			},
+		},
+		{
+			name: "applies the new secret to the container on restart",
+			args: []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				current := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+				e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				if env := e.container("dokku.postgres.lollipop").Spec.Env; !slices.Contains(env, "POSTGRES_PASSWORD="+current) {
+					t.Errorf("expected restarted container env to contain the new password, got %v", env)
+				}
+			},
+		},
+		{
+			name: "updates linked apps",
+			args: []string{"postgres", "lollipop", "POSTGRES_PASSWORD_SECRET", "--no-restart"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				current := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+				calls := e.dokkuCalls()
+				last := calls[len(calls)-1]
+				if !strings.HasPrefix(last, "config:set --no-restart playground") || !strings.Contains(last, current) {
+					t.Errorf("expected linked app to receive the new password, got %s", last)
+				}
+			},
+		},
+		{
+			name:     "keeps the previous secret when it cannot be applied",
+			args:     []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.ExecHandler = func(name string, options engine.ExecOptions) error {
+					return &engine.ExitError{Code: 1}
+				}
+			},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				if current := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]; current != previous {
+					t.Errorf("expected POSTGRES_PASSWORD to be unchanged, got %q", current)
+				}
+
+				env, err := os.ReadFile(filepath.Join(e.dataRoot, "postgres", "lollipop", ".env"))
+				if err != nil || !strings.Contains(string(env), "POSTGRES_PASSWORD="+previous+"\n") {
+					t.Errorf("expected env file to hold the previous POSTGRES_PASSWORD, got %q (%v)", env, err)
+				}
+			},
+		},
+		{
+			name:     "fails for an unknown secret argument",
+			args:     []string{"postgres", "lollipop", "POSTGRES_DB"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails when the service is not running",
+			args:     []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServicePauseCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+		},
+		{
+			name:     "fails before recording a secret when the container is stopped",
+			args:     []string{"postgres", "lollipop", "POSTGRES_PASSWORD"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.container("dokku.postgres.lollipop").Running = false
+			},
+			check: func(t *testing.T, e *testEnv, previous string) {
+				if !strings.Contains(e.stderr.String(), "must be running to rotate a secret") {
+					t.Errorf("expected not running error, got %s", e.stderr.String())
+				}
+				if current := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]; current != previous {
+					t.Errorf("expected POSTGRES_PASSWORD to be unchanged, got %q", current)
+				}
+				if strings.Contains(e.stdout.String(), "Restoring previous settings") {
+					t.Errorf("expected settings not to be written, got %s", e.stdout.String())
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop", tt.createArgs...)
+			previous := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceRotateSecretCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e, previous)
+			}
+		})
+	}
+}
+
+func TestServiceRotateSecretUserPassword(t *testing.T) {
+	registryPath := t.TempDir()
+	writeTemplate(t, registryPath, "mariadb", strings.Join([]string{
+		"ARG IMAGE=mariadb:11.4",
+		"FROM ${IMAGE}",
+		"LABEL com.dokku.template.name=mariadb",
+		`LABEL com.dokku.template.description="A template for managing mariadb"`,
+		`LABEL com.dokku.template.config.commands.rotate-secret="mariadb-rotate \"--from={{ .PREVIOUS_MARIADB_ROOT_PASSWORD }}\" \"--to={{ .MARIADB_ROOT_PASSWORD }}\""`,
+		"LABEL com.dokku.template.config.ports.wait=3306",
+		"LABEL com.dokku.template.config.variables.mapped.root-password=MARIADB_ROOT_PASSWORD",
+		`ARG MARIADB_ROOT_PASSWORD_SECRET="{{ randAlphaNum 32 }}"`,
+		"",
+	}, "\n"))
+
+	e := newTestEnv(t)
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "mariadb", "lollipop", "--root-password", "a&b+c'd<e")
+	e.run(&ServiceRotateSecretCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "mariadb", "lollipop", "MARIADB_ROOT_PASSWORD")
+
+	prefix := "ContainerExec dokku.mariadb.lollipop mariadb-rotate --from=a&b+c'd<e --to="
+	if !slices.ContainsFunc(e.runtime.Calls, func(call string) bool { return strings.HasPrefix(call, prefix) }) {
+		t.Errorf("expected call starting with %q, calls: %v", prefix, e.runtime.Calls)
+	}
+}
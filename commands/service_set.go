@@ -0,0 +1,578 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+	"mvdan.cc/sh/v3/shell"
+
+	"dokku-service/argument"
+	"dokku-service/container"
+	"dokku-service/engine"
+	"dokku-service/network"
+	"dokku-service/service"
+	"dokku-service/template"
+)
+
+// envKeyPattern matches valid environment variable names
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// serviceProperty describes a service config property that can be changed after creation
+type serviceProperty struct {
+	// Display returns the value of the property to print, defaulting to Value
+	Display func(config service.ConfigOutput) string
+
+	// Recreate specifies whether the container must be recreated for a change to take effect
+	Recreate bool
+
+	// Set validates and applies new values for the property
+	Set func(ctx context.Context, input servicePropertyInput) error
+
+	// Unset resets the property to its default
+	Unset func(ctx context.Context, input servicePropertyInput) error
+
+	// Value returns the current value of the property
+	Value func(config service.ConfigOutput) string
+}
+
+// servicePropertyInput contains the input parameters for changing a service property
+type servicePropertyInput struct {
+	// Config is the service config to change
+	Config *service.ConfigOutput
+
+	// Template is the registry's template, used for property defaults
+	Template template.ServiceTemplate
+
+	// Trace controls whether to print the commands being executed
+	Trace bool
+
+	// Values are the values passed for the property
+	Values []string
+}
+
+// serviceProperties are the service config properties that can be changed via service-set and service-unset
+var serviceProperties = map[string]serviceProperty{
+	"container-create-flags": {
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			for _, createFlag := range input.Values {
+				if _, err := shell.Fields(createFlag, nil); err != nil {
+					return fmt.Errorf("invalid container create flag '%s': %w", createFlag, err)
+				}
+			}
+			if err := engine.ValidateCreateFlags(input.Values); err != nil {
+				return err
+			}
+
+			input.Config.Config.ContainerCreateFlags = input.Values
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			input.Config.Config.ContainerCreateFlags = []string{}
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			return strings.Join(config.Config.ContainerCreateFlags, " ")
+		},
+	},
+	"env": {
+		// values may hold credentials, so only the keys are printed
+		Display: func(config service.ConfigOutput) string {
+			return strings.Join(serviceEnvKeys(config), " ")
+		},
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			env := copyEnvironmentVariables(input.Config.Config.EnvironmentVariables)
+			for _, value := range input.Values {
+				key, envValue, ok := strings.Cut(value, "=")
+				if !ok {
+					return fmt.Errorf("invalid environment variable '%s', expected KEY=VALUE", value)
+				}
+				if err := validateEnvKey(*input.Config, key); err != nil {
+					return err
+				}
+
+				env[key] = envValue
+			}
+
+			input.Config.Config.EnvironmentVariables = env
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			keys := input.Values
+			if len(keys) == 0 {
+				keys = serviceEnvKeys(*input.Config)
+			}
+
+			env := copyEnvironmentVariables(input.Config.Config.EnvironmentVariables)
+			for _, key := range keys {
+				if err := validateEnvKey(*input.Config, key); err != nil {
+					return err
+				}
+
+				delete(env, key)
+			}
+
+			input.Config.Config.EnvironmentVariables = env
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			pairs := []string{}
+			for _, key := range serviceEnvKeys(config) {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", key, config.Config.EnvironmentVariables[key]))
+			}
+
+			return strings.Join(pairs, " ")
+		},
+	},
+	"image-name": {
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			if len(input.Values) != 1 || input.Values[0] == "" {
+				return fmt.Errorf("exactly one image name must be specified")
+			}
+
+			_, imageTag := splitImage(input.Config.Config.Arguments["IMAGE"].Value)
+			setImageArgument(input.Config, input.Values[0], imageTag)
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			_, imageTag := splitImage(input.Config.Config.Arguments["IMAGE"].Value)
+			setImageArgument(input.Config, input.Template.Image.Name, imageTag)
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			imageName, _ := splitImage(config.Config.Arguments["IMAGE"].Value)
+			return imageName
+		},
+	},
+	"image-tag": {
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			if len(input.Values) != 1 || input.Values[0] == "" {
+				return fmt.Errorf("exactly one image tag must be specified")
+			}
+
+			imageName, _ := splitImage(input.Config.Config.Arguments["IMAGE"].Value)
+			setImageArgument(input.Config, imageName, input.Values[0])
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			imageName, _ := splitImage(input.Config.Config.Arguments["IMAGE"].Value)
+			setImageArgument(input.Config, imageName, input.Template.Image.Tag)
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			_, imageTag := splitImage(config.Config.Arguments["IMAGE"].Value)
+			return imageTag
+		},
+	},
+	"post-create-networks": {
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			if err := validateNetworks(ctx, input.Values, input.Trace); err != nil {
+				return err
+			}
+
+			input.Config.Config.PostCreateNetworks = input.Values
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			input.Config.Config.PostCreateNetworks = []string{}
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			return strings.Join(config.Config.PostCreateNetworks, " ")
+		},
+	},
+	"post-start-networks": {
+		// networks stay attached across restarts, so removing one requires a new container
+		Recreate: true,
+		Set: func(ctx context.Context, input servicePropertyInput) error {
+			if err := validateNetworks(ctx, input.Values, input.Trace); err != nil {
+				return err
+			}
+
+			input.Config.Config.PostStartNetworks = input.Values
+			return nil
+		},
+		Unset: func(ctx context.Context, input servicePropertyInput) error {
+			input.Config.Config.PostStartNetworks = []string{}
+			return nil
+		},
+		Value: func(config service.ConfigOutput) string {
+			return strings.Join(config.Config.PostStartNetworks, " ")
+		},
+	},
+}
+
+type ServiceSetCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceSetCommand) Name() string {
+	return "service-set"
+}
+
+func (c *ServiceSetCommand) Synopsis() string {
+	return "service-set command"
+}
+
+func (c *ServiceSetCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceSetCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"set the post-start networks":    fmt.Sprintf("%s %s postgres lollipop post-start-networks bridge-a,bridge-b", appName, c.Name()),
+		"set an environment variable":    fmt.Sprintf("%s %s postgres lollipop env POSTGRES_INITDB_ARGS=--data-checksums", appName, c.Name()),
+		"set the container create flags": fmt.Sprintf("%s %s -- postgres lollipop container-create-flags '--shm-size 256m'", appName, c.Name()),
+	}
+}
+
+func (c *ServiceSetCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "property",
+		Description: fmt.Sprintf("the property to set, one of: %s", strings.Join(servicePropertyNames(), ", ")),
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "values",
+		Description: "the values to set the property to",
+		Optional:    false,
+		Type:        command.ArgumentList,
+	})
+	return args
+}
+
+func (c *ServiceSetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceSetCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceSetCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceSetCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceSetCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	propertyName := arguments["property"].StringValue()
+	property, ok := serviceProperties[propertyName]
+	if !ok {
+		c.Ui.Error(fmt.Sprintf("Invalid property '%s', must be one of: %s", propertyName, strings.Join(servicePropertyNames(), ", ")))
+		return 1
+	}
+
+	values := arguments["values"].ListValue()
+	if strings.HasSuffix(propertyName, "-networks") {
+		values = splitNetworks(values)
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	newConfig := config
+	if err := property.Set(c.Context, servicePropertyInput{
+		Config:   &newConfig,
+		Template: serviceTemplate,
+		Trace:    c.trace,
+		Values:   values,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to set %s: %s", propertyName, err.Error()))
+		return 1
+	}
+
+	if err := updateServiceProperty(c.Context, updateServicePropertyInput{
+		Config:       config,
+		Logger:       logger,
+		NewConfig:    newConfig,
+		Property:     property,
+		PropertyName: propertyName,
+		ServiceName:  serviceName,
+		ServiceType:  serviceTemplate.Name,
+		Trace:        c.trace,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// updateServicePropertyInput contains the input parameters for the updateServiceProperty function
+type updateServicePropertyInput struct {
+	// Config is the service config before the change
+	Config service.ConfigOutput
+
+	// Logger is the logger to write the audit to
+	Logger *command.ZerologUi
+
+	// NewConfig is the service config after the change
+	NewConfig service.ConfigOutput
+
+	// Property is the property being changed
+	Property serviceProperty
+
+	// PropertyName is the name of the property being changed
+	PropertyName string
+
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+
+	// Trace controls whether to print the commands being executed
+	Trace bool
+}
+
+// updateServiceProperty persists a changed service config, logging what changed and how to apply it
+func updateServiceProperty(ctx context.Context, input updateServicePropertyInput) error {
+	display := input.Property.Value
+	if input.Property.Display != nil {
+		display = input.Property.Display
+	}
+
+	if input.Property.Value(input.Config) == input.Property.Value(input.NewConfig) {
+		input.Logger.Info(fmt.Sprintf("%s is already set to %s", input.PropertyName, displayPropertyValue(display(input.NewConfig))))
+		return nil
+	}
+
+	input.Logger.LogHeader1(fmt.Sprintf("Updating %s for %s service %s", input.PropertyName, input.ServiceType, input.ServiceName))
+	if err := service.WriteConfig(ctx, service.WriteConfigInput{
+		ConfigOutput: input.NewConfig,
+	}); err != nil {
+		return fmt.Errorf("failed to write service config: %s", err.Error())
+	}
+
+	if err := service.WriteEnvFile(ctx, service.WriteEnvFileInput{
+		ConfigOutput: input.NewConfig,
+	}); err != nil {
+		return fmt.Errorf("failed to write service env file: %s", err.Error())
+	}
+
+	input.Logger.Info(fmt.Sprintf("previous: %s", displayPropertyValue(display(input.Config))))
+	input.Logger.Info(fmt.Sprintf("current:  %s", displayPropertyValue(display(input.NewConfig))))
+
+	containerExists, err := container.Exists(ctx, container.ExistsInput{
+		Name: container.Name(container.NameInput{
+			ServiceName: input.ServiceName,
+			ServiceType: input.ServiceType,
+		}),
+		Trace: input.Trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for container existence: %s", err.Error())
+	}
+
+	switch {
+	case !containerExists:
+		input.Logger.Info("Change will take effect when the service is next started")
+	case input.Property.Recreate:
+		input.Logger.Info("Change requires recreating the container, run service-restart to apply it")
+	default:
+		input.Logger.Info("Change requires restarting the container, run service-restart to apply it")
+	}
+
+	return nil
+}
+
+// setImageArgument points the IMAGE argument of a service config at a new image
+func setImageArgument(config *service.ConfigOutput, imageName string, imageTag string) {
+	reference := fmt.Sprintf("%s:%s", imageName, imageTag)
+	arguments := map[string]argument.Argument{}
+	for key, value := range config.Config.Arguments {
+		arguments[key] = value
+	}
+	arguments["IMAGE"] = argument.Argument{
+		Key:      "IMAGE",
+		Value:    reference,
+		Override: true,
+	}
+
+	env := copyEnvironmentVariables(config.Config.EnvironmentVariables)
+	env["IMAGE"] = reference
+
+	config.Config.Arguments = arguments
+	config.Config.EnvironmentVariables = env
+	config.Config.Image = service.RunImageConfig{
+		Name: imageName,
+		Tag:  imageTag,
+	}
+}
+
+// copyEnvironmentVariables returns a copy of a set of environment variables
+func copyEnvironmentVariables(env map[string]string) map[string]string {
+	copied := map[string]string{}
+	for key, value := range env {
+		copied[key] = value
+	}
+	return copied
+}
+
+// serviceEnvKeys returns the sorted environment variable keys of a service that are not set by arguments
+func serviceEnvKeys(config service.ConfigOutput) []string {
+	keys := []string{}
+	for key := range config.Config.EnvironmentVariables {
+		if validateEnvKey(config, key) == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateEnvKey ensures an environment variable is validly named and not set by an argument
+func validateEnvKey(config service.ConfigOutput, key string) error {
+	if !envKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid environment variable name '%s'", key)
+	}
+
+	for _, arg := range config.Config.Arguments {
+		if strings.TrimSuffix(arg.Key, "_SECRET") == key {
+			return fmt.Errorf("environment variable '%s' must be set by argument", key)
+		}
+	}
+
+	return nil
+}
+
+// validateNetworks ensures each network exists
+func validateNetworks(ctx context.Context, networks []string, trace bool) error {
+	for _, networkName := range networks {
+		ok, err := network.Exists(ctx, network.ExistsInput{
+			Name:  networkName,
+			Trace: trace,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check for network existence: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("missing network: %s", networkName)
+		}
+	}
+
+	return nil
+}
+
+// splitNetworks splits comma-separated network lists into individual networks
+func splitNetworks(values []string) []string {
+	networks := []string{}
+	for _, value := range values {
+		for _, networkName := range strings.Split(value, ",") {
+			if networkName = strings.TrimSpace(networkName); networkName != "" {
+				networks = append(networks, networkName)
+			}
+		}
+	}
+	return networks
+}
+
+// servicePropertyNames returns the sorted names of the properties that can be changed
+func servicePropertyNames() []string {
+	names := []string{}
+	for name := range serviceProperties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// displayPropertyValue returns a property value for display, marking empty values
+func displayPropertyValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
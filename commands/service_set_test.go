@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServiceSetCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "sets an environment variable",
+			args: []string{"postgres", "lollipop", "env", "POSTGRES_INITDB_ARGS=--data-checksums"},
+			check: func(t *testing.T, e *testEnv) {
+				if value := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_INITDB_ARGS"]; value != "--data-checksums" {
+					t.Errorf("expected POSTGRES_INITDB_ARGS to be set, got %q", value)
+				}
+
+				env, err := os.ReadFile(filepath.Join(e.dataRoot, "postgres", "lollipop", ".env"))
+				if err != nil || !strings.Contains(string(env), "POSTGRES_INITDB_ARGS=--data-checksums") {
+					t.Errorf("expected env file to contain POSTGRES_INITDB_ARGS, got %q (%v)", env, err)
+				}
+			},
+		},
+		{
+			name: "applies an environment variable to the container on restart",
+			args: []string{"postgres", "lollipop", "env", "FOO=bar"},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stdout.String(), "Change requires recreating the container, run service-restart to apply it") {
+					t.Errorf("expected recreate notice, got %s", e.stdout.String())
+				}
+
+				e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				c, ok := e.runtime.Containers["dokku.postgres.lollipop"]
+				if !ok {
+					t.Fatalf("expected container to exist, got %v", e.runtime.ContainerNames())
+				}
+				if !slices.Contains(c.Spec.Env, "FOO=bar") {
+					t.Errorf("expected restarted container env to contain FOO=bar, got %v", c.Spec.Env)
+				}
+			},
+		},
+		{
+			name: "prints only the keys of environment variables",
+			args: []string{"postgres", "lollipop", "env", "API_TOKEN=hunter2"},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stdout.String(), "current:  API_TOKEN") {
+					t.Errorf("expected the key to be printed, got %s", e.stdout.String())
+				}
+
+				password := e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]
+				for _, secret := range []string{"hunter2", password} {
+					if strings.Contains(e.stdout.String(), secret) {
+						t.Errorf("expected %q not to be printed, got %s", secret, e.stdout.String())
+					}
+				}
+			},
+		},
+		{
+			name: "sets the container create flags",
+			args: []string{"--", "postgres", "lollipop", "container-create-flags", "--shm-size 256m"},
+			check: func(t *testing.T, e *testEnv) {
+				flags := e.config("postgres", "lollipop").Config.ContainerCreateFlags
+				if len(flags) != 1 || flags[0] != "--shm-size 256m" {
+					t.Errorf("expected container create flags to be set, got %v", flags)
+				}
+			},
+		},
+		{
+			name: "sets the post-start networks",
+			args: []string{"postgres", "lollipop", "post-start-networks", "backend"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["backend"] = true
+			},
+			check: func(t *testing.T, e *testEnv) {
+				networks := e.config("postgres", "lollipop").Config.PostStartNetworks
+				if len(networks) != 1 || networks[0] != "backend" {
+					t.Errorf("expected post-start networks to be set, got %v", networks)
+				}
+			},
+		},
+		{
+			name: "detaches a removed post-start network on restart",
+			args: []string{"postgres", "lollipop", "post-start-networks", "frontend"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["backend"] = true
+				e.runtime.Networks["frontend"] = true
+				e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "post-start-networks", "backend")
+				e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stdout.String(), "Change requires recreating the container, run service-restart to apply it") {
+					t.Errorf("expected recreate notice, got %s", e.stdout.String())
+				}
+
+				e.run(&ServiceRestartCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				networks := e.container("dokku.postgres.lollipop").Networks
+				if _, ok := networks["backend"]; ok {
+					t.Errorf("expected container to be detached from backend, got %v", networks)
+				}
+				if _, ok := networks["frontend"]; !ok {
+					t.Errorf("expected container to be attached to frontend, got %v", networks)
+				}
+			},
+		},
+		{
+			name:     "fails for a missing network",
+			args:     []string{"postgres", "lollipop", "post-start-networks", "backend"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails for an environment variable set by argument",
+			args:     []string{"postgres", "lollipop", "env", "POSTGRES_PASSWORD=hunter2"},
+			exitCode: 1,
+		},
+		{
+			name:     "fails for an invalid property",
+			args:     []string{"postgres", "lollipop", "bogus", "value"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
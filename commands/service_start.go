@@ -7,17 +7,13 @@ import (
 	"path/filepath"
 
 	"github.com/josegonzalez/cli-skeleton/command"
-	"github.com/moby/moby/client"
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
 
 	"dokku-service/container"
-	"dokku-service/healthcheck"
-	"dokku-service/hook"
 	"dokku-service/image"
-	"dokku-service/network"
+	"dokku-service/plan"
 	"dokku-service/service"
-	"dokku-service/volume"
 )
 
 type ServiceStartCommand struct {
@@ -29,6 +25,12 @@ type ServiceStartCommand struct {
 	// dataRoot specifies the root directory for service data
 	dataRoot string
 
+	// dryRun specifies whether to output the planned changes instead of making them
+	dryRun bool
+
+	// format specifies the output format of the planned changes
+	format string
+
 	// registryPath specifies an override path to the registry
 	registryPath string
 
@@ -84,6 +86,8 @@ func (c *ServiceStartCommand) FlagSet() *flag.FlagSet {
 	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
 	f.BoolVar(&c.trace, "trace", false, "output trace information")
 	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.dryRun, "dry-run", false, "output the planned changes instead of making them")
+	f.StringVar(&c.format, "format", "text", "the output format to use for planned changes: [text, json]")
 	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
 	return f
 }
@@ -91,7 +95,9 @@ func (c *ServiceStartCommand) FlagSet() *flag.FlagSet {
 func (c *ServiceStartCommand) AutocompleteFlags() complete.Flags {
 	return command.MergeAutocompleteFlags(
 		c.Meta.AutocompleteFlags(command.FlagSetClient),
-		complete.Flags{},
+		complete.Flags{
+			"--format": complete.PredictSet("text", "json"),
+		},
 	)
 }
 
@@ -111,6 +117,29 @@ func (c *ServiceStartCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != "text" && c.format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid format specified: %s", c.format))
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.dryRun {
+		return dryRun(dryRunInput{
+			Context: &c.Context,
+			Format:  c.format,
+			Meta:    &c.Meta,
+			Run: func() int {
+				return c.startService(arguments)
+			},
+			Title: fmt.Sprintf("Planned changes to start %s service %s", arguments["template"].StringValue(), arguments["name"].StringValue()),
+		})
+	}
+
+	return c.startService(arguments)
+}
+
+// startService starts a service from parsed arguments, returning the exit code
+func (c *ServiceStartCommand) startService(arguments map[string]command.Argument) int {
 	logger, ok := c.Ui.(*command.ZerologUi)
 	if !ok {
 		c.Ui.Error("Unable to fetch logger from cli")
@@ -136,10 +165,18 @@ func (c *ServiceStartCommand) Run(args []string) int {
 		ServiceName: serviceName,
 		ServiceType: serviceTemplate.Name,
 	})
-	networkAlias := network.Alias(network.AliasInput{
-		ServiceName: serviceName,
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
 		ServiceType: serviceTemplate.Name,
 	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+	redactSecretArguments(c.Context, config.Config.Arguments)
+
 	containerExists, err := container.Exists(c.Context, container.ExistsInput{
 		Name:  containerName,
 		Trace: c.trace,
@@ -150,23 +187,38 @@ func (c *ServiceStartCommand) Run(args []string) int {
 	}
 
 	if containerExists {
-		cli, err := client.NewClientWithOpts(
-			client.FromEnv,
-			client.WithAPIVersionNegotiation(),
-		)
+		dockerContainer, err := container.Inspect(c.Context, container.InspectInput{
+			Name:  containerName,
+			Trace: c.trace,
+		})
 		if err != nil {
 			c.Ui.Error(err.Error())
 			return 1
 		}
 
-		dockerContainer, err := cli.ContainerInspect(c.Context, containerName)
-		if err != nil {
-			c.Ui.Error(err.Error())
-			return 1
+		sidecarInput := serviceContainerInput{
+			Config:      config,
+			Logger:      logger,
+			ServiceName: serviceName,
+			Template:    serviceTemplate,
+			Trace:       c.trace,
 		}
-
-		if dockerContainer.State.Running {
+		if dockerContainer.Running {
 			c.Ui.Info(fmt.Sprintf("Service %s is already running", serviceName))
+			if err := ensureAmbassador(c.Context, ensureAmbassadorInput{
+				Config:        config,
+				ContainerName: containerName,
+				ServiceName:   serviceName,
+				Trace:         c.trace,
+			}); err != nil {
+				c.Ui.Error(fmt.Sprintf("Failed to start ambassador: %s", err.Error()))
+				return 1
+			}
+
+			if err := startSidecarContainers(c.Context, sidecarInput); err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
 			return 0
 		}
 
@@ -180,21 +232,25 @@ func (c *ServiceStartCommand) Run(args []string) int {
 			return 1
 		}
 
-		return 0
-	}
+		if err := ensureAmbassador(c.Context, ensureAmbassadorInput{
+			Config:        config,
+			ContainerName: containerName,
+			ServiceName:   serviceName,
+			Trace:         c.trace,
+		}); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to start ambassador: %s", err.Error()))
+			return 1
+		}
 
-	config, err := service.Config(c.Context, service.ConfigInput{
-		DataRoot:    c.dataRoot,
-		Name:        serviceName,
-		Registry:    templateRegistry,
-		ServiceType: serviceTemplate.Name,
-	})
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
-		return 1
+		if err := startSidecarContainers(c.Context, sidecarInput); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		return 0
 	}
 
-	err = os.RemoveAll(filepath.Join(config.Config.ServiceRoot, "ID"))
+	err = plan.RemoveAll(c.Context, filepath.Join(config.Config.ServiceRoot, "ID"))
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Failed to remove ID file: %s", err.Error()))
 		return 1
@@ -229,148 +285,22 @@ func (c *ServiceStartCommand) Run(args []string) int {
 		}
 	}
 
-	logger.LogHeader2("Creating volumes")
-	var createdVolumes []volume.Volume
-	for _, volumeDescriptor := range config.Template.Volumes {
-		volume, err := volume.Create(c.Context, volume.CreateInput{
-			DataRoot:         config.Config.DataRoot,
-			ServiceName:      serviceName,
-			Template:         config.Template,
-			Trace:            c.trace,
-			UseVolumes:       config.Config.UseVolumes,
-			VolumeDescriptor: volumeDescriptor,
-		})
-		if err != nil {
-			c.Ui.Error("Failed to run volume for service: " + err.Error())
-			return 1
-		}
-
-		createdVolumes = append(createdVolumes, volume)
-	}
-
-	logger.LogHeader2("Executing pre-create hook")
-	err = hook.Execute(c.Context, hook.ExecuteInput{
-		DataRoot:    config.Config.DataRoot,
-		Exists:      config.Template.Hooks.PreCreate,
-		Name:        "pre-create",
+	containerInput := serviceContainerInput{
+		Config:      config,
+		ImageName:   imageName,
+		Logger:      logger,
 		ServiceName: serviceName,
 		Template:    serviceTemplate, // use the registry's template to avoid using the temp path from config.Template
-		Volumes:     createdVolumes,
 		Trace:       c.trace,
-	})
-	if err != nil {
-		c.Ui.Error("Failed to execute pre-create hook for service: " + err.Error())
-		return 1
 	}
-
-	logger.LogHeader2("Creating container")
-	envFile := fmt.Sprintf("%s/.env", config.Config.ServiceRoot)
-	err = container.Create(c.Context, container.CreateInput{
-		CreateFlags:   config.Config.ContainerCreateFlags,
-		ContainerName: containerName,
-		EnvFile:       envFile,
-		ImageName:     imageName,
-		ServiceRoot:   config.Config.ServiceRoot,
-		Trace:         c.trace,
-		UseVolumes:    config.Config.UseVolumes,
-		Volumes:       createdVolumes,
-	})
-	if err != nil {
-		c.Ui.Error("Failed to create container for service: " + err.Error())
-		return 1
-	}
-
-	logger.LogHeader2("Attaching container to post-create networks")
-	for _, networkName := range config.Config.PostCreateNetworks {
-		if err := network.Connect(c.Context, network.ConnectInput{
-			ContainerName: containerName,
-			NetworkAlias:  networkAlias,
-			NetworkName:   networkName,
-			Trace:         c.trace,
-		}); err != nil {
-			c.Ui.Error("Failed to attach container to network: " + err.Error())
-			return 1
-		}
-	}
-
-	// todo: attach container to container-specific network
-	logger.LogHeader2("Executing post-create hook")
-	err = hook.Execute(c.Context, hook.ExecuteInput{
-		DataRoot:    config.Config.DataRoot,
-		Exists:      config.Template.Hooks.PostCreate,
-		Name:        "post-create",
-		ServiceName: serviceName,
-		Template:    serviceTemplate, // use the registry's template to avoid using the temp path from config.Template
-		Volumes:     createdVolumes,
-		Trace:       c.trace,
-	})
-	if err != nil {
-		c.Ui.Error("Failed to execute post-create hook for service: " + err.Error())
-		return 1
-	}
-
-	logger.LogHeader2("Starting container")
-	err = container.Start(c.Context, container.StartInput{
-		Name:  containerName,
-		Trace: c.trace,
-	})
+	createdVolumes, err := createServiceContainer(c.Context, containerInput)
 	if err != nil {
 		c.Ui.Error(err.Error())
 		return 1
 	}
 
-	logger.LogHeader2("Waiting for service to be ready")
-	cli, err := client.NewClientWithOpts(
-		client.FromEnv,
-		client.WithAPIVersionNegotiation(),
-	)
-	if err != nil {
-		logger.Error(err.Error())
-		return 1
-	}
-
-	container, err := cli.ContainerInspect(c.Context, containerName)
-	if err != nil {
-		logger.Error(err.Error())
-		return 1
-	}
-	if err := healthcheck.ListeningCheck(c.Context, healthcheck.ListeningCheckInput{
-		Container:    container,
-		NetworkAlias: networkAlias,
-		Ports:        config.Template.Ports.Wait,
-		Timeout:      5,
-		Trace:        c.trace,
-		Wait:         1,
-	}); err != nil {
-		c.Ui.Error("Failed to wait for service to be ready: " + err.Error())
-		return 1
-	}
-
-	logger.LogHeader2("Attaching container to post-start networks")
-	for _, networkName := range config.Config.PostStartNetworks {
-		if err := network.Connect(c.Context, network.ConnectInput{
-			ContainerName: containerName,
-			NetworkAlias:  networkAlias,
-			NetworkName:   networkName,
-			Trace:         c.trace,
-		}); err != nil {
-			c.Ui.Error("Failed to attach container to network: " + err.Error())
-			return 1
-		}
-	}
-
-	logger.LogHeader2("Executing post-start hook")
-	err = hook.Execute(c.Context, hook.ExecuteInput{
-		DataRoot:    config.Config.DataRoot,
-		Exists:      config.Template.Hooks.PostStart,
-		Name:        "post-start",
-		ServiceName: serviceName,
-		Template:    serviceTemplate, // use the registry's template to avoid using the temp path from config.Template
-		Volumes:     createdVolumes,
-		Trace:       c.trace,
-	})
-	if err != nil {
-		c.Ui.Error("Failed to execute post-start hook for service: " + err.Error())
+	if err := startServiceContainer(c.Context, containerInput, createdVolumes); err != nil {
+		c.Ui.Error(err.Error())
 		return 1
 	}
 
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceStartCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "starts an existing stopped container",
+			setup: func(e *testEnv) {
+				if err := e.runtime.ContainerStop(e.ctx, "dokku.postgres.lollipop"); err != nil {
+					e.t.Fatalf("failed to stop container: %s", err)
+				}
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerStart dokku.postgres.lollipop") {
+					t.Errorf("expected container to be started, calls: %v", e.runtime.Calls)
+				}
+				if e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") {
+					t.Errorf("expected existing container to be reused")
+				}
+			},
+		},
+		{
+			name: "does nothing when the container is running",
+			check: func(t *testing.T, e *testEnv) {
+				if e.runtime.HasCall("ContainerStart dokku.postgres.lollipop") {
+					t.Errorf("expected running container not to be started again")
+				}
+			},
+		},
+		{
+			name: "recreates a removed container and rebuilds a missing image",
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				delete(e.runtime.Images, "dokku/service-postgres:lollipop")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ImageBuild dokku/service-postgres:lollipop") {
+					t.Errorf("expected image to be rebuilt, calls: %v", e.runtime.Calls)
+				}
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected recreated container to be running")
+				}
+			},
+		},
+		{
+			name:     "fails when the readiness check fails",
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				e.runtime.Errors["ContainerRun"] = errors.New("wait failed")
+			},
+		},
+		{
+			name: "outputs a plan to recreate a removed container with --dry-run",
+			args: []string{"--dry-run"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+				e.runtime.Calls = nil
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; ok {
+					t.Errorf("expected container not to be recreated")
+				}
+				if e.runtime.HasCall("ContainerCreate dokku.postgres.lollipop") || e.runtime.HasCall("ContainerStart dokku.postgres.lollipop") {
+					t.Errorf("expected no changes, calls: %v", e.runtime.Calls)
+				}
+
+				stdout := e.stdout.String()
+				for _, step := range []string{"container create dokku.postgres.lollipop", "container start dokku.postgres.lollipop"} {
+					if !strings.Contains(stdout, step) {
+						t.Errorf("expected plan to contain %q, got %s", step, stdout)
+					}
+				}
+				if strings.Contains(stdout, e.config("postgres", "lollipop").Config.EnvironmentVariables["POSTGRES_PASSWORD"]) {
+					t.Errorf("expected password to be redacted, got %s", stdout)
+				}
+			},
+		},
+		{
+			name: "outputs no changes when the container is running with --dry-run",
+			args: []string{"--dry-run"},
+			check: func(t *testing.T, e *testEnv) {
+				if !strings.Contains(e.stdout.String(), "No changes") {
+					t.Errorf("expected an empty plan, got %s", e.stdout.String())
+				}
+				if _, err := os.Stat(filepath.Join(e.dataRoot, "postgres", "lollipop", "ID")); err != nil {
+					t.Errorf("expected ID file to be kept: %s", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.runtime.Calls = nil
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceStartCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, append([]string{"postgres", "lollipop"}, tt.args...)...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/posener/complete"
 	flag "github.com/spf13/pflag"
 
+	"dokku-service/ambassador"
 	"dokku-service/container"
 )
 
@@ -143,6 +144,33 @@ func (c *ServiceStopCommand) Run(args []string) int {
 	}
 
 	logger.LogHeader1("Pausing service")
+	if err := removeSidecarContainers(c.Context, serviceContainerInput{
+		Logger:      logger,
+		ServiceName: serviceName,
+		Template:    serviceTemplate,
+		Trace:       c.trace,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	ambassadorExists, err := ambassador.Exists(c.Context, ambassador.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for ambassador existence: %s", err.Error()))
+		return 1
+	}
+
+	if ambassadorExists {
+		if err := destroyAmbassador(c.Context, containerName, c.trace); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		logger.Info("Ambassador removed")
+	}
+
 	err = container.Stop(c.Context, container.StopInput{
 		Name:  containerName,
 		Trace: c.trace,
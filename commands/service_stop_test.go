@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceStopCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "stops and removes the service container",
+			check: func(t *testing.T, e *testEnv) {
+				if !e.runtime.HasCall("ContainerStop dokku.postgres.lollipop") {
+					t.Errorf("expected container to be stopped, calls: %v", e.runtime.Calls)
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop"]; ok {
+					t.Errorf("expected container to be removed")
+				}
+			},
+		},
+		{
+			name: "stops the ambassador of an exposed service",
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "5432")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop.ambassador"]; ok {
+					t.Errorf("expected ambassador to be removed")
+				}
+			},
+		},
+		{
+			name: "succeeds when the container does not exist",
+			setup: func(e *testEnv) {
+				e.runtime.Containers = map[string]*engine.FakeContainer{}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop")
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
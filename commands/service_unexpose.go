@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/ambassador"
+	"dokku-service/container"
+	"dokku-service/service"
+)
+
+type ServiceUnexposeCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceUnexposeCommand) Name() string {
+	return "service-unexpose"
+}
+
+func (c *ServiceUnexposeCommand) Synopsis() string {
+	return "service-unexpose command"
+}
+
+func (c *ServiceUnexposeCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceUnexposeCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"unexpose a service": fmt.Sprintf("%s %s postgres lollipop", appName, c.Name()),
+	}
+}
+
+func (c *ServiceUnexposeCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceUnexposeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceUnexposeCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceUnexposeCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceUnexposeCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceUnexposeCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	if len(config.Config.Expose.Ports) == 0 {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not exposed", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Unexposing %s service %s", serviceTemplate.Name, serviceName))
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	exists, err := ambassador.Exists(c.Context, ambassador.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for ambassador existence: %s", err.Error()))
+		return 1
+	}
+
+	if exists {
+		if err := destroyAmbassador(c.Context, containerName, c.trace); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	config.Config.Expose = service.ExposeConfig{}
+	if err := service.WriteConfig(c.Context, service.WriteConfigInput{
+		ConfigOutput: config,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write service config: %s", err.Error()))
+		return 1
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s unexposed", serviceTemplate.Name, serviceName))
+	return 0
+}
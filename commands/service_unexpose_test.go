@@ -0,0 +1,58 @@
+package commands
+
+import "testing"
+
+func TestServiceUnexposeCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "removes the ambassador and the exposed ports",
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "15432")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop.ambassador"]; ok {
+					t.Errorf("expected ambassador to be removed")
+				}
+				if len(e.config("postgres", "lollipop").Config.Expose.Ports) != 0 {
+					t.Errorf("expected exposed ports to be cleared")
+				}
+			},
+		},
+		{
+			name: "clears the exposed ports when the ambassador is missing",
+			setup: func(e *testEnv) {
+				e.run(&ServiceExposeCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "15432")
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if len(e.config("postgres", "lollipop").Config.Expose.Ports) != 0 {
+					t.Errorf("expected exposed ports to be cleared")
+				}
+			},
+		},
+		{
+			name:     "fails when the service is not exposed",
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceUnexposeCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, "postgres", "lollipop")
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
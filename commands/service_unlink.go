@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/app"
+	"dokku-service/container"
+	"dokku-service/network"
+	"dokku-service/service"
+)
+
+type ServiceUnlinkCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// noRestart specifies whether to skip restarting the app
+	noRestart bool
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceUnlinkCommand) Name() string {
+	return "service-unlink"
+}
+
+func (c *ServiceUnlinkCommand) Synopsis() string {
+	return "service-unlink command"
+}
+
+func (c *ServiceUnlinkCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceUnlinkCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"unlink a service from an app": fmt.Sprintf("%s %s postgres lollipop playground", appName, c.Name()),
+	}
+}
+
+func (c *ServiceUnlinkCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "app",
+		Description: "the app to unlink the service from",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceUnlinkCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceUnlinkCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceUnlinkCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.BoolVar(&c.noRestart, "no-restart", false, "do not restart the app after unlinking")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceUnlinkCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceUnlinkCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	appName := arguments["app"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	links, err := service.Links(c.Context, service.LinksInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service links: %s", err.Error()))
+		return 1
+	}
+
+	var appLink *service.Link
+	remainingLinks := []service.Link{}
+	for _, link := range links {
+		if link.App == appName {
+			appLink = &link
+			continue
+		}
+
+		remainingLinks = append(remainingLinks, link)
+	}
+
+	if appLink == nil {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not linked to %s", serviceTemplate.Name, serviceName, appName))
+		return 1
+	}
+
+	logger.LogHeader1(fmt.Sprintf("Unlinking %s service %s from %s", serviceTemplate.Name, serviceName, appName))
+
+	// the values are irrelevant when unsetting, only the variable names are used
+	appVariables := linkVariables(serviceName, serviceTemplate.Name, config.Template.ExportedVariables, appLink.Primary)
+	keys := []string{}
+	for key := range appVariables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// the unprefixed variables move to another linked service of the same type when the primary is unlinked
+	promoteName := ""
+	if appLink.Primary {
+		linkedServices, err := service.LinkedServices(c.Context, service.LinkedServicesInput{
+			AppName:     appName,
+			DataRoot:    c.dataRoot,
+			ServiceType: serviceTemplate.Name,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to fetch app links: %s", err.Error()))
+			return 1
+		}
+
+		for _, linkedService := range linkedServices {
+			if linkedService.Name != serviceName {
+				promoteName = linkedService.Name
+				break
+			}
+		}
+	}
+
+	logger.LogHeader2("Unsetting exported variables on app")
+	if err := app.UnsetConfig(c.Context, app.UnsetConfigInput{
+		AppName:   appName,
+		Keys:      keys,
+		NoRestart: c.noRestart || promoteName != "",
+		Trace:     c.trace,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to unset exported variables on app: %s", err.Error()))
+		return 1
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if containerExists {
+		logger.LogHeader2("Detaching container from app networks")
+		attachedNetworks := serviceNetworks(config, links, appName)
+		for _, networkName := range appLink.Networks {
+			if attachedNetworks[networkName] {
+				continue
+			}
+
+			if err := network.Disconnect(c.Context, network.DisconnectInput{
+				ContainerName: containerName,
+				NetworkName:   networkName,
+				Trace:         c.trace,
+			}); err != nil {
+				c.Ui.Error("Failed to detach container from network: " + err.Error())
+				return 1
+			}
+			attachedNetworks[networkName] = true
+		}
+	}
+
+	if err := service.WriteLinks(c.Context, service.WriteLinksInput{
+		DataRoot:    c.dataRoot,
+		Links:       remainingLinks,
+		Name:        serviceName,
+		ServiceType: serviceTemplate.Name,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to remove service link: %s", err.Error()))
+		return 1
+	}
+
+	if promoteName != "" {
+		promoteArgs := []string{"--data-root", c.dataRoot}
+		if c.registryPath != "" {
+			promoteArgs = append(promoteArgs, "--registry-path", c.registryPath)
+		}
+		if c.noRestart {
+			promoteArgs = append(promoteArgs, "--no-restart")
+		}
+		if c.trace {
+			promoteArgs = append(promoteArgs, "--trace")
+		}
+
+		promoteCommand := &ServicePromoteCommand{Meta: c.Meta, Context: c.Context}
+		if exitCode := promoteCommand.Run(append(promoteArgs, serviceTemplate.Name, promoteName, appName)); exitCode != 0 {
+			c.Ui.Error(fmt.Sprintf("Failed to promote %s service %s for %s, run service-promote to restore the primary variables", serviceTemplate.Name, promoteName, appName))
+			return exitCode
+		}
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s unlinked from %s", serviceTemplate.Name, serviceName, appName))
+	return 0
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"dokku-service/service"
+)
+
+func TestServiceUnlinkCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "unsets the exported variables on the app",
+			args: []string{"postgres", "lollipop", "playground"},
+			setup: func(e *testEnv) {
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				last := calls[len(calls)-1]
+				if last != "config:unset playground DATABASE_URL DOKKU_POSTGRES_LOLLIPOP_URL" {
+					t.Errorf("expected config:unset call, got %s", last)
+				}
+				e.run(&ServiceLinkedCommand{Meta: e.meta(), Context: e.ctx}, 1, "postgres", "lollipop", "playground")
+			},
+		},
+		{
+			name: "detaches the container from app networks",
+			args: []string{"postgres", "lollipop", "playground", "--no-restart"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["app-net"] = true
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground", "--network", "app-net")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.container("dokku.postgres.lollipop").Networks["app-net"]; ok {
+					t.Errorf("expected container to be detached from app-net")
+				}
+				calls := e.dokkuCalls()
+				if !strings.HasPrefix(calls[len(calls)-1], "config:unset --no-restart playground") {
+					t.Errorf("expected config:unset without restart, got %s", calls[len(calls)-1])
+				}
+			},
+		},
+		{
+			name: "keeps networks used by other linked apps",
+			args: []string{"postgres", "lollipop", "playground"},
+			setup: func(e *testEnv) {
+				e.runtime.Networks["app-net"] = true
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground", "--network", "app-net")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "sandbox", "--network", "app-net")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if _, ok := e.container("dokku.postgres.lollipop").Networks["app-net"]; !ok {
+					t.Errorf("expected container to stay attached to app-net")
+				}
+			},
+		},
+		{
+			name: "promotes the next linked service when unlinking the primary",
+			args: []string{"postgres", "lollipop", "playground"},
+			setup: func(e *testEnv) {
+				e.create("postgres", "gumdrop")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "gumdrop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				unset, promote := calls[len(calls)-2], calls[len(calls)-1]
+				if !strings.HasPrefix(unset, "config:unset --no-restart playground DATABASE_URL") {
+					t.Errorf("expected config:unset without restart, got %s", unset)
+				}
+				if !strings.HasPrefix(promote, "config:set playground") || !strings.Contains(promote, "DATABASE_URL=postgres://postgres:") || !strings.Contains(promote, "@dokku.postgres.gumdrop:5432/gumdrop") {
+					t.Errorf("expected gumdrop to be promoted, got %s", promote)
+				}
+
+				linkedServices, err := service.LinkedServices(e.ctx, service.LinkedServicesInput{
+					AppName:     "playground",
+					DataRoot:    e.dataRoot,
+					ServiceType: "postgres",
+				})
+				if err != nil {
+					t.Fatalf("failed to fetch app links: %s", err)
+				}
+				if len(linkedServices) != 1 || linkedServices[0].Name != "gumdrop" || !linkedServices[0].Link.Primary {
+					t.Errorf("expected gumdrop to be the primary link, got %+v", linkedServices)
+				}
+			},
+		},
+		{
+			name: "keeps the primary when unlinking another service",
+			args: []string{"postgres", "gumdrop", "playground"},
+			setup: func(e *testEnv) {
+				e.create("postgres", "gumdrop")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "playground")
+				e.run(&ServiceLinkCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "gumdrop", "playground")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				calls := e.dokkuCalls()
+				if last := calls[len(calls)-1]; last != "config:unset playground DOKKU_POSTGRES_GUMDROP_URL" {
+					t.Errorf("expected only the prefixed variables to be unset, got %s", last)
+				}
+			},
+		},
+		{
+			name:     "fails when not linked",
+			args:     []string{"postgres", "lollipop", "playground"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceUnlinkCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/service"
+)
+
+type ServiceUnsetCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceUnsetCommand) Name() string {
+	return "service-unset"
+}
+
+func (c *ServiceUnsetCommand) Synopsis() string {
+	return "service-unset command"
+}
+
+func (c *ServiceUnsetCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceUnsetCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"unset the post-start networks":   fmt.Sprintf("%s %s postgres lollipop post-start-networks", appName, c.Name()),
+		"unset an environment variable":   fmt.Sprintf("%s %s postgres lollipop env POSTGRES_INITDB_ARGS", appName, c.Name()),
+		"reset the image to the template": fmt.Sprintf("%s %s postgres lollipop image-tag", appName, c.Name()),
+	}
+}
+
+func (c *ServiceUnsetCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "property",
+		Description: fmt.Sprintf("the property to unset, one of: %s", strings.Join(servicePropertyNames(), ", ")),
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "keys",
+		Description: "the env keys to unset, defaulting to all env keys not set by arguments",
+		Optional:    true,
+		Type:        command.ArgumentList,
+	})
+	return args
+}
+
+func (c *ServiceUnsetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceUnsetCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceUnsetCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceUnsetCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *ServiceUnsetCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	propertyName := arguments["property"].StringValue()
+	property, ok := serviceProperties[propertyName]
+	if !ok {
+		c.Ui.Error(fmt.Sprintf("Invalid property '%s', must be one of: %s", propertyName, strings.Join(servicePropertyNames(), ", ")))
+		return 1
+	}
+
+	keys := arguments["keys"].ListValue()
+	if len(keys) > 0 && propertyName != "env" {
+		c.Ui.Error(fmt.Sprintf("Property '%s' does not accept keys", propertyName))
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+
+	newConfig := config
+	if err := property.Unset(c.Context, servicePropertyInput{
+		Config:   &newConfig,
+		Template: serviceTemplate,
+		Trace:    c.trace,
+		Values:   keys,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to unset %s: %s", propertyName, err.Error()))
+		return 1
+	}
+
+	if err := updateServiceProperty(c.Context, updateServicePropertyInput{
+		Config:       config,
+		Logger:       logger,
+		NewConfig:    newConfig,
+		Property:     property,
+		PropertyName: propertyName,
+		ServiceName:  serviceName,
+		ServiceType:  serviceTemplate.Name,
+		Trace:        c.trace,
+	}); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
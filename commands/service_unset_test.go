@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceUnsetCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "unsets an environment variable",
+			args: []string{"postgres", "lollipop", "env", "POSTGRES_INITDB_ARGS"},
+			check: func(t *testing.T, e *testEnv) {
+				env := e.config("postgres", "lollipop").Config.EnvironmentVariables
+				if _, ok := env["POSTGRES_INITDB_ARGS"]; ok {
+					t.Errorf("expected POSTGRES_INITDB_ARGS to be unset")
+				}
+				if env["POSTGRES_PASSWORD"] == "" {
+					t.Errorf("expected POSTGRES_PASSWORD to be kept")
+				}
+				if strings.Contains(e.stdout.String(), "--data-checksums") || strings.Contains(e.stdout.String(), env["POSTGRES_PASSWORD"]) {
+					t.Errorf("expected environment variable values not to be printed, got %s", e.stdout.String())
+				}
+			},
+		},
+		{
+			name: "resets the image tag to the template default",
+			args: []string{"postgres", "lollipop", "image-tag"},
+			check: func(t *testing.T, e *testEnv) {
+				if image := e.config("postgres", "lollipop").Config.Arguments["IMAGE"].Value; image != "postgres:16.0" {
+					t.Errorf("expected IMAGE argument postgres:16.0, got %s", image)
+				}
+			},
+		},
+		{
+			name:     "fails for keys on a property other than env",
+			args:     []string{"postgres", "lollipop", "image-tag", "17.0"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "env", "POSTGRES_INITDB_ARGS=--data-checksums")
+			e.run(&ServiceSetCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop", "image-tag", "17.0")
+
+			e.run(&ServiceUnsetCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
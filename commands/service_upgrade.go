@@ -0,0 +1,584 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+
+	"dokku-service/ambassador"
+	"dokku-service/container"
+	"dokku-service/engine"
+	"dokku-service/image"
+	"dokku-service/plan"
+	"dokku-service/service"
+	"dokku-service/template"
+)
+
+type ServiceUpgradeCommand struct {
+	command.Meta
+
+	// Context specifies the context to use
+	Context context.Context
+
+	// dataRoot specifies the root directory for service data
+	dataRoot string
+
+	// dryRun specifies whether to output the planned changes instead of making them
+	dryRun bool
+
+	// format specifies the output format of the planned changes
+	format string
+
+	// image specifies the image to upgrade to
+	image string
+
+	// imageVersion specifies the image tag to upgrade to
+	imageVersion string
+
+	// registryPath specifies an override path to the registry
+	registryPath string
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+func (c *ServiceUpgradeCommand) Name() string {
+	return "service-upgrade"
+}
+
+func (c *ServiceUpgradeCommand) Synopsis() string {
+	return "service-upgrade command"
+}
+
+func (c *ServiceUpgradeCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *ServiceUpgradeCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"upgrade to a new image version": fmt.Sprintf("%s %s --image-version 16.4 postgres lollipop", appName, c.Name()),
+		"upgrade to a different image":   fmt.Sprintf("%s %s --image postgis/postgis --image-version 16-3.4 postgres lollipop", appName, c.Name()),
+	}
+}
+
+func (c *ServiceUpgradeCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "template",
+		Description: "the template to use",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	args = append(args, command.Argument{
+		Name:        "name",
+		Description: "the name of the created service",
+		Optional:    false,
+		Type:        command.ArgumentString,
+	})
+	return args
+}
+
+func (c *ServiceUpgradeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServiceUpgradeCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *ServiceUpgradeCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.BoolVar(&c.trace, "trace", false, "output trace information")
+	f.StringVar(&c.dataRoot, "data-root", DATA_ROOT, "the root directory for service data")
+	f.BoolVar(&c.dryRun, "dry-run", false, "output the planned changes instead of making them")
+	f.StringVar(&c.format, "format", "text", "the output format to use for planned changes: [text, json]")
+	f.StringVar(&c.image, "image", "", "the image to upgrade to (default: the current image)")
+	f.StringVar(&c.imageVersion, "image-version", "", "the image tag to upgrade to (default: the current tag)")
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the registry")
+	return f
+}
+
+func (c *ServiceUpgradeCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{
+			"--format": complete.PredictSet("text", "json"),
+		},
+	)
+}
+
+func (c *ServiceUpgradeCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.format != "text" && c.format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid format specified: %s", c.format))
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	if c.dryRun {
+		return dryRun(dryRunInput{
+			Context: &c.Context,
+			Format:  c.format,
+			Meta:    &c.Meta,
+			Run: func() int {
+				return c.upgradeService(arguments)
+			},
+			Title: fmt.Sprintf("Planned changes to upgrade %s service %s", arguments["template"].StringValue(), arguments["name"].StringValue()),
+		})
+	}
+
+	return c.upgradeService(arguments)
+}
+
+// upgradeService upgrades a service from parsed arguments, returning the exit code
+func (c *ServiceUpgradeCommand) upgradeService(arguments map[string]command.Argument) int {
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	if c.image == "" && c.imageVersion == "" {
+		c.Ui.Error("An --image or --image-version must be specified")
+		return 1
+	}
+
+	templateRegistry, defferedTemplateFunc, err := fetchTemplateRegistry(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateName := arguments["template"].StringValue()
+	serviceTemplate, err := fetchTemplate(templateRegistry, templateName)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	serviceName := arguments["name"].StringValue()
+	config, err := service.Config(c.Context, service.ConfigInput{
+		DataRoot:    c.dataRoot,
+		Name:        serviceName,
+		Registry:    templateRegistry,
+		ServiceType: serviceTemplate.Name,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to fetch service config: %s", err.Error()))
+		return 1
+	}
+	redactSecretArguments(c.Context, config.Config.Arguments)
+
+	// configs written by older versions may hold flags the engine api does not support,
+	// which must be rejected before the existing container is removed
+	if err := engine.ValidateCreateFlags(config.Config.ContainerCreateFlags); err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid container create flags in service config, update them with service-set: %s", err.Error()))
+		return 1
+	}
+
+	currentImage := config.Config.Arguments["IMAGE"].Value
+	currentImageName, currentImageTag := splitImage(currentImage)
+	newImageName, newImageTag := currentImageName, currentImageTag
+	if c.image != "" {
+		newImageName = c.image
+	}
+	if c.imageVersion != "" {
+		newImageTag = c.imageVersion
+	}
+
+	newImage := fmt.Sprintf("%s:%s", newImageName, newImageTag)
+	if newImage == currentImage {
+		logger.Info(fmt.Sprintf("%s service %s is already running %s", serviceTemplate.Name, serviceName, currentImage))
+		return 0
+	}
+
+	containerName := container.Name(container.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	containerExists, err := container.Exists(c.Context, container.ExistsInput{
+		Name:  containerName,
+		Trace: c.trace,
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to check for container existence: %s", err.Error()))
+		return 1
+	}
+
+	if !containerExists {
+		c.Ui.Error(fmt.Sprintf("%s service %s is not running, start it before upgrading", serviceTemplate.Name, serviceName))
+		return 1
+	}
+
+	newConfig := config
+	setImageArgument(&newConfig, newImageName, newImageTag)
+
+	logger.LogHeader1(fmt.Sprintf("Upgrading %s service %s from %s to %s", serviceTemplate.Name, serviceName, currentImage, newImage))
+	imageName := image.Name(image.NameInput{
+		ServiceName: serviceName,
+		ServiceType: serviceTemplate.Name,
+	})
+	upgrade := &serviceUpgrade{
+		config:          config,
+		containerName:   containerName,
+		imageName:       imageName,
+		logger:          logger,
+		serviceName:     serviceName,
+		serviceTemplate: serviceTemplate,
+		trace:           c.trace,
+	}
+	if err := upgrade.run(c.Context, newConfig); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to upgrade service: %s", err.Error()))
+		if rollbackErr := upgrade.rollback(c.Context); rollbackErr != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to roll back service: %s", rollbackErr.Error()))
+			return 1
+		}
+
+		logger.Info(fmt.Sprintf("%s service %s rolled back to %s", serviceTemplate.Name, serviceName, currentImage))
+		return 1
+	}
+
+	if err := service.WriteConfig(c.Context, service.WriteConfigInput{
+		ConfigOutput: newConfig,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write service config: %s", err.Error()))
+		return 1
+	}
+
+	if err := service.WriteEnvFile(c.Context, service.WriteEnvFileInput{
+		ConfigOutput: newConfig,
+	}); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write service env file: %s", err.Error()))
+		return 1
+	}
+
+	if err := upgrade.cleanup(c.Context); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to remove previous container or image: %s", err.Error()))
+	}
+
+	logger.Info(fmt.Sprintf("%s service %s upgraded to %s", serviceTemplate.Name, serviceName, newImage))
+	return 0
+}
+
+// serviceUpgrade tracks the steps taken during an upgrade so they can be rolled back
+type serviceUpgrade struct {
+	// config is the config of the service before the upgrade
+	config service.ConfigOutput
+
+	// containerID is the contents of the ID file of the previous container
+	containerID []byte
+
+	// containerName is the name of the service container
+	containerName string
+
+	// imageName is the name of the service image
+	imageName string
+
+	// logger is the logger to write progress to
+	logger *command.ZerologUi
+
+	// removedSidecars specifies whether the sidecar containers were removed
+	removedSidecars bool
+
+	// renamedContainer specifies whether the previous container was moved aside
+	renamedContainer bool
+
+	// serviceName is the name of the service
+	serviceName string
+
+	// serviceTemplate is the registry's template for the service
+	serviceTemplate template.ServiceTemplate
+
+	// taggedImage specifies whether the previous image was tagged for rollback
+	taggedImage bool
+
+	// trace specifies whether to output trace information
+	trace bool
+}
+
+// rollbackContainerName returns the name the previous container is moved to during an upgrade
+func (u *serviceUpgrade) rollbackContainerName() string {
+	return u.containerName + ".rollback"
+}
+
+// rollbackImageName returns the name the previous image is tagged as during an upgrade
+func (u *serviceUpgrade) rollbackImageName() string {
+	return u.imageName + ".rollback"
+}
+
+// run builds the new image and replaces the service container with one created from it
+func (u *serviceUpgrade) run(ctx context.Context, newConfig service.ConfigOutput) error {
+	imageExists, err := image.Exists(ctx, image.ExistsInput{
+		Name:  u.imageName,
+		Trace: u.trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for image existence: %w", err)
+	}
+
+	if imageExists {
+		u.logger.LogHeader2("Tagging previous image for rollback")
+		if err := image.Tag(ctx, image.TagInput{
+			Source: u.imageName,
+			Target: u.rollbackImageName(),
+			Trace:  u.trace,
+		}); err != nil {
+			return err
+		}
+		u.taggedImage = true
+	}
+
+	u.logger.LogHeader2("Building base image from template")
+	if err := image.Build(ctx, image.BuildInput{
+		Arguments:  newConfig.Config.Arguments,
+		BuildFlags: newConfig.Config.ImageBuildFlags,
+		Name:       u.imageName,
+		Template:   u.serviceTemplate,
+		Trace:      u.trace,
+	}); err != nil {
+		return fmt.Errorf("failed to build image for service: %w", err)
+	}
+
+	u.logger.LogHeader2("Stopping previous container")
+	// sidecars are linked to the service container, so are recreated along with it
+	u.removedSidecars = true
+	if err := removeSidecarContainers(ctx, u.sidecarInput()); err != nil {
+		return err
+	}
+
+	if err := u.removeAmbassador(ctx); err != nil {
+		return err
+	}
+
+	if err := container.Stop(ctx, container.StopInput{
+		Name:  u.containerName,
+		Trace: u.trace,
+	}); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+
+	if err := container.Rename(ctx, container.RenameInput{
+		Name:    u.containerName,
+		NewName: u.rollbackContainerName(),
+		Trace:   u.trace,
+	}); err != nil {
+		return err
+	}
+	u.renamedContainer = true
+
+	// the cidfile must not exist for the new container to be created
+	idFile := filepath.Join(u.config.Config.ServiceRoot, "ID")
+	u.containerID, err = plan.ReadFile(ctx, idFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read ID file: %w", err)
+	}
+	if err := plan.RemoveAll(ctx, idFile); err != nil {
+		return fmt.Errorf("failed to remove ID file: %w", err)
+	}
+
+	containerInput := serviceContainerInput{
+		Config:      newConfig,
+		ImageName:   u.imageName,
+		Logger:      u.logger,
+		ServiceName: u.serviceName,
+		Template:    u.serviceTemplate,
+		Trace:       u.trace,
+	}
+	createdVolumes, err := createServiceContainer(ctx, containerInput)
+	if err != nil {
+		return err
+	}
+
+	return startServiceContainer(ctx, containerInput, createdVolumes)
+}
+
+// rollback restores the previous image and container after a failed upgrade
+func (u *serviceUpgrade) rollback(ctx context.Context) error {
+	u.logger.LogHeader1("Rolling back upgrade")
+	if u.renamedContainer {
+		if err := removeSidecarContainers(ctx, u.sidecarInput()); err != nil {
+			return err
+		}
+
+		if err := u.removeAmbassador(ctx); err != nil {
+			return err
+		}
+
+		exists, err := container.Exists(ctx, container.ExistsInput{
+			Name:  u.containerName,
+			Trace: u.trace,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check for container existence: %w", err)
+		}
+
+		if exists {
+			u.logger.LogHeader2("Removing upgraded container")
+			// the container may never have started, so a failed stop is not fatal
+			_ = container.Stop(ctx, container.StopInput{
+				Name:  u.containerName,
+				Trace: u.trace,
+			})
+			if err := container.Destroy(ctx, container.DestroyInput{
+				Name:  u.containerName,
+				Trace: u.trace,
+			}); err != nil {
+				return fmt.Errorf("failed to remove container: %w", err)
+			}
+		}
+	}
+
+	if u.taggedImage {
+		u.logger.LogHeader2("Restoring previous image")
+		if err := image.Tag(ctx, image.TagInput{
+			Source: u.rollbackImageName(),
+			Target: u.imageName,
+			Trace:  u.trace,
+		}); err != nil {
+			return err
+		}
+
+		if err := image.Remove(ctx, image.RemoveInput{
+			Name:  u.rollbackImageName(),
+			Trace: u.trace,
+		}); err != nil {
+			return err
+		}
+		u.taggedImage = false
+	}
+
+	if !u.renamedContainer {
+		return u.restoreSidecars(ctx)
+	}
+
+	u.logger.LogHeader2("Restoring previous container")
+	if err := container.Rename(ctx, container.RenameInput{
+		Name:    u.rollbackContainerName(),
+		NewName: u.containerName,
+		Trace:   u.trace,
+	}); err != nil {
+		return err
+	}
+	u.renamedContainer = false
+
+	if u.containerID != nil {
+		if err := plan.WriteFile(ctx, filepath.Join(u.config.Config.ServiceRoot, "ID"), u.containerID, 0o666); err != nil {
+			return fmt.Errorf("failed to restore ID file: %w", err)
+		}
+	}
+
+	if err := container.Start(ctx, container.StartInput{
+		Name:  u.containerName,
+		Trace: u.trace,
+	}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	if err := ensureAmbassador(ctx, ensureAmbassadorInput{
+		Config:        u.config,
+		ContainerName: u.containerName,
+		Recreate:      true,
+		ServiceName:   u.serviceName,
+		Trace:         u.trace,
+	}); err != nil {
+		return err
+	}
+
+	return u.restoreSidecars(ctx)
+}
+
+// cleanup removes the previous container and image after a successful upgrade
+func (u *serviceUpgrade) cleanup(ctx context.Context) error {
+	if u.renamedContainer {
+		if err := container.Destroy(ctx, container.DestroyInput{
+			Name:  u.rollbackContainerName(),
+			Trace: u.trace,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if u.taggedImage {
+		return image.Remove(ctx, image.RemoveInput{
+			Name:  u.rollbackImageName(),
+			Trace: u.trace,
+		})
+	}
+
+	return nil
+}
+
+// sidecarInput returns the input for managing the sidecar containers of the service before the upgrade
+func (u *serviceUpgrade) sidecarInput() serviceContainerInput {
+	return serviceContainerInput{
+		Config:      u.config,
+		Logger:      u.logger,
+		ServiceName: u.serviceName,
+		Template:    u.serviceTemplate,
+		Trace:       u.trace,
+	}
+}
+
+// restoreSidecars recreates the sidecar containers removed during the upgrade from the previous config
+func (u *serviceUpgrade) restoreSidecars(ctx context.Context) error {
+	if !u.removedSidecars {
+		return nil
+	}
+
+	if err := startSidecarContainers(ctx, u.sidecarInput()); err != nil {
+		return err
+	}
+	u.removedSidecars = false
+
+	return nil
+}
+
+// removeAmbassador stops and removes the ambassador for the service container if one exists
+func (u *serviceUpgrade) removeAmbassador(ctx context.Context) error {
+	exists, err := ambassador.Exists(ctx, ambassador.ExistsInput{
+		Name:  u.containerName,
+		Trace: u.trace,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for ambassador existence: %w", err)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	return destroyAmbassador(ctx, u.containerName, u.trace)
+}
+
+// splitImage splits an image reference into its name and tag
+func splitImage(reference string) (string, string) {
+	index := strings.LastIndex(reference, ":")
+	if index == -1 || strings.Contains(reference[index:], "/") {
+		return reference, "latest"
+	}
+
+	return reference[:index], reference[index+1:]
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+)
+
+func TestServiceUpgradeCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(e *testEnv)
+		check    func(t *testing.T, e *testEnv)
+	}{
+		{
+			name: "replaces the container with one built from the new image",
+			args: []string{"postgres", "lollipop", "--image-version", "17.0"},
+			check: func(t *testing.T, e *testEnv) {
+				if image := e.config("postgres", "lollipop").Config.Arguments["IMAGE"].Value; image != "postgres:17.0" {
+					t.Errorf("expected IMAGE argument postgres:17.0, got %s", image)
+				}
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected upgraded container to be running")
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop.rollback"]; ok {
+					t.Errorf("expected rollback container to be removed")
+				}
+				if e.runtime.Images["dokku/service-postgres:lollipop.rollback"] {
+					t.Errorf("expected rollback image to be removed")
+				}
+			},
+		},
+		{
+			name: "does nothing when already running the image",
+			args: []string{"postgres", "lollipop", "--image-version", "16.0"},
+			check: func(t *testing.T, e *testEnv) {
+				if e.runtime.HasCall("ImageBuild dokku/service-postgres:lollipop") {
+					t.Errorf("expected image not to be rebuilt")
+				}
+			},
+		},
+		{
+			name:     "rolls back when the new container does not become ready",
+			args:     []string{"postgres", "lollipop", "--image-version", "17.0"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				failed := false
+				e.runtime.RunHandler = func(spec engine.ContainerSpec, stdout io.Writer, stderr io.Writer) error {
+					if failed {
+						return nil
+					}
+					failed = true
+					return &engine.ExitError{Code: 1}
+				}
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if image := e.config("postgres", "lollipop").Config.Arguments["IMAGE"].Value; image != "postgres:16.0" {
+					t.Errorf("expected IMAGE argument to stay postgres:16.0, got %s", image)
+				}
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected previous container to be running")
+				}
+				if _, ok := e.runtime.Containers["dokku.postgres.lollipop.rollback"]; ok {
+					t.Errorf("expected rollback container to be renamed back")
+				}
+			},
+		},
+		{
+			name:     "fails when the image cannot be built",
+			args:     []string{"postgres", "lollipop", "--image-version", "17.0"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.runtime.Errors["ImageBuild"] = errors.New("build failed")
+			},
+			check: func(t *testing.T, e *testEnv) {
+				if !e.container("dokku.postgres.lollipop").Running {
+					t.Errorf("expected previous container to keep running")
+				}
+			},
+		},
+		{
+			name: "outputs a plan without upgrading with --dry-run",
+			args: []string{"postgres", "lollipop", "--image-version", "17.0", "--dry-run"},
+			check: func(t *testing.T, e *testEnv) {
+				if image := e.config("postgres", "lollipop").Config.Arguments["IMAGE"].Value; image != "postgres:16.0" {
+					t.Errorf("expected IMAGE argument to be unchanged, got %s", image)
+				}
+				if e.runtime.HasCall("ImageBuild dokku/service-postgres:lollipop") || e.runtime.HasCall("ContainerStop dokku.postgres.lollipop") {
+					t.Errorf("expected no changes, calls: %v", e.runtime.Calls)
+				}
+
+				stdout := e.stdout.String()
+				steps := []string{
+					"image tag dokku/service-postgres:lollipop.rollback",
+					"image build dokku/service-postgres:lollipop",
+					"container stop dokku.postgres.lollipop",
+					"container rename dokku.postgres.lollipop",
+					"container create dokku.postgres.lollipop",
+					"container start dokku.postgres.lollipop",
+					"container rm dokku.postgres.lollipop.rollback",
+					"image rm dokku/service-postgres:lollipop.rollback",
+				}
+				last := -1
+				for _, step := range steps {
+					index := strings.Index(stdout, step)
+					if index <= last {
+						t.Fatalf("expected %q after the previous step in plan:\n%s", step, stdout)
+					}
+					last = index
+				}
+			},
+		},
+		{
+			name:     "fails when the container does not exist",
+			args:     []string{"postgres", "lollipop", "--image-version", "17.0"},
+			exitCode: 1,
+			setup: func(e *testEnv) {
+				e.run(&ServiceStopCommand{Meta: e.meta(), Context: e.ctx}, 0, "postgres", "lollipop")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.create("postgres", "lollipop")
+			e.runtime.Calls = nil
+			if tt.setup != nil {
+				tt.setup(e)
+			}
+
+			e.run(&ServiceUpgradeCommand{Meta: e.meta(), Context: e.ctx}, tt.exitCode, tt.args...)
+			if tt.check != nil {
+				tt.check(t, e)
+			}
+		})
+	}
+}
@@ -3,6 +3,10 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
+	"dokku-service/template"
 
 	"github.com/josegonzalez/cli-skeleton/command"
 	"github.com/posener/complete"
@@ -121,8 +125,53 @@ func (c *TemplateInfoCommand) Run(args []string) int {
 			defaultValue = fmt.Sprintf(`"%s"`, argument.Value)
 		}
 
-		c.Ui.Info(fmt.Sprintf("- %s [default: %v, required: %v]", argument.Name, defaultValue, isRequired))
+		c.Ui.Info(fmt.Sprintf("- %s [default: %v, required: %v, type: %s]", argument.Name, defaultValue, isRequired, argument.Type))
+		if argument.Description != "" {
+			c.Ui.Info(fmt.Sprintf("  %s", argument.Description))
+		}
+		for _, constraint := range argumentConstraints(argument) {
+			c.Ui.Info(fmt.Sprintf("  %s", constraint))
+		}
+	}
+
+	if len(serviceTemplate.Sidecars) > 0 {
+		c.Ui.Info("sidecars:")
+	}
+	for _, sidecar := range serviceTemplate.Sidecars {
+		c.Ui.Info(fmt.Sprintf("- %s [image: %s]", sidecar.Name, sidecar.Image))
+		if sidecar.Command != "" {
+			c.Ui.Info(fmt.Sprintf("  command: %s", sidecar.Command))
+		}
+		for _, volume := range sidecar.Volumes {
+			c.Ui.Info(fmt.Sprintf("  volume: %s", volume.ContainerPath))
+		}
+		if len(sidecar.WaitPorts) > 0 {
+			ports := []string{}
+			for _, port := range sidecar.WaitPorts {
+				ports = append(ports, strconv.Itoa(port))
+			}
+			c.Ui.Info(fmt.Sprintf("  wait ports: %s", strings.Join(ports, ", ")))
+		}
 	}
 
 	return 0
 }
+
+// argumentConstraints describes the values a template argument accepts
+func argumentConstraints(argument template.Argument) []string {
+	constraints := []string{}
+	if len(argument.Enum) > 0 {
+		constraints = append(constraints, fmt.Sprintf("one of: %s", strings.Join(argument.Enum, ", ")))
+	}
+	if argument.Pattern != "" {
+		constraints = append(constraints, fmt.Sprintf("pattern: %s", argument.Pattern))
+	}
+	if argument.Min != nil {
+		constraints = append(constraints, fmt.Sprintf("min: %d", *argument.Min))
+	}
+	if argument.Max != nil {
+		constraints = append(constraints, fmt.Sprintf("max: %d", *argument.Max))
+	}
+
+	return constraints
+}
@@ -0,0 +1,377 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dokku-service/engine"
+	"dokku-service/template"
+)
+
+func TestTemplateInfoCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   []string
+	}{
+		{
+			name: "outputs the template arguments",
+			args: []string{"postgres"},
+			stdout: []string{
+				"name: postgres",
+				`- IMAGE [default: "postgres:16.0", required: false, type: string]`,
+				"- POSTGRES_PASSWORD_SECRET [default: generated on create",
+				"The password of the postgres user",
+			},
+		},
+		{
+			name: "outputs argument constraints",
+			args: []string{"redis"},
+			stdout: []string{
+				`- REDIS_DATABASE [default: "0", required: false, type: int]`,
+				"The database index exported to linked apps",
+				"min: 0",
+				"max: 15",
+			},
+		},
+		{
+			name:     "fails for an unknown template",
+			args:     []string{"mysql"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, tt.exitCode, tt.args...)
+			for _, expected := range tt.stdout {
+				if !strings.Contains(e.stdout.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateFormatParity(t *testing.T) {
+	registryPath, vendoredRegistryPath, cleanup, err := fetchRegistryPath(context.Background(), "")
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, templateName := range []string{"postgres", "redis"} {
+		t.Run(templateName, func(t *testing.T) {
+			input := template.NewServiceTemplateInput{
+				Name:                 templateName,
+				RegistryPath:         registryPath,
+				VendoredRegistry:     true,
+				VendoredRegistryPath: vendoredRegistryPath,
+			}
+
+			fromDockerfile, err := template.ParseDockerfile(context.Background(), input)
+			if err != nil {
+				t.Fatalf("failed to parse Dockerfile: %s", err)
+			}
+			fromCompose, err := template.ParseComposeFile(context.Background(), input)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %s", template.COMPOSE_FILE, err)
+			}
+
+			// generated values differ on every parse
+			for _, serviceTemplate := range []*template.ServiceTemplate{&fromDockerfile, &fromCompose} {
+				for i, argument := range serviceTemplate.Arguments {
+					if argument.IsVariable {
+						serviceTemplate.Arguments[i].Value = ""
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(fromDockerfile, fromCompose) {
+				dockerfileJSON, _ := json.MarshalIndent(fromDockerfile, "", "  ")
+				composeJSON, _ := json.MarshalIndent(fromCompose, "", "  ")
+				t.Errorf("expected identical templates, got Dockerfile:\n%s\ncompose:\n%s", dockerfileJSON, composeJSON)
+			}
+		})
+	}
+}
+
+func TestComposeTemplate(t *testing.T) {
+	registryPath := t.TempDir()
+	templatePath := filepath.Join(registryPath, "memcached")
+	if err := os.MkdirAll(templatePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	compose := strings.Join([]string{
+		"services:",
+		"  memcached:",
+		"    image: memcached:1.6",
+		"    build:",
+		"      dockerfile: Containerfile",
+		"      args:",
+		"        - MEMCACHED_MEMORY=64",
+		"    ports:",
+		"      - 11211",
+		"    labels:",
+		"      dokku.com/service.name: memcached",
+		"      dokku.com/service.description: A template for managing memcached",
+		"      dokku.com/service.arguments.MEMCACHED_MEMORY.type: int",
+		"      dokku.com/service.ports.wait: 11211",
+		"",
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(templatePath, template.COMPOSE_FILE), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templatePath, "Containerfile"), []byte("ARG IMAGE\nFROM ${IMAGE}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEnv(t)
+	e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, 0, "--registry-path", registryPath, "memcached")
+	for _, expected := range []string{
+		"description: A template for managing memcached",
+		`- IMAGE [default: "memcached:1.6", required: false, type: string]`,
+		`- MEMCACHED_MEMORY [default: "64", required: false, type: int]`,
+	} {
+		if !strings.Contains(e.stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+		}
+	}
+
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "--dry-run", "--format", "json", "memcached", "lollipop")
+	for _, expected := range []string{`"dockerfile": "Containerfile"`, `"MEMCACHED_MEMORY": "64"`, "dokku/wait:0.6.0"} {
+		if !strings.Contains(e.stdout.String(), expected) {
+			t.Errorf("expected plan to contain %q, got:\n%s", expected, e.stdout.String())
+		}
+	}
+
+	e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 0, "--registry-path", registryPath)
+
+	if err := os.Remove(filepath.Join(templatePath, "Containerfile")); err != nil {
+		t.Fatal(err)
+	}
+	e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 1, "--registry-path", registryPath)
+	if !strings.Contains(e.stdout.String(), "memcached/docker-compose.yml: build dockerfile Containerfile does not exist") {
+		t.Errorf("expected missing build dockerfile problem, got:\n%s", e.stdout.String())
+	}
+}
+
+// dockerfileRuntime records the Dockerfile each image is built from
+type dockerfileRuntime struct {
+	*engine.Fake
+
+	// dockerfiles maps image tags to the contents of the Dockerfile they were built from
+	dockerfiles map[string]string
+}
+
+func (r *dockerfileRuntime) ImageBuild(ctx context.Context, options engine.BuildOptions) error {
+	b, err := os.ReadFile(filepath.Join(options.ContextDirectory, options.Dockerfile))
+	if err != nil {
+		return err
+	}
+	r.dockerfiles[options.Tag] = string(b)
+
+	return r.Fake.ImageBuild(ctx, options)
+}
+
+func TestComposeTemplateWithoutBuildFile(t *testing.T) {
+	registryPath := t.TempDir()
+	templatePath := filepath.Join(registryPath, "memcached")
+	if err := os.MkdirAll(templatePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeCompose := func(extra ...string) {
+		t.Helper()
+		compose := append([]string{
+			"version: \"3.7\"",
+			"services:",
+			"  memcached:",
+			"    image: memcached:1.6",
+			"    ports:",
+			"      - 11211",
+			"    labels:",
+			"      dokku.com/service.name: memcached",
+			"      dokku.com/service.description: A template for managing memcached",
+			"      dokku.com/service.ports.wait: 11211",
+			"    restart: always",
+		}, extra...)
+		if err := os.WriteFile(filepath.Join(templatePath, template.COMPOSE_FILE), []byte(strings.Join(compose, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCompose()
+
+	e := newTestEnv(t)
+	runtime := &dockerfileRuntime{Fake: e.runtime, dockerfiles: map[string]string{}}
+	e.ctx = engine.WithRuntime(context.Background(), runtime)
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "memcached", "lollipop")
+	if dockerfile := runtime.dockerfiles["dokku/service-memcached:lollipop"]; dockerfile != template.GENERATED_DOCKERFILE {
+		t.Errorf("expected image to be built from the generated Dockerfile, got %q", dockerfile)
+	}
+	if _, err := os.Stat(filepath.Join(templatePath, "Dockerfile")); !os.IsNotExist(err) {
+		t.Errorf("expected no Dockerfile to be written to the template, got %v", err)
+	}
+	if !e.runtime.HasCall("ContainerRun dokku/wait:0.6.0 -c dokku.memcached.lollipop:11211") {
+		t.Errorf("expected readiness check on port 11211, calls: %v", e.runtime.Calls)
+	}
+
+	e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 0, "--registry-path", registryPath)
+
+	for _, tt := range []struct {
+		extra   []string
+		problem string
+	}{
+		{
+			extra:   []string{"    networks:", "      - dokku"},
+			problem: "memcached/docker-compose.yml: compose parse error: line 12: unsupported service key networks",
+		},
+		{
+			extra:   []string{"    build:", "      context: .."},
+			problem: "memcached/docker-compose.yml: compose parse error: line 13: unsupported build key context",
+		},
+		{
+			extra:   []string{"networks:", "  dokku: {}"},
+			problem: "memcached/docker-compose.yml: compose parse error: line 12: unsupported top level key networks",
+		},
+	} {
+		writeCompose(tt.extra...)
+		e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 1, "--registry-path", registryPath)
+		if !strings.Contains(e.stdout.String(), tt.problem) {
+			t.Errorf("expected output to contain %q, got:\n%s", tt.problem, e.stdout.String())
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(templatePath, template.COMPOSE_FILE), []byte("services:\n  memcached:\n    image: memcached:1.6\n    labels:\n      dokku.com/service-name: memcached\n    restart: always\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, 1, "--registry-path", registryPath, "memcached")
+	if !strings.Contains(e.stderr.String(), "line 5: invalid label key: dokku.com/service-name") {
+		t.Errorf("expected label key error, got %s", e.stderr.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(templatePath, template.COMPOSE_FILE), []byte("services:\n  memcached:\n    image: memcached:1.6\n    restart: unless-stopped\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, 1, "--registry-path", registryPath, "memcached")
+	if !strings.Contains(e.stderr.String(), "line 4: restart must be always") {
+		t.Errorf("expected restart policy error, got %s", e.stderr.String())
+	}
+}
+
+func TestTemplateInheritance(t *testing.T) {
+	registryPath := t.TempDir()
+	writeTemplate(t, registryPath, "pgext", strings.Join([]string{
+		"ARG IMAGE=postgres:17.0",
+		"FROM ${IMAGE}",
+		"RUN apt-get update && apt-get install -y postgresql-17-postgis-3",
+		"LABEL com.dokku.template.name=pgext",
+		`LABEL com.dokku.template.description="A template for managing postgres with postgis"`,
+		"LABEL com.dokku.template.extends=vendored:postgres",
+		`LABEL com.dokku.template.config.commands.connect="psql -h localhost -U postgres -d {{ .POSTGRES_DB }}"`,
+		"ARG POSTGRES_EXTENSIONS=postgis",
+		"",
+	}, "\n"))
+	writeTemplate(t, registryPath, "rediscache", strings.Join([]string{
+		"ARG IMAGE",
+		"FROM ${IMAGE}",
+		"LABEL com.dokku.template.name=rediscache",
+		`LABEL com.dokku.template.description="A template for managing a redis cache"`,
+		"LABEL com.dokku.template.extends=vendored:redis",
+		"",
+	}, "\n"))
+
+	e := newTestEnv(t)
+	e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, 0, "--registry-path", registryPath, "pgext")
+	stdout := e.stdout.String()
+	expected := []string{
+		"description: A template for managing postgres with postgis",
+		`- IMAGE [default: "postgres:17.0"`,
+		"- POSTGRES_PASSWORD_SECRET [default: generated on create",
+		`- POSTGRES_EXTENSIONS [default: "postgis"`,
+	}
+	last := -1
+	for _, line := range expected {
+		index := strings.Index(stdout, line)
+		if index <= last {
+			t.Fatalf("expected %q after the previous argument in output:\n%s", line, stdout)
+		}
+		last = index
+	}
+
+	templateRegistry, cleanup, err := fetchTemplateRegistry(context.Background(), registryPath)
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgext, err := fetchTemplate(templateRegistry, "pgext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgext.Commands["connect"] != "psql -h localhost -U postgres -d {{ .POSTGRES_DB }}" {
+		t.Errorf("expected connect command to be overridden, got %q", pgext.Commands["connect"])
+	}
+	if pgext.Commands["export"] == "" || pgext.ExportedVariables["DATABASE_URL"] == "" {
+		t.Errorf("expected export command and exported variables to be inherited, got %v %v", pgext.Commands, pgext.ExportedVariables)
+	}
+	if len(pgext.Volumes) != 1 || pgext.Volumes[0].ContainerPath != "/var/lib/postgresql/data" {
+		t.Errorf("expected volume to be inherited, got %v", pgext.Volumes)
+	}
+
+	rediscache, err := fetchTemplate(templateRegistry, "rediscache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rediscache.Image.Name != "redis" || !rediscache.Hooks.PreCreate {
+		t.Errorf("expected image and hooks to be inherited, got %+v %+v", rediscache.Image, rediscache.Hooks)
+	}
+	if !strings.HasSuffix(rediscache.HookPath("pre-create"), filepath.Join("redis", "bin", "pre-create")) {
+		t.Errorf("expected the pre-create hook to be inherited, got %s", rediscache.HookPath("pre-create"))
+	}
+
+	e.run(&ServiceCreateCommand{Meta: e.meta(), Context: e.ctx}, 0, "--registry-path", registryPath, "rediscache", "lollipop")
+	if !e.runtime.HasCall("ContainerRun bash:5.2 /usr/local/bin/hook") {
+		t.Errorf("expected the inherited pre-create hook to run, calls: %v", e.runtime.Calls)
+	}
+	if !e.runtime.HasCall("ContainerRun dokku/wait:0.6.0 -c dokku.rediscache.lollipop:6379") {
+		t.Errorf("expected readiness check on the inherited wait port, calls: %v", e.runtime.Calls)
+	}
+
+	e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 0, "--registry-path", registryPath)
+}
+
+func TestTemplateInheritanceCycle(t *testing.T) {
+	registryPath := t.TempDir()
+	for _, names := range [][]string{{"first", "second"}, {"second", "first"}} {
+		writeTemplate(t, registryPath, names[0], strings.Join([]string{
+			"ARG IMAGE=postgres:16.0",
+			"FROM ${IMAGE}",
+			"LABEL com.dokku.template.name=" + names[0],
+			`LABEL com.dokku.template.description="A cyclic template"`,
+			"LABEL com.dokku.template.extends=" + names[1],
+			"",
+		}, "\n"))
+	}
+
+	e := newTestEnv(t)
+	e.runWithoutDataRoot(&TemplateInfoCommand{Meta: e.meta()}, 1, "--registry-path", registryPath, "first")
+	if !strings.Contains(e.stderr.String(), "template inheritance cycle: first -> second -> first") {
+		t.Errorf("expected cycle error, got %s", e.stderr.String())
+	}
+
+	e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, 1, "--registry-path", registryPath)
+	for _, expected := range []string{
+		"first/Dockerfile:5: failed to extend template: template inheritance cycle: first -> second -> first",
+		"second/Dockerfile:5: failed to extend template: template inheritance cycle: second -> first -> second",
+	} {
+		if !strings.Contains(e.stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateListCommand(t *testing.T) {
+	e := newTestEnv(t)
+	e.runWithoutDataRoot(&TemplateListCommand{Meta: e.meta()}, 0)
+
+	for _, expected := range []string{"postgres: ", "redis: "} {
+		if !strings.Contains(e.stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"dokku-service/template"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/posener/complete"
+	flag "github.com/spf13/pflag"
+)
+
+type TemplateValidateCommand struct {
+	command.Meta
+
+	// registryPath specifies an override path to the template registry
+	registryPath string
+}
+
+func (c *TemplateValidateCommand) Name() string {
+	return "template-validate"
+}
+
+func (c *TemplateValidateCommand) Synopsis() string {
+	return "template-validate command"
+}
+
+func (c *TemplateValidateCommand) Help() string {
+	return command.CommandHelp(c)
+}
+
+func (c *TemplateValidateCommand) Examples() map[string]string {
+	appName := os.Getenv("CLI_APP_NAME")
+	return map[string]string{
+		"Validate all templates in a registry": fmt.Sprintf("%s %s --registry-path /path/to/registry", appName, c.Name()),
+		"Validate a single template":           fmt.Sprintf("%s %s --registry-path /path/to/registry postgres", appName, c.Name()),
+	}
+}
+
+func (c *TemplateValidateCommand) Arguments() []command.Argument {
+	args := []command.Argument{}
+	args = append(args, command.Argument{
+		Name:        "templates",
+		Description: "the templates to validate, defaulting to every template in the registry",
+		Optional:    true,
+		Type:        command.ArgumentList,
+	})
+	return args
+}
+
+func (c *TemplateValidateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TemplateValidateCommand) ParsedArguments(args []string) (map[string]command.Argument, error) {
+	return command.ParseArguments(args, c.Arguments())
+}
+
+func (c *TemplateValidateCommand) FlagSet() *flag.FlagSet {
+	f := c.Meta.FlagSet(c.Name(), command.FlagSetClient)
+	f.StringVar(&c.registryPath, "registry-path", "", "an override path to the template registry")
+	return f
+}
+
+func (c *TemplateValidateCommand) AutocompleteFlags() complete.Flags {
+	return command.MergeAutocompleteFlags(
+		c.Meta.AutocompleteFlags(command.FlagSetClient),
+		complete.Flags{},
+	)
+}
+
+func (c *TemplateValidateCommand) Run(args []string) int {
+	flags := c.FlagSet()
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	arguments, err := c.ParsedArguments(flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		c.Ui.Error(command.CommandErrorText(c))
+		return 1
+	}
+
+	logger, ok := c.Ui.(*command.ZerologUi)
+	if !ok {
+		c.Ui.Error("Unable to fetch logger from cli")
+		return 1
+	}
+
+	// the registry is not parsed, as a single broken template would hide the problems of the rest
+	registryPath, vendoredRegistryPath, defferedTemplateFunc, err := fetchRegistryPath(c.Context, c.registryPath)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	defer defferedTemplateFunc()
+
+	templateNames := arguments["templates"].ListValue()
+	if len(templateNames) == 0 {
+		dirEntries, err := os.ReadDir(registryPath)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read registry: %s", err.Error()))
+			return 1
+		}
+
+		for _, dirEntry := range dirEntries {
+			if dirEntry.IsDir() {
+				templateNames = append(templateNames, dirEntry.Name())
+			}
+		}
+	}
+
+	logger.LogHeader1("Validating templates")
+	problemCount := 0
+	for _, templateName := range templateNames {
+		problems, err := template.Validate(c.Context, template.ValidateInput{
+			Name:                 templateName,
+			RegistryPath:         registryPath,
+			VendoredRegistryPath: vendoredRegistryPath,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Template %s could not be validated: %s", templateName, err.Error()))
+			problemCount++
+			continue
+		}
+
+		for _, problem := range problems {
+			c.Ui.Output(problem.String())
+		}
+		problemCount += len(problems)
+	}
+
+	if problemCount > 0 {
+		c.Ui.Error(fmt.Sprintf("Found %d problems in %d templates", problemCount, len(templateNames)))
+		return 1
+	}
+
+	c.Ui.Info(fmt.Sprintf("No problems found in %d templates", len(templateNames)))
+	return 0
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplate writes a template Dockerfile and any bin files to a registry
+func writeTemplate(t *testing.T, registryPath string, name string, dockerfile string, binFiles ...string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(registryPath, name, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(registryPath, name, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, binFile := range binFiles {
+		if err := os.WriteFile(filepath.Join(registryPath, name, "bin", binFile), []byte("#!/usr/bin/env bash\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTemplateValidateCommand(t *testing.T) {
+	validDockerfile := strings.Join([]string{
+		"ARG IMAGE=postgres:16.0",
+		"FROM ${IMAGE}",
+		"LABEL com.dokku.template.name=valid",
+		`LABEL com.dokku.template.description="A valid template"`,
+		`LABEL com.dokku.template.config.commands.connect="psql {{ .POSTGRES_DB }}"`,
+		`LABEL com.dokku.template.config.commands.rotate-secret="rotate {{ .PREVIOUS_POSTGRES_PASSWORD }} {{ .POSTGRES_PASSWORD }}"`,
+		"LABEL com.dokku.template.config.hooks.post-create=true",
+		"LABEL com.dokku.template.config.ports.expose=5432",
+		"LABEL com.dokku.template.config.ports.wait=5432",
+		`LABEL com.dokku.template.config.variables.exported.DATABASE_URL="postgres://{{ .POSTGRES_PASSWORD_SECRET }}@{{ .HOSTNAME }}/{{ .POSTGRES_DB }}"`,
+		`LABEL com.dokku.template.config.variables.mapped.password="POSTGRES_PASSWORD"`,
+		"ARG POSTGRES_DB",
+		`ARG POSTGRES_PASSWORD_SECRET="{{ randAlphaNum 32 }}"`,
+		"",
+	}, "\n")
+
+	brokenDockerfile := strings.Join([]string{
+		"ARG IMAGE=postgres:16.0",
+		"FROM postgres:16.0",
+		"LABEL com.dokku.template.name=postgres",
+		`LABEL com.dokku.template.description="A broken template"`,
+		`LABEL com.dokku.template.config.commands.connect="psql {{ .POSTGRES_USER }}"`,
+		"LABEL com.dokku.template.config.hooks.pre-create=true",
+		"LABEL com.dokku.template.config.ports.expose=5432",
+		"LABEL com.dokku.template.config.ports.wait=5433",
+		`LABEL com.dokku.template.config.variables.mapped.name="POSTGRES_NAME"`,
+		"LABEL com.dokku.template.config.bogus=true",
+		"ARG POSTGRES_DB",
+		"",
+	}, "\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		setup    func(t *testing.T, registryPath string)
+		stdout   []string
+		stderr   []string
+	}{
+		{
+			name: "passes the vendored templates",
+		},
+		{
+			name: "passes a valid template",
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "valid", validDockerfile, "post-create")
+			},
+			stdout: []string{"No problems found in 1 templates"},
+		},
+		{
+			name:     "reports each problem with its position",
+			exitCode: 1,
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "broken", brokenDockerfile)
+			},
+			stdout: []string{
+				"broken/Dockerfile:2: FROM must use the ${IMAGE} argument",
+				"broken/Dockerfile:3: template name postgres does not match folder broken",
+				"broken/Dockerfile:5: label com.dokku.template.config.commands.connect references {{ .POSTGRES_USER }}, which is not defined by an ARG",
+				"broken/Dockerfile:6: pre-create hook is enabled but bin/pre-create does not exist",
+				"broken/Dockerfile:8: wait port 5433 is not listed in com.dokku.template.config.ports.expose",
+				"broken/Dockerfile:9: mapped variable POSTGRES_NAME is not defined by an ARG",
+				"broken/Dockerfile:10: invalid LABEL directive: invalid label key: com.dokku.template.config.bogus",
+			},
+			stderr: []string{"Found 7 problems in 1 templates"},
+		},
+		{
+			name:     "reports missing required directives",
+			exitCode: 1,
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "empty", "FROM alpine\n")
+			},
+			stdout: []string{
+				"empty/Dockerfile: missing required ARG IMAGE",
+				"empty/Dockerfile: missing required label com.dokku.template.name",
+				"empty/Dockerfile:1: FROM must use the ${IMAGE} argument",
+			},
+		},
+		{
+			name:     "reports invalid argument labels",
+			exitCode: 1,
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "valid", validDockerfile+strings.Join([]string{
+					"LABEL com.dokku.template.config.arguments.POSTGRES_DB.type=int",
+					"LABEL com.dokku.template.config.arguments.POSTGRES_DB.min=ten",
+					"LABEL com.dokku.template.config.arguments.POSTGRES_USER.description=missing",
+					"LABEL com.dokku.template.config.arguments.IMAGE.enum=postgres:15.0,postgres:17.0",
+					"",
+				}, "\n"), "post-create")
+			},
+			stdout: []string{
+				"valid/Dockerfile:1: invalid argument metadata: invalid default for argument IMAGE",
+				"valid/Dockerfile:15: invalid argument label: invalid min for argument POSTGRES_DB",
+				"valid/Dockerfile:16: invalid argument label: label com.dokku.template.config.arguments.POSTGRES_USER.description describes unknown argument POSTGRES_USER",
+			},
+		},
+		{
+			name:     "reports invalid sidecar labels",
+			exitCode: 1,
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "valid", validDockerfile+strings.Join([]string{
+					`LABEL com.dokku.template.config.sidecars.pgbouncer.command="pgbouncer {{ .PGBOUNCER_CONFIG }}"`,
+					`LABEL com.dokku.template.config.sidecars.pgbouncer.volumes="/data:/var/lib/data"`,
+					"LABEL com.dokku.template.config.sidecars.ambassador.image=alpine",
+					"LABEL com.dokku.template.config.sidecars.exporter.ports.wait=http",
+					"LABEL com.dokku.template.config.sidecars.exporter.image=postgres-exporter",
+					"",
+				}, "\n"), "post-create")
+			},
+			stdout: []string{
+				"valid/Dockerfile:14: invalid sidecar: missing required label com.dokku.template.config.sidecars.pgbouncer.image",
+				"valid/Dockerfile:14: label com.dokku.template.config.sidecars.pgbouncer.command references {{ .PGBOUNCER_CONFIG }}, which is not defined by an ARG",
+				"valid/Dockerfile:15: invalid sidecar label: invalid volume /data:/var/lib/data for sidecar pgbouncer, /data is not a VOLUME of the template",
+				"valid/Dockerfile:16: invalid LABEL directive: invalid sidecar name ambassador, the name is reserved",
+				`valid/Dockerfile:17: invalid sidecar label: invalid wait port "http" for sidecar exporter`,
+			},
+			stderr: []string{"Found 5 problems in 1 templates"},
+		},
+		{
+			name:     "reports references inside range and with blocks",
+			exitCode: 1,
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "valid", validDockerfile+strings.Join([]string{
+					`LABEL com.dokku.template.config.commands.export="pg_dump {{ with .POSTGRES_DB }}{{ .POSTGRES_SCHEMA }}{{ else }}{{ .POSTGRES_HOST }}{{ end }}"`,
+					`LABEL com.dokku.template.config.commands.import="pg_restore {{ range .POSTGRES_DB }}{{ .POSTGRES_TABLE }}{{ else }}{{ .POSTGRES_PORT }}{{ end }}"`,
+					"",
+				}, "\n"), "post-create")
+			},
+			stdout: []string{
+				"valid/Dockerfile:14: label com.dokku.template.config.commands.export references {{ .POSTGRES_SCHEMA }}, which is not defined by an ARG",
+				"valid/Dockerfile:14: label com.dokku.template.config.commands.export references {{ .POSTGRES_HOST }}, which is not defined by an ARG",
+				"valid/Dockerfile:15: label com.dokku.template.config.commands.import references {{ .POSTGRES_TABLE }}, which is not defined by an ARG",
+				"valid/Dockerfile:15: label com.dokku.template.config.commands.import references {{ .POSTGRES_PORT }}, which is not defined by an ARG",
+			},
+		},
+		{
+			name: "validates only the named templates",
+			args: []string{"valid"},
+			setup: func(t *testing.T, registryPath string) {
+				writeTemplate(t, registryPath, "valid", validDockerfile, "post-create")
+				writeTemplate(t, registryPath, "broken", brokenDockerfile)
+			},
+			stdout: []string{"No problems found in 1 templates"},
+		},
+		{
+			name:     "fails for a template without a Dockerfile",
+			args:     []string{"mysql"},
+			exitCode: 1,
+			setup:    func(t *testing.T, registryPath string) {},
+			stderr:   []string{"Template mysql could not be validated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			args := tt.args
+			if tt.setup != nil {
+				registryPath := t.TempDir()
+				tt.setup(t, registryPath)
+				args = append([]string{"--registry-path", registryPath}, args...)
+			}
+
+			e.runWithoutDataRoot(&TemplateValidateCommand{Meta: e.meta()}, tt.exitCode, args...)
+			for _, expected := range tt.stdout {
+				if !strings.Contains(e.stdout.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, e.stdout.String())
+				}
+			}
+			for _, expected := range tt.stderr {
+				if !strings.Contains(e.stderr.String(), expected) {
+					t.Errorf("expected error output to contain %q, got:\n%s", expected, e.stderr.String())
+				}
+			}
+		})
+	}
+}
@@ -2,14 +2,14 @@ package container
 
 import (
 	"context"
+	"dokku-service/engine"
 	"dokku-service/service"
 	"fmt"
-	"html/template"
 	"os"
 	"strings"
+	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
-	"github.com/alexellis/go-execute/v2"
 	"golang.org/x/term"
 	"mvdan.cc/sh/v3/shell"
 )
@@ -46,43 +46,24 @@ func Connect(ctx context.Context, input ConnectInput) error {
 		return fmt.Errorf("failed to execute connect command template: %w", err)
 	}
 
-	args := []string{
-		"container",
-		"exec",
-		"--env=LANG=C.UTF-8",
-		"--env=LC_ALL=C.UTF-8",
-		"-i",
-	}
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		args = append(args, "-t")
-	}
-	args = append(args, input.ContainerName)
-
 	fields, err := shell.Fields(builder.String(), func(key string) string {
 		return input.ConfigOutput.Config.EnvironmentVariables[key]
 	})
 	if err != nil {
 		return fmt.Errorf("failed to parse connect command: %w", err)
 	}
-	args = append(args, fields...)
-
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        args,
-		StreamStdio: true,
-		Stdin:       os.Stdin,
-	}
 
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+	engine.Trace(input.Trace, "container exec", append([]string{input.ContainerName}, fields...)...)
+	err = engine.FromContext(ctx).ContainerExec(ctx, input.ContainerName, engine.ExecOptions{
+		Command: fields,
+		Env:     []string{"LANG=C.UTF-8", "LC_ALL=C.UTF-8"},
+		Stderr:  os.Stderr,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Tty:     term.IsTerminal(int(os.Stdout.Fd())),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to execute connect command: %w", err)
-	}
-
-	if res.ExitCode != 0 {
-		return fmt.Errorf("connect command failed: %s", res.Stderr)
+		return fmt.Errorf("connect command failed: %w", err)
 	}
 
 	return nil
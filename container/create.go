@@ -2,32 +2,43 @@ package container
 
 import (
 	"context"
-	"dokku-service/logstreamer"
+	"dokku-service/engine"
+	"dokku-service/plan"
+	"dokku-service/service"
 	"dokku-service/volume"
 	"fmt"
-	"os"
 	"strconv"
-	"strings"
-	"sync"
-
-	"github.com/alexellis/go-execute/v2"
-	"mvdan.cc/sh/v3/shell"
 )
 
 type CreateInput struct {
+	// Command specifies the command to run in the container, defaulting to the image's command
+	Command []string
+
+	// ConfigHash specifies the hash of the service config the container is created from
+	ConfigHash string
+
 	// CreateFlags specifies the flags to use when creating the container
 	CreateFlags []string
 
 	// ContainerName specifies the name of the container to create
 	ContainerName string
 
-	// EnvFile specifies the file to use for environment variables
+	// Env specifies KEY=VALUE environment variables to set in addition to those in the env file
+	Env []string
+
+	// EnvFile specifies the file to use for environment variables, if any
 	EnvFile string
 
 	// ImageName specifies the name of the image to use
 	ImageName string
 
-	// ServiceRoot specifies the root directory for the service
+	// Links specifies legacy container links in the form name:alias
+	Links []string
+
+	// Network specifies the network to create the container on, defaulting to the runtime's default network
+	Network string
+
+	// ServiceRoot specifies the root directory for the service, where the container id is written if set
 	ServiceRoot string
 
 	// Trace controls whether to print the command being executed
@@ -40,55 +51,57 @@ type CreateInput struct {
 	Volumes []volume.Volume
 }
 
-// Create creates a container
+// Create creates a container, writing its id to the ID file in the service root
 func Create(ctx context.Context, input CreateInput) error {
-	cmdArgs := []string{
-		"container", "create",
-		"--name", input.ContainerName,
-		"--env-file", input.EnvFile,
-		"--restart", "always",
-		"--hostname", input.ContainerName,
-		"--cidfile", fmt.Sprintf("%s/ID", input.ServiceRoot),
+	env := []string{}
+	if input.EnvFile != "" {
+		var err error
+		env, err = service.ReadEnvFile(ctx, service.ReadEnvFileInput{
+			Path: input.EnvFile,
+		})
+		if err != nil {
+			return err
+		}
 	}
-	cmdArgs = append(cmdArgs, "--label", fmt.Sprintf("com.dokku.service-volumes=%s", strconv.FormatBool(input.UseVolumes)))
 
-	for _, createFlag := range input.CreateFlags {
-		out, _ := shell.Fields(createFlag, nil)
-		cmdArgs = append(cmdArgs, out...)
+	spec := engine.ContainerSpec{
+		Command:     input.Command,
+		CreateFlags: input.CreateFlags,
+		Env:         append(env, input.Env...),
+		Hostname:    input.ContainerName,
+		Image:       input.ImageName,
+		Labels: map[string]string{
+			"com.dokku.service-volumes": strconv.FormatBool(input.UseVolumes),
+		},
+		Links:         input.Links,
+		Name:          input.ContainerName,
+		Network:       input.Network,
+		RestartPolicy: "always",
 	}
-
-	for _, volume := range input.Volumes {
-		cmdArgs = append(cmdArgs, "--mount", volume.MountArgs)
+	if input.ConfigHash != "" {
+		spec.Labels[service.ConfigHashLabel] = input.ConfigHash
 	}
 
-	cmdArgs = append(cmdArgs, input.ImageName)
-
-	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        cmdArgs,
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stdout,
-		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stderr,
-		}),
+	for _, volume := range input.Volumes {
+		spec.Mounts = append(spec.Mounts, engine.Mount{
+			Source: volume.Source,
+			Target: volume.ContainerPath,
+			Type:   volume.MountType,
+		})
 	}
 
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+	engine.Trace(input.Trace, "container create", input.ContainerName, input.ImageName)
+	containerID, err := engine.FromContext(ctx).ContainerCreate(ctx, spec)
 	if err != nil {
 		return fmt.Errorf("container create for service failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
+	if input.ServiceRoot == "" {
+		return nil
+	}
+
+	if err := plan.WriteFile(ctx, fmt.Sprintf("%s/ID", input.ServiceRoot), []byte(containerID), 0o644); err != nil {
+		return fmt.Errorf("failed to write container id file: %w", err)
 	}
 
 	return nil
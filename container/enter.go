@@ -2,12 +2,12 @@ package container
 
 import (
 	"context"
+	"dokku-service/engine"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
-	"github.com/alexellis/go-execute/v2"
 	"golang.org/x/term"
 )
 
@@ -22,6 +22,9 @@ type EnterInput struct {
 	// Shell to use in the container
 	Shell string
 
+	// Stdin is the reader to pass to the command as stdin
+	Stdin io.Reader
+
 	// StdErrWriter is the writer to write the stderr of the command to
 	StdErrWriter io.Writer
 
@@ -33,7 +36,21 @@ type EnterInput struct {
 }
 
 // Enter enters a container
+//
+// A non-zero exit of the command is not an error, as it is the
+// exit of the interactive session rather than a failure to enter
 func Enter(ctx context.Context, input EnterInput) error {
+	err := execCommand(ctx, input)
+	var exitErr *engine.ExitError
+	if errors.As(err, &exitErr) {
+		return nil
+	}
+
+	return err
+}
+
+// execCommand executes a command in a container, returning an error if it exits non-zero
+func execCommand(ctx context.Context, input EnterInput) error {
 	command := []string{input.Shell}
 	if len(input.Command) > 0 {
 		command = input.Command
@@ -42,12 +59,10 @@ func Enter(ctx context.Context, input EnterInput) error {
 		command = []string{"/bin/bash"}
 	}
 
-	args := []string{"container", "exec"}
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		args = append(args, "-it")
-	}
-
-	args = append(args, input.Name)
+	// a tty is only allocated for interactive sessions, as it
+	// mangles binary output and stdin streamed into the container
+	interactive := input.Stdin == nil && input.StdOutWriter == nil
+	tty := interactive && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
 
 	stdoutWriter := io.Writer(os.Stdout)
 	if input.StdOutWriter != nil {
@@ -58,32 +73,28 @@ func Enter(ctx context.Context, input EnterInput) error {
 		stderrWriter = input.StdErrWriter
 	}
 
+	stdin := io.Reader(os.Stdin)
+	if input.Stdin != nil {
+		stdin = input.Stdin
+	}
+
 	env := []string{}
 	if input.Trace {
 		env = append(env, "TRACE=1")
 	}
 
-	args = append(args, command...)
-	cmd := execute.ExecTask{
-		Command:      "docker",
-		Args:         args,
-		Env:          env,
-		StdOutWriter: stdoutWriter,
-		StdErrWriter: stderrWriter,
-		Stdin:        os.Stdin,
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+	engine.Trace(input.Trace, "container exec", append([]string{input.Name}, command...)...)
+	err := engine.FromContext(ctx).ContainerExec(ctx, input.Name, engine.ExecOptions{
+		Command: command,
+		Env:     env,
+		Stderr:  stderrWriter,
+		Stdin:   stdin,
+		Stdout:  stdoutWriter,
+		Tty:     tty,
+	})
 	if err != nil {
 		return fmt.Errorf("exec into container failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		return nil
-	}
-
 	return nil
 }
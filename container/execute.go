@@ -4,9 +4,9 @@ import (
 	"context"
 	"dokku-service/service"
 	"fmt"
-	"html/template"
 	"io"
 	"strings"
+	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
 	"mvdan.cc/sh/v3/shell"
@@ -23,6 +23,9 @@ type ExecuteInput struct {
 	// Config specifies the service template config to use
 	ConfigOutput service.ConfigOutput
 
+	// Stdin is the reader to pass to the command as stdin
+	Stdin io.Reader
+
 	// StdErrWriter is the writer to write the stderr of the command to
 	StdErrWriter io.Writer
 
@@ -60,9 +63,10 @@ func Execute(ctx context.Context, input ExecuteInput) error {
 		return fmt.Errorf("failed to parse connect command: %w", err)
 	}
 
-	return Enter(ctx, EnterInput{
+	return execCommand(ctx, EnterInput{
 		Name:         input.Name,
 		Command:      fields,
+		Stdin:        input.Stdin,
 		StdErrWriter: input.StdErrWriter,
 		StdOutWriter: input.StdOutWriter,
 		Trace:        input.Trace,
@@ -2,11 +2,9 @@ package container
 
 import (
 	"context"
+	"dokku-service/engine"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // ExistsInput contains the input parameters for the Exists function
@@ -20,26 +18,13 @@ type ExistsInput struct {
 
 // Exists checks if a container exists
 func Exists(ctx context.Context, input ExistsInput) (bool, error) {
-	cmd := execute.ExecTask{
-		Command: "docker",
-		Args: []string{
-			"container", "inspect",
-			input.Name,
-		},
-		StreamStdio: false,
-	}
+	if _, err := Inspect(ctx, InspectInput(input)); err != nil {
+		if errors.Is(err, engine.ErrNotFound) {
+			return false, nil
+		}
 
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
-	if err != nil {
 		return false, fmt.Errorf("check for container existence failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		return false, nil
-	}
-
 	return true, nil
 }
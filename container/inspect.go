@@ -0,0 +1,21 @@
+package container
+
+import (
+	"context"
+	"dokku-service/engine"
+)
+
+// InspectInput contains the input parameters for the Inspect function
+type InspectInput struct {
+	// Name of the container to inspect
+	Name string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Inspect returns the state of a container, wrapping engine.ErrNotFound if it does not exist
+func Inspect(ctx context.Context, input InspectInput) (engine.Container, error) {
+	engine.Trace(input.Trace, "container inspect", input.Name)
+	return engine.FromContext(ctx).ContainerInspect(ctx, input.Name)
+}
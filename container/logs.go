@@ -2,13 +2,12 @@ package container
 
 import (
 	"context"
+	"dokku-service/engine"
 	"dokku-service/logstreamer"
 	"fmt"
 	"os"
-	"strings"
+	"strconv"
 	"sync"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // LogsInput contains the input parameters for the Logs function
@@ -19,6 +18,9 @@ type LogsInput struct {
 	// Name of the container to get logs from
 	Name string
 
+	// Prefix is written before each line of the logs, if set
+	Prefix string
+
 	// Tail is the number of lines to show from the end of the logs
 	Tail int
 
@@ -28,45 +30,27 @@ type LogsInput struct {
 
 // Logs gets logs from a container
 func Logs(ctx context.Context, input LogsInput) error {
-	args := []string{"container", "logs"}
-	if input.Follow {
-		args = append(args, "--follow")
-	}
-	if input.Tail < 0 {
-		args = append(args, "--tail", "all")
-	} else if input.Tail > 0 {
-		args = append(args, "--tail", fmt.Sprintf("%d", input.Tail))
-	}
-	args = append(args, input.Name)
-
 	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        args,
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			DisablePrefix: true,
+	disablePrefix := input.Prefix == ""
+	engine.Trace(input.Trace, "container logs", "--follow="+strconv.FormatBool(input.Follow), "--tail="+strconv.Itoa(input.Tail), input.Name)
+	err := engine.FromContext(ctx).ContainerLogs(ctx, input.Name, engine.LogsOptions{
+		Follow: input.Follow,
+		Stdout: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+			DisablePrefix: disablePrefix,
 			Mutex:         &mu,
+			Prefix:        input.Prefix,
 			Writer:        os.Stdout,
 		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			DisablePrefix: true,
+		Stderr: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+			DisablePrefix: disablePrefix,
 			Mutex:         &mu,
+			Prefix:        input.Prefix,
 			Writer:        os.Stderr,
 		}),
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+		Tail: input.Tail,
+	})
 	if err != nil {
-		return fmt.Errorf("container start for service failed: %w", err)
-	}
-
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
+		return fmt.Errorf("container logs for service failed: %w", err)
 	}
 
 	return nil
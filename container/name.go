@@ -10,8 +10,15 @@ type NameInput struct {
 
 	// ServiceType is the type of service
 	ServiceType string
+
+	// Sidecar is the name of a sidecar of the service, or empty for the service container
+	Sidecar string
 }
 
 func Name(input NameInput) string {
+	if input.Sidecar != "" {
+		return fmt.Sprintf("dokku.%s.%s.%s", input.ServiceType, input.ServiceName, input.Sidecar)
+	}
+
 	return fmt.Sprintf("dokku.%s.%s", input.ServiceType, input.ServiceName)
 }
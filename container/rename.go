@@ -0,0 +1,29 @@
+package container
+
+import (
+	"context"
+	"dokku-service/engine"
+	"fmt"
+)
+
+// RenameInput contains the input parameters for the Rename function
+type RenameInput struct {
+	// Name of the container to rename
+	Name string
+
+	// NewName is the name to rename the container to
+	NewName string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Rename renames a container
+func Rename(ctx context.Context, input RenameInput) error {
+	engine.Trace(input.Trace, "container rename", input.Name, input.NewName)
+	if err := engine.FromContext(ctx).ContainerRename(ctx, input.Name, input.NewName); err != nil {
+		return fmt.Errorf("failed to rename container: %w", err)
+	}
+
+	return nil
+}
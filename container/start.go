@@ -2,13 +2,8 @@ package container
 
 import (
 	"context"
-	"dokku-service/logstreamer"
+	"dokku-service/engine"
 	"fmt"
-	"os"
-	"strings"
-	"sync"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // StartInput contains the input parameters for the Start function
@@ -22,33 +17,10 @@ type StartInput struct {
 
 // Start starts a container
 func Start(ctx context.Context, input StartInput) error {
-	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        []string{"container", "start", input.Name},
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stdout,
-		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stderr,
-		}),
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
-	if err != nil {
+	engine.Trace(input.Trace, "container start", input.Name)
+	if err := engine.FromContext(ctx).ContainerStart(ctx, input.Name); err != nil {
 		return fmt.Errorf("container start for service failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
-	}
-
 	return nil
 }
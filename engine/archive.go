@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// archiveDirectory streams a tar archive of a directory for use as a build context
+func archiveDirectory(directory string) (io.ReadCloser, error) {
+	if _, err := os.Stat(directory); err != nil {
+		return nil, fmt.Errorf("failed to read build context: %w", err)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeArchive(directory, writer))
+	}()
+
+	return reader, nil
+}
+
+// writeArchive writes the files of a directory to a tar archive
+func writeArchive(directory string, writer io.Writer) error {
+	tw := tar.NewWriter(writer)
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive build context: %w", err)
+	}
+
+	return tw.Close()
+}
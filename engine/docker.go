@@ -0,0 +1,657 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/moby/moby/client"
+	"golang.org/x/term"
+)
+
+var _ Runtime = (*Docker)(nil)
+
+// Docker is a runtime backed by the Docker Engine API
+type Docker struct {
+	// Host is the daemon socket to connect to, defaulting to DOCKER_HOST
+	Host string
+
+	once   sync.Once
+	client *client.Client
+	err    error
+}
+
+// NewDockerInput contains the input parameters for the NewDocker function
+type NewDockerInput struct {
+	// Host is the daemon socket to connect to, defaulting to DOCKER_HOST
+	Host string
+}
+
+// NewDocker returns a runtime backed by the Docker Engine API
+//
+// The connection to the daemon is established lazily on first use
+func NewDocker(input NewDockerInput) *Docker {
+	return &Docker{Host: input.Host}
+}
+
+// api returns the engine api client, creating it on first use
+func (d *Docker) api() (*client.Client, error) {
+	d.once.Do(func() {
+		opts := []client.Opt{
+			client.FromEnv,
+			client.WithAPIVersionNegotiation(),
+		}
+		if d.Host != "" {
+			opts = append(opts, client.WithHost(d.Host))
+		}
+
+		d.client, d.err = client.NewClientWithOpts(opts...)
+	})
+
+	return d.client, d.err
+}
+
+// wrapError marks engine api not found errors with ErrNotFound
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if client.IsErrNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	return err
+}
+
+// ContainerCreate creates a container, pulling its image if it does not exist locally
+func (d *Docker) ContainerCreate(ctx context.Context, spec ContainerSpec) (string, error) {
+	cli, err := d.api()
+	if err != nil {
+		return "", err
+	}
+
+	config, hostConfig, networkingConfig, err := containerConfig(spec)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, spec.Name)
+	if client.IsErrNotFound(err) {
+		if err := d.imagePull(ctx, spec.Image); err != nil {
+			return "", err
+		}
+
+		response, err = cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, spec.Name)
+	}
+	if err != nil {
+		return "", wrapError(err)
+	}
+
+	return response.ID, nil
+}
+
+// containerConfig converts a container spec into engine api configuration
+func containerConfig(spec ContainerSpec) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	labels := map[string]string{}
+	for key, value := range spec.Labels {
+		labels[key] = value
+	}
+
+	config := &container.Config{
+		Cmd:          spec.Command,
+		Env:          append([]string{}, spec.Env...),
+		ExposedPorts: nat.PortSet{},
+		Hostname:     spec.Hostname,
+		Image:        spec.Image,
+		Labels:       labels,
+	}
+	hostConfig := &container.HostConfig{
+		Links:        spec.Links,
+		PortBindings: nat.PortMap{},
+	}
+	if spec.RestartPolicy != "" {
+		policy, err := parseRestartPolicy(spec.RestartPolicy)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		hostConfig.RestartPolicy = policy
+	}
+	if spec.Network != "" {
+		hostConfig.NetworkMode = container.NetworkMode(spec.Network)
+	}
+
+	for _, m := range spec.Mounts {
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Source: m.Source,
+			Target: m.Target,
+			Type:   mount.Type(m.Type),
+		})
+	}
+
+	for _, port := range spec.Ports {
+		containerPort := nat.Port(fmt.Sprintf("%d/tcp", port.ContainerPort))
+		config.ExposedPorts[containerPort] = struct{}{}
+		hostConfig.PortBindings[containerPort] = append(hostConfig.PortBindings[containerPort], nat.PortBinding{
+			HostIP:   port.HostIP,
+			HostPort: strconv.Itoa(port.HostPort),
+		})
+	}
+
+	if err := applyCreateFlags(spec.CreateFlags, config, hostConfig); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return config, hostConfig, &network.NetworkingConfig{}, nil
+}
+
+// ContainerStart starts a container
+func (d *Docker) ContainerStart(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.ContainerStart(ctx, name, container.StartOptions{}))
+}
+
+// ContainerStop stops a container
+func (d *Docker) ContainerStop(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.ContainerStop(ctx, name, container.StopOptions{}))
+}
+
+// ContainerRemove removes a stopped container
+func (d *Docker) ContainerRemove(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.ContainerRemove(ctx, name, container.RemoveOptions{}))
+}
+
+// ContainerRename renames a container
+func (d *Docker) ContainerRename(ctx context.Context, name string, newName string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.ContainerRename(ctx, name, newName))
+}
+
+// ContainerInspect returns the state of a container
+func (d *Docker) ContainerInspect(ctx context.Context, name string) (Container, error) {
+	cli, err := d.api()
+	if err != nil {
+		return Container{}, err
+	}
+
+	response, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return Container{}, wrapError(err)
+	}
+
+	c := Container{
+		Labels:   map[string]string{},
+		Networks: map[string]string{},
+	}
+	if response.ContainerJSONBase != nil {
+		c.ID = response.ID
+		c.Image = response.Image
+		c.Name = strings.TrimPrefix(response.Name, "/")
+		c.RestartCount = response.RestartCount
+		if response.State != nil {
+			c.Pid = response.State.Pid
+			c.Running = response.State.Running
+			c.Status = response.State.Status
+		}
+	}
+	if response.Config != nil {
+		c.Image = response.Config.Image
+		for key, value := range response.Config.Labels {
+			c.Labels[key] = value
+		}
+	}
+	if response.NetworkSettings != nil {
+		c.IPAddress = response.NetworkSettings.IPAddress
+		for networkName, endpoint := range response.NetworkSettings.Networks {
+			ipAddress := ""
+			if endpoint != nil {
+				ipAddress = endpoint.IPAddress
+			}
+			c.Networks[networkName] = ipAddress
+		}
+	}
+
+	return c, nil
+}
+
+// ContainerLogs writes the logs of a container to the writers in the options
+func (d *Docker) ContainerLogs(ctx context.Context, name string, options LogsOptions) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	response, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	tail := ""
+	if options.Tail < 0 {
+		tail = "all"
+	} else if options.Tail > 0 {
+		tail = strconv.Itoa(options.Tail)
+	}
+
+	reader, err := cli.ContainerLogs(ctx, name, container.LogsOptions{
+		Follow:     options.Follow,
+		ShowStderr: true,
+		ShowStdout: true,
+		Tail:       tail,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer reader.Close()
+
+	if response.Config != nil && response.Config.Tty {
+		_, err = io.Copy(options.Stdout, reader)
+	} else {
+		_, err = stdcopy.StdCopy(options.Stdout, options.Stderr, reader)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+
+	return nil
+}
+
+// ContainerExec executes a command in a running container, returning an ExitError if it exits non-zero
+func (d *Docker) ContainerExec(ctx context.Context, name string, options ExecOptions) error {
+	// output is discarded when no writer is passed, as io.MultiWriter and stdcopy panic on nil writers
+	if options.Stdout == nil {
+		options.Stdout = io.Discard
+	}
+	if options.Stderr == nil {
+		options.Stderr = io.Discard
+	}
+
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	execResponse, err := cli.ContainerExecCreate(ctx, name, container.ExecOptions{
+		AttachStderr: true,
+		AttachStdin:  options.Stdin != nil,
+		AttachStdout: true,
+		Cmd:          options.Command,
+		Env:          options.Env,
+		Tty:          options.Tty,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+
+	hijacked, err := cli.ContainerExecAttach(ctx, execResponse.ID, container.ExecAttachOptions{
+		Tty: options.Tty,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer hijacked.Close()
+
+	if options.Tty {
+		if file, ok := options.Stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+			state, err := term.MakeRaw(int(file.Fd()))
+			if err != nil {
+				return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+			}
+			defer term.Restore(int(file.Fd()), state)
+		}
+		if file, ok := options.Stdout.(*os.File); ok {
+			if width, height, err := term.GetSize(int(file.Fd())); err == nil {
+				_ = cli.ContainerExecResize(ctx, execResponse.ID, container.ResizeOptions{
+					Height: uint(height),
+					Width:  uint(width),
+				})
+			}
+		}
+	}
+
+	if options.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(hijacked.Conn, options.Stdin)
+			_ = hijacked.CloseWrite()
+		}()
+	}
+
+	stderr := &bytes.Buffer{}
+	stderrWriter := io.MultiWriter(options.Stderr, stderr)
+	if options.Tty {
+		_, err = io.Copy(options.Stdout, hijacked.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(options.Stdout, stderrWriter, hijacked.Reader)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	for {
+		inspect, err := cli.ContainerExecInspect(ctx, execResponse.ID)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return &ExitError{Code: inspect.ExitCode, Stderr: stderr.String()}
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// ContainerRun creates and starts a container, waits for it to exit and removes it,
+// returning an ExitError if it exits non-zero
+func (d *Docker) ContainerRun(ctx context.Context, spec ContainerSpec, stdout io.Writer, stderr io.Writer) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	id, err := d.ContainerCreate(ctx, spec)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = cli.ContainerRemove(context.WithoutCancel(ctx), id, container.RemoveOptions{Force: true})
+	}()
+
+	hijacked, err := cli.ContainerAttach(ctx, id, container.AttachOptions{
+		Stderr: true,
+		Stdout: true,
+		Stream: true,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer hijacked.Close()
+
+	waitCh, errCh := cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+	if err := cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return wrapError(err)
+	}
+
+	output := &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(stdout, io.MultiWriter(stderr, output), hijacked.Reader); err != nil {
+		return fmt.Errorf("failed to read container output: %w", err)
+	}
+
+	select {
+	case err := <-errCh:
+		return wrapError(err)
+	case result := <-waitCh:
+		if result.Error != nil {
+			return errors.New(result.Error.Message)
+		}
+		if result.StatusCode != 0 {
+			return &ExitError{Code: int(result.StatusCode), Stderr: output.String()}
+		}
+	}
+
+	return nil
+}
+
+// ImageBuild builds an image from a context directory
+func (d *Docker) ImageBuild(ctx context.Context, options BuildOptions) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	buildOptions := build.ImageBuildOptions{
+		BuildArgs:  map[string]*string{},
+		Dockerfile: options.Dockerfile,
+		Remove:     true,
+		Tags:       []string{options.Tag},
+	}
+	for key, value := range options.BuildArgs {
+		buildOptions.BuildArgs[key] = &value
+	}
+	if err := applyBuildFlags(options.Flags, &buildOptions); err != nil {
+		return err
+	}
+
+	buildContext, err := archiveDirectory(options.ContextDirectory)
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+
+	response, err := cli.ImageBuild(ctx, buildContext, buildOptions)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer response.Body.Close()
+
+	return decodeStream(response.Body, options.Output)
+}
+
+// ImageExists checks if an image exists locally
+func (d *Docker) ImageExists(ctx context.Context, name string) (bool, error) {
+	cli, err := d.api()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := cli.ImageInspect(ctx, name); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ImageTag adds a tag to an existing image
+func (d *Docker) ImageTag(ctx context.Context, source string, target string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.ImageTag(ctx, source, target))
+}
+
+// ImageRemove removes an image tag, deleting the image once it has no tags left
+func (d *Docker) ImageRemove(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.ImageRemove(ctx, name, image.RemoveOptions{PruneChildren: true})
+	return wrapError(err)
+}
+
+// imagePull pulls an image, writing progress to stderr
+func (d *Docker) imagePull(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	reader, err := cli.ImagePull(ctx, name, image.PullOptions{})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer reader.Close()
+
+	return decodeStream(reader, os.Stderr)
+}
+
+// NetworkExists checks if a network exists
+func (d *Docker) NetworkExists(ctx context.Context, name string) (bool, error) {
+	cli, err := d.api()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := cli.NetworkInspect(ctx, name, network.InspectOptions{}); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NetworkConnect connects a container to a network under an alias
+func (d *Docker) NetworkConnect(ctx context.Context, networkName string, containerName string, alias string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	endpoint := &network.EndpointSettings{}
+	if alias != "" {
+		endpoint.Aliases = []string{alias}
+	}
+
+	return wrapError(cli.NetworkConnect(ctx, networkName, containerName, endpoint))
+}
+
+// NetworkDisconnect disconnects a container from a network
+func (d *Docker) NetworkDisconnect(ctx context.Context, networkName string, containerName string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.NetworkDisconnect(ctx, networkName, containerName, false))
+}
+
+// VolumeCreate creates a named volume
+func (d *Docker) VolumeCreate(ctx context.Context, name string, labels map[string]string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.VolumeCreate(ctx, volume.CreateOptions{
+		Labels: labels,
+		Name:   name,
+	})
+	return wrapError(err)
+}
+
+// VolumeExists checks if a named volume exists
+func (d *Docker) VolumeExists(ctx context.Context, name string) (bool, error) {
+	cli, err := d.api()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := cli.VolumeInspect(ctx, name); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// VolumeRemove removes a named volume that is not in use
+func (d *Docker) VolumeRemove(ctx context.Context, name string) error {
+	cli, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	return wrapError(cli.VolumeRemove(ctx, name, false))
+}
+
+// streamMessage is a single message of a build or pull progress stream
+type streamMessage struct {
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	ID       string `json:"id"`
+	Progress string `json:"progress"`
+	Status   string `json:"status"`
+	Stream   string `json:"stream"`
+}
+
+// decodeStream writes the messages of a build or pull progress stream to a writer,
+// returning the first error message in the stream
+func decodeStream(reader io.Reader, writer io.Writer) error {
+	if writer == nil {
+		writer = io.Discard
+	}
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var message streamMessage
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode progress stream: %w", err)
+		}
+
+		if message.ErrorDetail.Message != "" {
+			return errors.New(message.ErrorDetail.Message)
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+
+		if message.Stream != "" {
+			fmt.Fprint(writer, message.Stream)
+			continue
+		}
+
+		// progress bars are only meaningful on a terminal, so skip them
+		if message.Status != "" && message.Progress == "" {
+			if message.ID != "" {
+				fmt.Fprintf(writer, "%s: %s\n", message.ID, message.Status)
+			} else {
+				fmt.Fprintln(writer, message.Status)
+			}
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package engine
+
+import (
+	"context"
+	"dokku-service/plan"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var _ Runtime = (*DryRun)(nil)
+
+// DryRun is a runtime that records changes to a plan instead of making them.
+// Reads are passed through to the wrapped runtime, with planned changes layered on top
+// so later steps see the containers, images and volumes earlier steps would have created.
+type DryRun struct {
+	containers     map[string]Container
+	images         map[string]bool
+	mu             sync.Mutex
+	plan           *plan.Plan
+	removed        map[string]bool
+	removedImages  map[string]bool
+	removedVolumes map[string]bool
+	runtime        Runtime
+	volumes        map[string]bool
+}
+
+// NewDryRunInput contains the input parameters for the NewDryRun function
+type NewDryRunInput struct {
+	// Plan is the plan to record changes to
+	Plan *plan.Plan
+
+	// Runtime is the runtime to read state from
+	Runtime Runtime
+}
+
+// NewDryRun returns a runtime that records changes to a plan
+func NewDryRun(input NewDryRunInput) *DryRun {
+	return &DryRun{
+		containers:     map[string]Container{},
+		images:         map[string]bool{},
+		plan:           input.Plan,
+		removed:        map[string]bool{},
+		removedImages:  map[string]bool{},
+		removedVolumes: map[string]bool{},
+		runtime:        input.Runtime,
+		volumes:        map[string]bool{},
+	}
+}
+
+// inspect returns the planned state of a container by name or id
+func (d *DryRun) inspect(ctx context.Context, name string) (Container, error) {
+	d.mu.Lock()
+	if c, ok := d.containers[name]; ok {
+		d.mu.Unlock()
+		return c, nil
+	}
+	for _, c := range d.containers {
+		if c.ID == name {
+			d.mu.Unlock()
+			return c, nil
+		}
+	}
+	removed := d.removed[name]
+	d.mu.Unlock()
+
+	if removed {
+		return Container{}, fmt.Errorf("%w: no such container: %s", ErrNotFound, name)
+	}
+
+	c, err := d.runtime.ContainerInspect(ctx, name)
+	if err != nil {
+		return Container{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.removed[c.Name] || d.removed[c.ID] {
+		return Container{}, fmt.Errorf("%w: no such container: %s", ErrNotFound, name)
+	}
+	return c, nil
+}
+
+// update stores the planned state of a container
+func (d *DryRun) update(c Container) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.removed, c.Name)
+	d.containers[c.Name] = c
+}
+
+// ContainerCreate records creating a container
+func (d *DryRun) ContainerCreate(ctx context.Context, spec ContainerSpec) (string, error) {
+	if _, _, _, err := containerConfig(spec); err != nil {
+		return "", err
+	}
+	if spec.Name != "" {
+		if _, err := d.inspect(ctx, spec.Name); err == nil {
+			return "", fmt.Errorf("conflict: container name %s is already in use", spec.Name)
+		}
+	}
+
+	d.plan.Add("container create", spec.Name, specDetails(spec))
+
+	networkName := spec.Network
+	if networkName == "" {
+		networkName = "bridge"
+	}
+	c := Container{
+		ID:       "planned-" + spec.Name,
+		Image:    spec.Image,
+		Labels:   map[string]string{},
+		Name:     spec.Name,
+		Networks: map[string]string{networkName: ""},
+		Status:   "created",
+	}
+	for key, value := range spec.Labels {
+		c.Labels[key] = value
+	}
+	d.update(c)
+
+	return c.ID, nil
+}
+
+// ContainerExec records executing a command in a container
+func (d *DryRun) ContainerExec(ctx context.Context, name string, options ExecOptions) error {
+	if _, err := d.inspect(ctx, name); err != nil {
+		return err
+	}
+
+	d.plan.Add("container exec", name, map[string]any{
+		"command": strings.Join(options.Command, " "),
+		"env":     options.Env,
+	})
+	return nil
+}
+
+// ContainerInspect returns the planned state of a container
+func (d *DryRun) ContainerInspect(ctx context.Context, name string) (Container, error) {
+	return d.inspect(ctx, name)
+}
+
+// ContainerLogs writes the logs of an existing container, and nothing for a planned one
+func (d *DryRun) ContainerLogs(ctx context.Context, name string, options LogsOptions) error {
+	d.mu.Lock()
+	_, planned := d.containers[name]
+	d.mu.Unlock()
+	if planned {
+		return nil
+	}
+
+	return d.runtime.ContainerLogs(ctx, name, options)
+}
+
+// ContainerRemove records removing a container
+func (d *DryRun) ContainerRemove(ctx context.Context, name string) error {
+	c, err := d.inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	d.plan.Add("container rm", c.Name, nil)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.containers, c.Name)
+	d.removed[c.Name] = true
+	if c.ID != "" {
+		d.removed[c.ID] = true
+	}
+	return nil
+}
+
+// ContainerRename records renaming a container
+func (d *DryRun) ContainerRename(ctx context.Context, name string, newName string) error {
+	c, err := d.inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	if _, err := d.inspect(ctx, newName); err == nil {
+		return fmt.Errorf("conflict: container name %s is already in use", newName)
+	}
+
+	d.plan.Add("container rename", c.Name, map[string]any{
+		"name": newName,
+	})
+
+	d.mu.Lock()
+	delete(d.containers, c.Name)
+	d.removed[c.Name] = true
+	d.mu.Unlock()
+
+	c.Name = newName
+	d.update(c)
+	return nil
+}
+
+// ContainerRun records running a container to completion
+func (d *DryRun) ContainerRun(ctx context.Context, spec ContainerSpec, stdout io.Writer, stderr io.Writer) error {
+	if _, _, _, err := containerConfig(spec); err != nil {
+		return err
+	}
+
+	d.plan.Add("container run", spec.Image, specDetails(spec))
+	return nil
+}
+
+// ContainerStart records starting a container that is not running
+func (d *DryRun) ContainerStart(ctx context.Context, name string) error {
+	c, err := d.inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	if c.Running {
+		return nil
+	}
+
+	d.plan.Add("container start", c.Name, nil)
+
+	// a placeholder pid lets readiness checks treat the container as started
+	c.Pid = 1
+	c.Running = true
+	c.Status = "running"
+	d.update(c)
+	return nil
+}
+
+// ContainerStop records stopping a running container
+func (d *DryRun) ContainerStop(ctx context.Context, name string) error {
+	c, err := d.inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !c.Running {
+		return nil
+	}
+
+	d.plan.Add("container stop", c.Name, nil)
+
+	c.Pid = 0
+	c.Running = false
+	c.Status = "exited"
+	d.update(c)
+	return nil
+}
+
+// ImageBuild records building an image
+func (d *DryRun) ImageBuild(ctx context.Context, options BuildOptions) error {
+	d.plan.Add("image build", options.Tag, map[string]any{
+		"build args": options.BuildArgs,
+		"context":    options.ContextDirectory,
+		"dockerfile": options.Dockerfile,
+		"flags":      options.Flags,
+	})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.images[options.Tag] = true
+	delete(d.removedImages, options.Tag)
+	return nil
+}
+
+// ImageExists checks if an image exists locally or is planned to be built
+func (d *DryRun) ImageExists(ctx context.Context, name string) (bool, error) {
+	d.mu.Lock()
+	planned, removed := d.images[name], d.removedImages[name]
+	d.mu.Unlock()
+	if planned {
+		return true, nil
+	}
+	if removed {
+		return false, nil
+	}
+
+	return d.runtime.ImageExists(ctx, name)
+}
+
+// ImageRemove records removing an image tag
+func (d *DryRun) ImageRemove(ctx context.Context, name string) error {
+	exists, err := d.ImageExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: no such image: %s", ErrNotFound, name)
+	}
+
+	d.plan.Add("image rm", name, nil)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.images, name)
+	d.removedImages[name] = true
+	return nil
+}
+
+// ImageTag records adding a tag to an image
+func (d *DryRun) ImageTag(ctx context.Context, source string, target string) error {
+	exists, err := d.ImageExists(ctx, source)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: no such image: %s", ErrNotFound, source)
+	}
+
+	d.plan.Add("image tag", target, map[string]any{
+		"source": source,
+	})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.images[target] = true
+	delete(d.removedImages, target)
+	return nil
+}
+
+// NetworkConnect records connecting a container to a network
+func (d *DryRun) NetworkConnect(ctx context.Context, networkName string, containerName string, alias string) error {
+	c, err := d.inspect(ctx, containerName)
+	if err != nil {
+		return err
+	}
+
+	d.plan.Add("network connect", networkName, map[string]any{
+		"alias":     alias,
+		"container": containerName,
+	})
+
+	networks := map[string]string{}
+	for key, value := range c.Networks {
+		networks[key] = value
+	}
+	networks[networkName] = ""
+	c.Networks = networks
+	d.update(c)
+	return nil
+}
+
+// NetworkDisconnect records disconnecting a container from a network
+func (d *DryRun) NetworkDisconnect(ctx context.Context, networkName string, containerName string) error {
+	c, err := d.inspect(ctx, containerName)
+	if err != nil {
+		return err
+	}
+
+	d.plan.Add("network disconnect", networkName, map[string]any{
+		"container": containerName,
+	})
+
+	networks := map[string]string{}
+	for key, value := range c.Networks {
+		if key != networkName {
+			networks[key] = value
+		}
+	}
+	c.Networks = networks
+	d.update(c)
+	return nil
+}
+
+// NetworkExists checks if a network exists
+func (d *DryRun) NetworkExists(ctx context.Context, name string) (bool, error) {
+	return d.runtime.NetworkExists(ctx, name)
+}
+
+// VolumeCreate records creating a named volume
+func (d *DryRun) VolumeCreate(ctx context.Context, name string, labels map[string]string) error {
+	d.plan.Add("volume create", name, map[string]any{
+		"labels": labels,
+	})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.volumes[name] = true
+	delete(d.removedVolumes, name)
+	return nil
+}
+
+// VolumeExists checks if a named volume exists or is planned to be created
+func (d *DryRun) VolumeExists(ctx context.Context, name string) (bool, error) {
+	d.mu.Lock()
+	planned, removed := d.volumes[name], d.removedVolumes[name]
+	d.mu.Unlock()
+	if planned {
+		return true, nil
+	}
+	if removed {
+		return false, nil
+	}
+
+	return d.runtime.VolumeExists(ctx, name)
+}
+
+// VolumeRemove records removing a named volume
+func (d *DryRun) VolumeRemove(ctx context.Context, name string) error {
+	exists, err := d.VolumeExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: no such volume: %s", ErrNotFound, name)
+	}
+
+	d.plan.Add("volume rm", name, nil)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.volumes, name)
+	d.removedVolumes[name] = true
+	return nil
+}
+
+// specDetails returns the plan details of a container spec
+func specDetails(spec ContainerSpec) map[string]any {
+	mounts := []string{}
+	for _, mount := range spec.Mounts {
+		mounts = append(mounts, fmt.Sprintf("%s:%s (%s)", mount.Source, mount.Target, mount.Type))
+	}
+
+	ports := []string{}
+	for _, port := range spec.Ports {
+		binding := fmt.Sprintf("%d->%d", port.HostPort, port.ContainerPort)
+		if port.HostIP != "" {
+			binding = fmt.Sprintf("%s:%s", port.HostIP, binding)
+		}
+		ports = append(ports, binding)
+	}
+
+	env := append([]string{}, spec.Env...)
+	sort.Strings(env)
+
+	return map[string]any{
+		"command":        strings.Join(spec.Command, " "),
+		"env":            env,
+		"flags":          spec.CreateFlags,
+		"hostname":       spec.Hostname,
+		"image":          spec.Image,
+		"labels":         spec.Labels,
+		"links":          spec.Links,
+		"mounts":         mounts,
+		"network":        spec.Network,
+		"ports":          ports,
+		"restart policy": spec.RestartPolicy,
+	}
+}
@@ -0,0 +1,332 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when a container, image, network or volume does not exist
+var ErrNotFound = errors.New("not found")
+
+// ExitError is returned when a process run by the runtime exits non-zero
+type ExitError struct {
+	// Code is the exit code of the process
+	Code int
+
+	// Stderr is the stderr output of the process
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("non-zero exit code %d: %s", e.Code, e.Stderr)
+}
+
+// Container describes the state of a container
+type Container struct {
+	// ID is the id of the container
+	ID string
+
+	// IPAddress is the ip address of the container on the default network
+	IPAddress string
+
+	// Image is the image the container was created from
+	Image string
+
+	// Labels are the labels set on the container
+	Labels map[string]string
+
+	// Name is the name of the container
+	Name string
+
+	// Networks maps the networks the container is attached to to its ip address on each
+	Networks map[string]string
+
+	// Pid is the pid of the container's main process
+	Pid int
+
+	// RestartCount is the number of times the container has been restarted
+	RestartCount int
+
+	// Running specifies whether the container is running
+	Running bool
+
+	// Status is the status of the container
+	Status string
+}
+
+// ContainerSpec describes a container to create
+type ContainerSpec struct {
+	// Command is the command to run in the container
+	Command []string
+
+	// CreateFlags are docker cli style flags to apply to the container
+	CreateFlags []string
+
+	// Env are KEY=VALUE environment variables to set in the container
+	Env []string
+
+	// Hostname is the hostname of the container
+	Hostname string
+
+	// Image is the image to create the container from
+	Image string
+
+	// Labels are the labels to set on the container
+	Labels map[string]string
+
+	// Links are legacy container links in the form name:alias
+	Links []string
+
+	// Mounts are the mounts to attach to the container
+	Mounts []Mount
+
+	// Name is the name of the container
+	Name string
+
+	// Network is the network to attach the container to on creation
+	Network string
+
+	// Ports are the ports to publish on the host
+	Ports []PortBinding
+
+	// RestartPolicy is the restart policy of the container
+	RestartPolicy string
+}
+
+// Mount describes a bind or volume mount
+type Mount struct {
+	// Source is the host path or volume name
+	Source string
+
+	// Target is the path in the container
+	Target string
+
+	// Type is either bind or volume
+	Type string
+}
+
+// PortBinding describes a container port published on the host
+type PortBinding struct {
+	// ContainerPort is the port in the container
+	ContainerPort int
+
+	// HostIP is the host ip to bind to
+	HostIP string
+
+	// HostPort is the port on the host
+	HostPort int
+}
+
+// ExecOptions describes a command to execute in a running container
+type ExecOptions struct {
+	// Command is the command to execute
+	Command []string
+
+	// Env are KEY=VALUE environment variables to set for the command
+	Env []string
+
+	// Stderr is the writer to write the stderr of the command to
+	Stderr io.Writer
+
+	// Stdin is the reader to pass to the command as stdin
+	Stdin io.Reader
+
+	// Stdout is the writer to write the stdout of the command to
+	Stdout io.Writer
+
+	// Tty specifies whether to allocate a tty for the command
+	Tty bool
+}
+
+// LogsOptions describes the logs to fetch from a container
+type LogsOptions struct {
+	// Follow specifies whether to follow the logs
+	Follow bool
+
+	// Stderr is the writer to write the stderr of the container to
+	Stderr io.Writer
+
+	// Stdout is the writer to write the stdout of the container to
+	Stdout io.Writer
+
+	// Tail is the number of lines to show from the end of the logs, with negative values showing all
+	Tail int
+}
+
+// BuildOptions describes an image to build
+type BuildOptions struct {
+	// BuildArgs are the build arguments to pass to the build
+	BuildArgs map[string]string
+
+	// ContextDirectory is the directory to use as the build context
+	ContextDirectory string
+
+	// Dockerfile is the path to the Dockerfile relative to the context directory
+	Dockerfile string
+
+	// Flags are docker cli style flags to apply to the build
+	Flags []string
+
+	// Output is the writer to write build output to
+	Output io.Writer
+
+	// Tag is the tag to apply to the built image
+	Tag string
+}
+
+// Runtime manages containers, images, networks and volumes
+type Runtime interface {
+	// ContainerCreate creates a container, pulling its image if it does not exist locally, and returns its id
+	ContainerCreate(ctx context.Context, spec ContainerSpec) (string, error)
+
+	// ContainerExec executes a command in a running container, returning an ExitError if it exits non-zero
+	ContainerExec(ctx context.Context, name string, options ExecOptions) error
+
+	// ContainerInspect returns the state of a container
+	ContainerInspect(ctx context.Context, name string) (Container, error)
+
+	// ContainerLogs writes the logs of a container to the writers in the options
+	ContainerLogs(ctx context.Context, name string, options LogsOptions) error
+
+	// ContainerRemove removes a stopped container
+	ContainerRemove(ctx context.Context, name string) error
+
+	// ContainerRename renames a container
+	ContainerRename(ctx context.Context, name string, newName string) error
+
+	// ContainerRun creates and starts a container, waits for it to exit and removes it,
+	// returning an ExitError if it exits non-zero
+	ContainerRun(ctx context.Context, spec ContainerSpec, stdout io.Writer, stderr io.Writer) error
+
+	// ContainerStart starts a container
+	ContainerStart(ctx context.Context, name string) error
+
+	// ContainerStop stops a container
+	ContainerStop(ctx context.Context, name string) error
+
+	// ImageBuild builds an image from a context directory
+	ImageBuild(ctx context.Context, options BuildOptions) error
+
+	// ImageExists checks if an image exists locally
+	ImageExists(ctx context.Context, name string) (bool, error)
+
+	// ImageRemove removes an image tag, deleting the image once it has no tags left
+	ImageRemove(ctx context.Context, name string) error
+
+	// ImageTag adds a tag to an existing image
+	ImageTag(ctx context.Context, source string, target string) error
+
+	// NetworkConnect connects a container to a network under an alias
+	NetworkConnect(ctx context.Context, networkName string, containerName string, alias string) error
+
+	// NetworkDisconnect disconnects a container from a network
+	NetworkDisconnect(ctx context.Context, networkName string, containerName string) error
+
+	// NetworkExists checks if a network exists
+	NetworkExists(ctx context.Context, name string) (bool, error)
+
+	// VolumeCreate creates a named volume
+	VolumeCreate(ctx context.Context, name string, labels map[string]string) error
+
+	// VolumeExists checks if a named volume exists
+	VolumeExists(ctx context.Context, name string) (bool, error)
+
+	// VolumeRemove removes a named volume that is not in use
+	VolumeRemove(ctx context.Context, name string) error
+}
+
+const (
+	// RuntimeDocker selects the Docker Engine API runtime
+	RuntimeDocker = "docker"
+
+	// RuntimePodman selects the Podman runtime
+	RuntimePodman = "podman"
+)
+
+// IsDefaultNetwork returns whether a network is the default network of docker or podman,
+// which does not resolve container names, so containers on it can only reach each other via links
+func IsDefaultNetwork(name string) bool {
+	return name == "" || name == "bridge" || name == "default" || name == "podman"
+}
+
+// RuntimeEnvVar is the environment variable used to select the runtime
+const RuntimeEnvVar = "DOKKU_SERVICE_RUNTIME"
+
+// NewRuntimeInput contains the input parameters for the NewRuntime function
+type NewRuntimeInput struct {
+	// Host is the socket to connect to, defaulting to the runtime's standard location
+	Host string
+
+	// Name is the name of the runtime, defaulting to docker
+	Name string
+}
+
+// NewRuntime returns the runtime with the given name
+func NewRuntime(input NewRuntimeInput) (Runtime, error) {
+	switch input.Name {
+	case "", RuntimeDocker:
+		return NewDocker(NewDockerInput{Host: input.Host}), nil
+	case RuntimePodman:
+		return NewPodman(NewPodmanInput{Host: input.Host}), nil
+	}
+
+	return nil, fmt.Errorf("unknown container runtime %q, expected one of: %s, %s", input.Name, RuntimeDocker, RuntimePodman)
+}
+
+// Environment returns the environment variables selecting the runtime and its socket,
+// for processes started without the current environment such as scheduled jobs
+func Environment(runtime Runtime) map[string]string {
+	switch r := runtime.(type) {
+	case *Podman:
+		return map[string]string{
+			"CONTAINER_HOST": r.Docker.Host,
+			RuntimeEnvVar:    RuntimePodman,
+		}
+	case *Docker:
+		env := map[string]string{RuntimeEnvVar: RuntimeDocker}
+		for _, key := range []string{"DOCKER_API_VERSION", "DOCKER_CERT_PATH", "DOCKER_HOST", "DOCKER_TLS_VERIFY"} {
+			if value := os.Getenv(key); value != "" {
+				env[key] = value
+			}
+		}
+		if r.Host != "" {
+			env["DOCKER_HOST"] = r.Host
+		}
+		return env
+	}
+
+	return map[string]string{}
+}
+
+type runtimeKey struct{}
+
+// defaultRuntime is used when no runtime is carried by the context
+var defaultRuntime = sync.OnceValue(func() Runtime {
+	return NewDocker(NewDockerInput{})
+})
+
+// WithRuntime returns a copy of the context carrying the runtime
+func WithRuntime(ctx context.Context, runtime Runtime) context.Context {
+	return context.WithValue(ctx, runtimeKey{}, runtime)
+}
+
+// FromContext returns the runtime carried by the context, defaulting to the docker daemon from the environment
+func FromContext(ctx context.Context) Runtime {
+	if runtime, ok := ctx.Value(runtimeKey{}).(Runtime); ok {
+		return runtime
+	}
+
+	return defaultRuntime()
+}
+
+// Trace prints a runtime call to stderr when tracing is enabled
+func Trace(enabled bool, call string, args ...string) {
+	if enabled {
+		fmt.Fprintln(os.Stderr, "engine: ", call, strings.Join(args, " "))
+	}
+}
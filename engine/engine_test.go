@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewRuntime(t *testing.T) {
+	defaultPodmanSocket := "unix:///run/user/1000/podman/podman.sock"
+	if os.Geteuid() == 0 {
+		defaultPodmanSocket = "unix:///run/podman/podman.sock"
+	}
+
+	tests := []struct {
+		name          string
+		input         NewRuntimeInput
+		containerHost string
+		runtime       string
+		host          string
+		err           string
+	}{
+		{
+			name:    "defaults to docker",
+			runtime: RuntimeDocker,
+		},
+		{
+			name:    "selects docker",
+			input:   NewRuntimeInput{Name: RuntimeDocker, Host: "unix:///var/run/docker.sock"},
+			runtime: RuntimeDocker,
+			host:    "unix:///var/run/docker.sock",
+		},
+		{
+			name:    "selects podman with the default socket",
+			input:   NewRuntimeInput{Name: RuntimePodman},
+			runtime: RuntimePodman,
+			host:    defaultPodmanSocket,
+		},
+		{
+			name:          "selects podman with the CONTAINER_HOST socket",
+			input:         NewRuntimeInput{Name: RuntimePodman},
+			containerHost: "unix:///tmp/podman.sock",
+			runtime:       RuntimePodman,
+			host:          "unix:///tmp/podman.sock",
+		},
+		{
+			name:          "selects podman with an explicit host",
+			input:         NewRuntimeInput{Name: RuntimePodman, Host: "tcp://127.0.0.1:8888"},
+			containerHost: "unix:///tmp/podman.sock",
+			runtime:       RuntimePodman,
+			host:          "tcp://127.0.0.1:8888",
+		},
+		{
+			name:  "rejects an unknown runtime",
+			input: NewRuntimeInput{Name: "containerd"},
+			err:   `unknown container runtime "containerd", expected one of: docker, podman`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONTAINER_HOST", tt.containerHost)
+			t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+			runtime, err := NewRuntime(tt.input)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			name, host := "", ""
+			switch r := runtime.(type) {
+			case *Docker:
+				name, host = RuntimeDocker, r.Host
+			case *Podman:
+				name, host = RuntimePodman, r.Docker.Host
+			default:
+				name = fmt.Sprintf("%T", runtime)
+			}
+			if name != tt.runtime {
+				t.Errorf("expected %s runtime, got %s", tt.runtime, name)
+			}
+			if host != tt.host {
+				t.Errorf("expected host %q, got %q", tt.host, host)
+			}
+		})
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtime    Runtime
+		dockerHost string
+		env        map[string]string
+	}{
+		{
+			name:    "selects docker",
+			runtime: NewDocker(NewDockerInput{}),
+			env:     map[string]string{RuntimeEnvVar: RuntimeDocker},
+		},
+		{
+			name:       "carries DOCKER_HOST",
+			runtime:    NewDocker(NewDockerInput{}),
+			dockerHost: "tcp://10.0.0.1:2376",
+			env:        map[string]string{RuntimeEnvVar: RuntimeDocker, "DOCKER_HOST": "tcp://10.0.0.1:2376"},
+		},
+		{
+			name:       "prefers the docker host of the runtime",
+			runtime:    NewDocker(NewDockerInput{Host: "unix:///var/run/docker.sock"}),
+			dockerHost: "tcp://10.0.0.1:2376",
+			env:        map[string]string{RuntimeEnvVar: RuntimeDocker, "DOCKER_HOST": "unix:///var/run/docker.sock"},
+		},
+		{
+			name:    "selects podman and its socket",
+			runtime: NewPodman(NewPodmanInput{Host: "unix:///run/podman/podman.sock"}),
+			env:     map[string]string{RuntimeEnvVar: RuntimePodman, "CONTAINER_HOST": "unix:///run/podman/podman.sock"},
+		},
+		{
+			name:    "is empty for other runtimes",
+			runtime: NewFake(),
+			env:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_API_VERSION", "")
+			t.Setenv("DOCKER_CERT_PATH", "")
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			t.Setenv("DOCKER_TLS_VERIFY", "")
+
+			env := Environment(tt.runtime)
+			if !maps.Equal(env, tt.env) {
+				t.Errorf("expected environment %v, got %v", tt.env, env)
+			}
+		})
+	}
+}
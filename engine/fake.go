@@ -0,0 +1,506 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types/build"
+)
+
+var _ Runtime = (*Fake)(nil)
+
+// Fake is an in-memory runtime that records calls and simulates
+// container, image, network and volume state, for use in tests
+type Fake struct {
+	// Calls are the runtime calls made, in order, in the form "Method arg1 arg2"
+	Calls []string
+
+	// Containers maps container names to their state
+	Containers map[string]*FakeContainer
+
+	// Errors maps method names to an error to return instead of calling the method
+	Errors map[string]error
+
+	// ExecHandler is called for ContainerExec, defaulting to exiting successfully without output
+	ExecHandler func(name string, options ExecOptions) error
+
+	// Images maps image names to whether they exist
+	Images map[string]bool
+
+	// Networks maps network names to whether they exist
+	Networks map[string]bool
+
+	// RunHandler is called for ContainerRun, defaulting to exiting successfully without output
+	RunHandler func(spec ContainerSpec, stdout io.Writer, stderr io.Writer) error
+
+	// Volumes maps volume names to their labels
+	Volumes map[string]map[string]string
+
+	mu      sync.Mutex
+	counter int
+}
+
+// FakeContainer is the state of a container in a Fake runtime
+type FakeContainer struct {
+	Container
+
+	// Logs are the logs written by ContainerLogs
+	Logs string
+
+	// Spec is the spec the container was created from
+	Spec ContainerSpec
+}
+
+// NewFake returns an empty in-memory runtime with the default bridge network
+func NewFake() *Fake {
+	return &Fake{
+		Containers: map[string]*FakeContainer{},
+		Errors:     map[string]error{},
+		Images:     map[string]bool{},
+		Networks:   map[string]bool{"bridge": true},
+		Volumes:    map[string]map[string]string{},
+	}
+}
+
+// HasCall returns whether a call matching "Method arg1 arg2" was made
+func (f *Fake) HasCall(call string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, c := range f.Calls {
+		if c == call {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainerNames returns the sorted names of all containers
+func (f *Fake) ContainerNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := []string{}
+	for name := range f.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// record records a call and returns the error registered for the method, if any
+func (f *Fake) record(method string, args ...string) error {
+	f.Calls = append(f.Calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	return f.Errors[method]
+}
+
+// container returns a container by name or id
+func (f *Fake) container(name string) (*FakeContainer, error) {
+	if c, ok := f.Containers[name]; ok {
+		return c, nil
+	}
+	for _, c := range f.Containers {
+		if c.ID == name {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no such container: %s", ErrNotFound, name)
+}
+
+// ipAddress returns the next fake ip address
+func (f *Fake) ipAddress() string {
+	f.counter++
+	return fmt.Sprintf("172.17.%d.%d", f.counter/250, f.counter%250+2)
+}
+
+// ContainerCreate creates a container, pulling its image if it does not exist locally
+func (f *Fake) ContainerCreate(ctx context.Context, spec ContainerSpec) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerCreate", spec.Name); err != nil {
+		return "", err
+	}
+	_, hostConfig, _, err := containerConfig(spec)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := f.Containers[spec.Name]; ok && spec.Name != "" {
+		return "", fmt.Errorf("conflict: container name %s is already in use", spec.Name)
+	}
+
+	// the network may be set by the spec or overridden by a --network create flag
+	networkName := string(hostConfig.NetworkMode)
+	if networkName == "" {
+		networkName = "bridge"
+	}
+	if !f.Networks[networkName] {
+		return "", fmt.Errorf("%w: network %s not found", ErrNotFound, networkName)
+	}
+
+	f.Images[spec.Image] = true
+	ipAddress := f.ipAddress()
+	labels := map[string]string{}
+	for key, value := range spec.Labels {
+		labels[key] = value
+	}
+
+	id := fmt.Sprintf("fake%08d", f.counter)
+	c := &FakeContainer{
+		Container: Container{
+			ID:        id,
+			Image:     spec.Image,
+			IPAddress: ipAddress,
+			Labels:    labels,
+			Name:      spec.Name,
+			Networks:  map[string]string{networkName: ipAddress},
+			Status:    "created",
+		},
+		Spec: spec,
+	}
+	if c.Name == "" {
+		c.Name = id
+	}
+	f.Containers[c.Name] = c
+
+	return id, nil
+}
+
+// ContainerExec executes a command in a running container, returning an ExitError if it exits non-zero
+func (f *Fake) ContainerExec(ctx context.Context, name string, options ExecOptions) error {
+	f.mu.Lock()
+	if err := f.record("ContainerExec", append([]string{name}, options.Command...)...); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	c, err := f.container(name)
+	if err == nil && !c.Running {
+		err = fmt.Errorf("container %s is not running", name)
+	}
+	handler := f.ExecHandler
+	f.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if handler == nil {
+		return nil
+	}
+
+	return handler(name, options)
+}
+
+// ContainerInspect returns the state of a container
+func (f *Fake) ContainerInspect(ctx context.Context, name string) (Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerInspect", name); err != nil {
+		return Container{}, err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return Container{}, err
+	}
+
+	inspected := c.Container
+	inspected.Labels = map[string]string{}
+	for key, value := range c.Labels {
+		inspected.Labels[key] = value
+	}
+	inspected.Networks = map[string]string{}
+	for key, value := range c.Networks {
+		inspected.Networks[key] = value
+	}
+
+	return inspected, nil
+}
+
+// ContainerLogs writes the logs of a container to the writers in the options
+func (f *Fake) ContainerLogs(ctx context.Context, name string, options LogsOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerLogs", name); err != nil {
+		return err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(options.Stdout, c.Logs)
+	return err
+}
+
+// ContainerRemove removes a stopped container
+func (f *Fake) ContainerRemove(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerRemove", name); err != nil {
+		return err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return err
+	}
+	if c.Running {
+		return fmt.Errorf("cannot remove running container %s", name)
+	}
+
+	delete(f.Containers, c.Name)
+	return nil
+}
+
+// ContainerRename renames a container
+func (f *Fake) ContainerRename(ctx context.Context, name string, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerRename", name, newName); err != nil {
+		return err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Containers[newName]; ok {
+		return fmt.Errorf("conflict: container name %s is already in use", newName)
+	}
+
+	delete(f.Containers, c.Name)
+	c.Name = newName
+	f.Containers[newName] = c
+	return nil
+}
+
+// ContainerRun creates and starts a container, waits for it to exit and removes it,
+// returning an ExitError if it exits non-zero
+func (f *Fake) ContainerRun(ctx context.Context, spec ContainerSpec, stdout io.Writer, stderr io.Writer) error {
+	f.mu.Lock()
+	if err := f.record("ContainerRun", append([]string{spec.Image}, spec.Command...)...); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	for _, link := range spec.Links {
+		name, _, _ := strings.Cut(link, ":")
+		if _, err := f.container(name); err != nil {
+			f.mu.Unlock()
+			return err
+		}
+	}
+	f.Images[spec.Image] = true
+	handler := f.RunHandler
+	f.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+
+	return handler(spec, stdout, stderr)
+}
+
+// ContainerStart starts a container
+func (f *Fake) ContainerStart(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerStart", name); err != nil {
+		return err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return err
+	}
+
+	if !c.Running {
+		f.counter++
+		c.Pid = 1000 + f.counter
+	}
+	c.Running = true
+	c.Status = "running"
+	return nil
+}
+
+// ContainerStop stops a container
+func (f *Fake) ContainerStop(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ContainerStop", name); err != nil {
+		return err
+	}
+	c, err := f.container(name)
+	if err != nil {
+		return err
+	}
+
+	c.Pid = 0
+	c.Running = false
+	c.Status = "exited"
+	return nil
+}
+
+// ImageBuild builds an image from a context directory
+func (f *Fake) ImageBuild(ctx context.Context, options BuildOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ImageBuild", options.Tag); err != nil {
+		return err
+	}
+	if err := applyBuildFlags(options.Flags, &build.ImageBuildOptions{}); err != nil {
+		return err
+	}
+
+	f.Images[options.Tag] = true
+	return nil
+}
+
+// ImageExists checks if an image exists locally
+func (f *Fake) ImageExists(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ImageExists", name); err != nil {
+		return false, err
+	}
+
+	return f.Images[name], nil
+}
+
+// ImageRemove removes an image tag, deleting the image once it has no tags left
+func (f *Fake) ImageRemove(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ImageRemove", name); err != nil {
+		return err
+	}
+	if !f.Images[name] {
+		return fmt.Errorf("%w: no such image: %s", ErrNotFound, name)
+	}
+
+	delete(f.Images, name)
+	return nil
+}
+
+// ImageTag adds a tag to an existing image
+func (f *Fake) ImageTag(ctx context.Context, source string, target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ImageTag", source, target); err != nil {
+		return err
+	}
+	if !f.Images[source] {
+		return fmt.Errorf("%w: no such image: %s", ErrNotFound, source)
+	}
+
+	f.Images[target] = true
+	return nil
+}
+
+// NetworkConnect connects a container to a network under an alias
+func (f *Fake) NetworkConnect(ctx context.Context, networkName string, containerName string, alias string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("NetworkConnect", networkName, containerName); err != nil {
+		return err
+	}
+	if !f.Networks[networkName] {
+		return fmt.Errorf("%w: network %s not found", ErrNotFound, networkName)
+	}
+	c, err := f.container(containerName)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Networks[networkName]; ok {
+		return fmt.Errorf("container %s is already connected to network %s", containerName, networkName)
+	}
+
+	c.Networks[networkName] = f.ipAddress()
+	return nil
+}
+
+// NetworkDisconnect disconnects a container from a network
+func (f *Fake) NetworkDisconnect(ctx context.Context, networkName string, containerName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("NetworkDisconnect", networkName, containerName); err != nil {
+		return err
+	}
+	c, err := f.container(containerName)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Networks[networkName]; !ok {
+		return fmt.Errorf("container %s is not connected to network %s", containerName, networkName)
+	}
+
+	delete(c.Networks, networkName)
+	return nil
+}
+
+// NetworkExists checks if a network exists
+func (f *Fake) NetworkExists(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("NetworkExists", name); err != nil {
+		return false, err
+	}
+
+	return f.Networks[name], nil
+}
+
+// VolumeCreate creates a named volume
+func (f *Fake) VolumeCreate(ctx context.Context, name string, labels map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("VolumeCreate", name); err != nil {
+		return err
+	}
+
+	f.Volumes[name] = labels
+	return nil
+}
+
+// VolumeExists checks if a named volume exists
+func (f *Fake) VolumeExists(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("VolumeExists", name); err != nil {
+		return false, err
+	}
+
+	_, ok := f.Volumes[name]
+	return ok, nil
+}
+
+// VolumeRemove removes a named volume
+func (f *Fake) VolumeRemove(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("VolumeRemove", name); err != nil {
+		return err
+	}
+	if _, ok := f.Volumes[name]; !ok {
+		return fmt.Errorf("%w: no such volume: %s", ErrNotFound, name)
+	}
+
+	delete(f.Volumes, name)
+	return nil
+}
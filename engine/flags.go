@@ -0,0 +1,354 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
+	flag "github.com/spf13/pflag"
+	"mvdan.cc/sh/v3/shell"
+)
+
+// splitFlags splits shell-quoted flag strings into individual arguments
+func splitFlags(flags []string) ([]string, error) {
+	args := []string{}
+	for _, value := range flags {
+		fields, err := shell.Fields(value, nil)
+		if err != nil {
+			return args, fmt.Errorf("invalid flag %q: %w", value, err)
+		}
+		args = append(args, fields...)
+	}
+
+	return args, nil
+}
+
+// ValidateCreateFlags checks that docker cli style container create flags are supported,
+// so flags stored in a service config can be rejected before an existing container is removed
+func ValidateCreateFlags(createFlags []string) error {
+	config := &container.Config{
+		ExposedPorts: nat.PortSet{},
+		Labels:       map[string]string{},
+	}
+	hostConfig := &container.HostConfig{
+		PortBindings: nat.PortMap{},
+	}
+
+	return applyCreateFlags(createFlags, config, hostConfig)
+}
+
+// applyCreateFlags applies docker cli style container create flags to a container config
+//
+// Only the subset of flags that map directly onto the Engine API is supported
+func applyCreateFlags(createFlags []string, config *container.Config, hostConfig *container.HostConfig) error {
+	args, err := splitFlags(createFlags)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	f := flag.NewFlagSet("container create", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	addHosts := f.StringArray("add-host", []string{}, "")
+	capAdd := f.StringArray("cap-add", []string{}, "")
+	capDrop := f.StringArray("cap-drop", []string{}, "")
+	cpuShares := f.Int64("cpu-shares", 0, "")
+	cpus := f.Float64("cpus", 0, "")
+	devices := f.StringArray("device", []string{}, "")
+	dns := f.StringArray("dns", []string{}, "")
+	entrypoint := f.String("entrypoint", "", "")
+	env := f.StringArrayP("env", "e", []string{}, "")
+	hostname := f.StringP("hostname", "h", "", "")
+	init := f.Bool("init", false, "")
+	labels := f.StringArrayP("label", "l", []string{}, "")
+	logDriver := f.String("log-driver", "", "")
+	logOpts := f.StringArray("log-opt", []string{}, "")
+	memory := f.StringP("memory", "m", "", "")
+	memorySwap := f.String("memory-swap", "", "")
+	mounts := f.StringArray("mount", []string{}, "")
+	network := f.String("network", "", "")
+	pidsLimit := f.Int64("pids-limit", 0, "")
+	privileged := f.Bool("privileged", false, "")
+	publish := f.StringArrayP("publish", "p", []string{}, "")
+	readOnly := f.Bool("read-only", false, "")
+	restart := f.String("restart", "", "")
+	securityOpts := f.StringArray("security-opt", []string{}, "")
+	shmSize := f.String("shm-size", "", "")
+	sysctls := f.StringArray("sysctl", []string{}, "")
+	tmpfs := f.StringArray("tmpfs", []string{}, "")
+	ulimits := f.StringArray("ulimit", []string{}, "")
+	user := f.StringP("user", "u", "", "")
+	volumes := f.StringArrayP("volume", "v", []string{}, "")
+	workdir := f.StringP("workdir", "w", "", "")
+	if err := f.Parse(args); err != nil {
+		return fmt.Errorf("unsupported container create flag: %w", err)
+	}
+	if f.NArg() > 0 {
+		return fmt.Errorf("unexpected container create argument: %s", f.Arg(0))
+	}
+
+	hostConfig.ExtraHosts = append(hostConfig.ExtraHosts, *addHosts...)
+	hostConfig.CapAdd = append(hostConfig.CapAdd, *capAdd...)
+	hostConfig.CapDrop = append(hostConfig.CapDrop, *capDrop...)
+	hostConfig.DNS = append(hostConfig.DNS, *dns...)
+	hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, *securityOpts...)
+	hostConfig.Binds = append(hostConfig.Binds, *volumes...)
+	config.Env = append(config.Env, *env...)
+
+	if f.Changed("cpu-shares") {
+		hostConfig.CPUShares = *cpuShares
+	}
+	if f.Changed("cpus") {
+		hostConfig.NanoCPUs = int64(*cpus * 1e9)
+	}
+	for _, device := range *devices {
+		mapping, err := parseDevice(device)
+		if err != nil {
+			return err
+		}
+		hostConfig.Devices = append(hostConfig.Devices, mapping)
+	}
+	if f.Changed("entrypoint") {
+		config.Entrypoint = strslice.StrSlice{*entrypoint}
+	}
+	if f.Changed("hostname") {
+		config.Hostname = *hostname
+	}
+	if f.Changed("init") {
+		hostConfig.Init = init
+	}
+	for _, label := range *labels {
+		key, value, _ := strings.Cut(label, "=")
+		config.Labels[key] = value
+	}
+	if f.Changed("log-driver") {
+		hostConfig.LogConfig.Type = *logDriver
+	}
+	for _, logOpt := range *logOpts {
+		key, value, ok := strings.Cut(logOpt, "=")
+		if !ok {
+			return fmt.Errorf("invalid log-opt %q, expected key=value", logOpt)
+		}
+		if hostConfig.LogConfig.Config == nil {
+			hostConfig.LogConfig.Config = map[string]string{}
+		}
+		hostConfig.LogConfig.Config[key] = value
+	}
+	if f.Changed("memory") {
+		if hostConfig.Memory, err = units.RAMInBytes(*memory); err != nil {
+			return fmt.Errorf("invalid memory %q: %w", *memory, err)
+		}
+	}
+	if f.Changed("memory-swap") {
+		if *memorySwap == "-1" {
+			hostConfig.MemorySwap = -1
+		} else if hostConfig.MemorySwap, err = units.RAMInBytes(*memorySwap); err != nil {
+			return fmt.Errorf("invalid memory-swap %q: %w", *memorySwap, err)
+		}
+	}
+	for _, value := range *mounts {
+		m, err := parseMount(value)
+		if err != nil {
+			return err
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, m)
+	}
+	if f.Changed("network") {
+		hostConfig.NetworkMode = container.NetworkMode(*network)
+	}
+	if f.Changed("pids-limit") {
+		hostConfig.PidsLimit = pidsLimit
+	}
+	if f.Changed("privileged") {
+		hostConfig.Privileged = *privileged
+	}
+	if len(*publish) > 0 {
+		exposedPorts, portBindings, err := nat.ParsePortSpecs(*publish)
+		if err != nil {
+			return fmt.Errorf("invalid publish %q: %w", strings.Join(*publish, " "), err)
+		}
+		for port := range exposedPorts {
+			config.ExposedPorts[port] = struct{}{}
+		}
+		for port, bindings := range portBindings {
+			hostConfig.PortBindings[port] = append(hostConfig.PortBindings[port], bindings...)
+		}
+	}
+	if f.Changed("read-only") {
+		hostConfig.ReadonlyRootfs = *readOnly
+	}
+	if f.Changed("restart") {
+		if hostConfig.RestartPolicy, err = parseRestartPolicy(*restart); err != nil {
+			return err
+		}
+	}
+	if f.Changed("shm-size") {
+		if hostConfig.ShmSize, err = units.RAMInBytes(*shmSize); err != nil {
+			return fmt.Errorf("invalid shm-size %q: %w", *shmSize, err)
+		}
+	}
+	for _, sysctl := range *sysctls {
+		key, value, ok := strings.Cut(sysctl, "=")
+		if !ok {
+			return fmt.Errorf("invalid sysctl %q, expected key=value", sysctl)
+		}
+		if hostConfig.Sysctls == nil {
+			hostConfig.Sysctls = map[string]string{}
+		}
+		hostConfig.Sysctls[key] = value
+	}
+	for _, mount := range *tmpfs {
+		path, options, _ := strings.Cut(mount, ":")
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = map[string]string{}
+		}
+		hostConfig.Tmpfs[path] = options
+	}
+	for _, value := range *ulimits {
+		ulimit, err := units.ParseUlimit(value)
+		if err != nil {
+			return fmt.Errorf("invalid ulimit %q: %w", value, err)
+		}
+		hostConfig.Ulimits = append(hostConfig.Ulimits, ulimit)
+	}
+	if f.Changed("user") {
+		config.User = *user
+	}
+	if f.Changed("workdir") {
+		config.WorkingDir = *workdir
+	}
+
+	return nil
+}
+
+// parseDevice parses a docker cli style device mapping such as /dev/fuse:/dev/fuse:rwm
+func parseDevice(value string) (container.DeviceMapping, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return container.DeviceMapping{}, fmt.Errorf("invalid device %q, expected host-path[:container-path[:permissions]]", value)
+	}
+
+	mapping := container.DeviceMapping{
+		CgroupPermissions: "rwm",
+		PathInContainer:   parts[0],
+		PathOnHost:        parts[0],
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		mapping.PathInContainer = parts[1]
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		mapping.CgroupPermissions = parts[2]
+	}
+
+	return mapping, nil
+}
+
+// parseMount parses a docker cli style mount such as type=bind,source=/data,target=/data,readonly
+func parseMount(value string) (mount.Mount, error) {
+	m := mount.Mount{
+		Type: mount.TypeVolume,
+	}
+	for _, option := range strings.Split(value, ",") {
+		key, optionValue, hasValue := strings.Cut(option, "=")
+		switch strings.ToLower(key) {
+		case "type":
+			m.Type = mount.Type(optionValue)
+		case "source", "src":
+			m.Source = optionValue
+		case "target", "destination", "dst":
+			m.Target = optionValue
+		case "readonly", "ro":
+			readOnly := true
+			if hasValue {
+				parsed, err := strconv.ParseBool(optionValue)
+				if err != nil {
+					return mount.Mount{}, fmt.Errorf("invalid mount %q: invalid readonly value %s", value, optionValue)
+				}
+				readOnly = parsed
+			}
+			m.ReadOnly = readOnly
+		default:
+			return mount.Mount{}, fmt.Errorf("invalid mount %q: unsupported option %s", value, key)
+		}
+	}
+
+	if m.Target == "" {
+		return mount.Mount{}, fmt.Errorf("invalid mount %q: missing target", value)
+	}
+
+	return m, nil
+}
+
+// parseRestartPolicy parses a docker cli style restart policy such as on-failure:3
+func parseRestartPolicy(value string) (container.RestartPolicy, error) {
+	name, retries, ok := strings.Cut(value, ":")
+	policy := container.RestartPolicy{
+		Name: container.RestartPolicyMode(name),
+	}
+	if ok {
+		count, err := strconv.Atoi(retries)
+		if err != nil {
+			return policy, fmt.Errorf("invalid restart policy %q: %w", value, err)
+		}
+		policy.MaximumRetryCount = count
+	}
+
+	return policy, nil
+}
+
+// applyBuildFlags applies docker cli style image build flags, given without their leading dashes, to build options
+func applyBuildFlags(buildFlags []string, options *build.ImageBuildOptions) error {
+	prefixed := []string{}
+	for _, buildFlag := range buildFlags {
+		prefixed = append(prefixed, "--"+buildFlag)
+	}
+
+	f := flag.NewFlagSet("image build", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	addHosts := f.StringArray("add-host", []string{}, "")
+	labels := f.StringArray("label", []string{}, "")
+	network := f.String("network", "", "")
+	noCache := f.Bool("no-cache", false, "")
+	platform := f.String("platform", "", "")
+	pull := f.Bool("pull", false, "")
+	shmSize := f.String("shm-size", "", "")
+	target := f.String("target", "", "")
+	if err := f.Parse(prefixed); err != nil {
+		return fmt.Errorf("unsupported image build flag: %w", err)
+	}
+	if f.NArg() > 0 {
+		return fmt.Errorf("unexpected image build argument: %s", f.Arg(0))
+	}
+
+	options.ExtraHosts = append(options.ExtraHosts, *addHosts...)
+	for _, label := range *labels {
+		key, value, _ := strings.Cut(label, "=")
+		if options.Labels == nil {
+			options.Labels = map[string]string{}
+		}
+		options.Labels[key] = value
+	}
+	options.NetworkMode = *network
+	options.NoCache = *noCache
+	options.Platform = *platform
+	options.PullParent = *pull
+	options.Target = *target
+	if f.Changed("shm-size") {
+		shm, err := units.RAMInBytes(*shmSize)
+		if err != nil {
+			return fmt.Errorf("invalid shm-size %q: %w", *shmSize, err)
+		}
+		options.ShmSize = shm
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var _ Runtime = (*Podman)(nil)
+
+// Podman is a runtime backed by the Docker-compatible API of the Podman service
+//
+// Podman does not support legacy container links, so links are resolved to
+// host entries pointing at the linked container's ip address instead
+type Podman struct {
+	*Docker
+}
+
+// NewPodmanInput contains the input parameters for the NewPodman function
+type NewPodmanInput struct {
+	// Host is the podman socket to connect to, defaulting to CONTAINER_HOST or the podman service socket
+	Host string
+}
+
+// NewPodman returns a runtime backed by the Podman service
+//
+// The podman service must be running, e.g. via `systemctl --user enable --now podman.socket`
+func NewPodman(input NewPodmanInput) *Podman {
+	host := input.Host
+	if host == "" {
+		host = os.Getenv("CONTAINER_HOST")
+	}
+	if host == "" {
+		host = podmanSocket()
+	}
+
+	return &Podman{Docker: NewDocker(NewDockerInput{Host: host})}
+}
+
+// podmanSocket returns the default podman service socket for the current user
+func podmanSocket() string {
+	if os.Geteuid() == 0 {
+		return "unix:///run/podman/podman.sock"
+	}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Geteuid())
+	}
+
+	return "unix://" + filepath.Join(runtimeDir, "podman", "podman.sock")
+}
+
+// ContainerCreate creates a container, pulling its image if it does not exist locally
+func (p *Podman) ContainerCreate(ctx context.Context, spec ContainerSpec) (string, error) {
+	spec, err := resolveLinks(ctx, p.Docker, spec)
+	if err != nil {
+		return "", err
+	}
+
+	return p.Docker.ContainerCreate(ctx, spec)
+}
+
+// ContainerRun creates and starts a container, waits for it to exit and removes it,
+// returning an ExitError if it exits non-zero
+func (p *Podman) ContainerRun(ctx context.Context, spec ContainerSpec, stdout io.Writer, stderr io.Writer) error {
+	spec, err := resolveLinks(ctx, p.Docker, spec)
+	if err != nil {
+		return err
+	}
+
+	return p.Docker.ContainerRun(ctx, spec, stdout, stderr)
+}
+
+// resolveLinks replaces the links of a container spec with host entries looked up
+// via the runtime, attaching the container to the linked container's network if none is set
+func resolveLinks(ctx context.Context, runtime Runtime, spec ContainerSpec) (ContainerSpec, error) {
+	if len(spec.Links) == 0 {
+		return spec, nil
+	}
+
+	createFlags := append([]string{}, spec.CreateFlags...)
+	for _, link := range spec.Links {
+		name, alias, ok := strings.Cut(link, ":")
+		if !ok {
+			alias = name
+		}
+
+		linked, err := runtime.ContainerInspect(ctx, name)
+		if err != nil {
+			return spec, fmt.Errorf("failed to resolve link to %s: %w", name, err)
+		}
+
+		ipAddress := ""
+		if spec.Network != "" {
+			ipAddress = linked.Networks[spec.Network]
+		} else {
+			networkNames := []string{}
+			for networkName := range linked.Networks {
+				networkNames = append(networkNames, networkName)
+			}
+			sort.Strings(networkNames)
+			for _, networkName := range networkNames {
+				if linked.Networks[networkName] != "" {
+					spec.Network = networkName
+					ipAddress = linked.Networks[networkName]
+					break
+				}
+			}
+		}
+		if ipAddress == "" {
+			ipAddress = linked.IPAddress
+		}
+		if ipAddress == "" {
+			return spec, fmt.Errorf("failed to resolve link to %s: container has no ip address", name)
+		}
+
+		createFlags = append(createFlags, fmt.Sprintf("--add-host %s:%s", alias, ipAddress))
+	}
+
+	spec.CreateFlags = createFlags
+	spec.Links = nil
+	return spec, nil
+}
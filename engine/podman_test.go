@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        ContainerSpec
+		containers  map[string]Container
+		inspectErr  error
+		createFlags []string
+		network     string
+		err         string
+	}{
+		{
+			name:        "leaves a spec without links unchanged",
+			spec:        ContainerSpec{Name: "app", CreateFlags: []string{"--hostname app"}},
+			createFlags: []string{"--hostname app"},
+		},
+		{
+			name: "resolves a link to a host entry for its alias",
+			spec: ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop:db"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {Networks: map[string]string{"bridge": "10.88.0.2"}},
+			},
+			createFlags: []string{"--add-host db:10.88.0.2"},
+			network:     "bridge",
+		},
+		{
+			name: "defaults the alias to the linked container name",
+			spec: ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {Networks: map[string]string{"bridge": "10.88.0.2"}},
+			},
+			createFlags: []string{"--add-host dokku.postgres.lollipop:10.88.0.2"},
+			network:     "bridge",
+		},
+		{
+			name: "uses the address on the network of the spec",
+			spec: ContainerSpec{Name: "app", Network: "backend", Links: []string{"dokku.postgres.lollipop:db"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {Networks: map[string]string{"backend": "10.89.0.5", "bridge": "10.88.0.2"}},
+			},
+			createFlags: []string{"--add-host db:10.89.0.5"},
+			network:     "backend",
+		},
+		{
+			name: "attaches to the first linked network by name",
+			spec: ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop:db"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {Networks: map[string]string{"podman": "10.88.0.2", "backend": "10.89.0.5", "empty": ""}},
+			},
+			createFlags: []string{"--add-host db:10.89.0.5"},
+			network:     "backend",
+		},
+		{
+			name: "falls back to the default network address",
+			spec: ContainerSpec{Name: "app", Network: "backend", Links: []string{"dokku.postgres.lollipop:db"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {IPAddress: "10.88.0.2", Networks: map[string]string{"bridge": "10.88.0.2"}},
+			},
+			createFlags: []string{"--add-host db:10.88.0.2"},
+			network:     "backend",
+		},
+		{
+			name: "keeps existing create flags and resolves each link",
+			spec: ContainerSpec{
+				Name:        "app",
+				CreateFlags: []string{"--hostname app"},
+				Links:       []string{"dokku.postgres.lollipop:db", "dokku.redis.lollipop:cache"},
+			},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {Networks: map[string]string{"bridge": "10.88.0.2"}},
+				"dokku.redis.lollipop":    {Networks: map[string]string{"bridge": "10.88.0.3"}},
+			},
+			createFlags: []string{"--hostname app", "--add-host db:10.88.0.2", "--add-host cache:10.88.0.3"},
+			network:     "bridge",
+		},
+		{
+			name: "fails when the linked container has no ip address",
+			spec: ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop:db"}},
+			containers: map[string]Container{
+				"dokku.postgres.lollipop": {},
+			},
+			err: "failed to resolve link to dokku.postgres.lollipop: container has no ip address",
+		},
+		{
+			name: "fails when the linked container does not exist",
+			spec: ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop:db"}},
+			err:  "failed to resolve link to dokku.postgres.lollipop: not found",
+		},
+		{
+			name:       "fails when the linked container cannot be inspected",
+			spec:       ContainerSpec{Name: "app", Links: []string{"dokku.postgres.lollipop:db"}},
+			inspectErr: errors.New("connection refused"),
+			err:        "failed to resolve link to dokku.postgres.lollipop: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := NewFake()
+			for name, c := range tt.containers {
+				c.Name = name
+				runtime.Containers[name] = &FakeContainer{Container: c}
+			}
+			if tt.inspectErr != nil {
+				runtime.Errors["ContainerInspect"] = tt.inspectErr
+			}
+
+			spec, err := resolveLinks(context.Background(), runtime, tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !slices.Equal(spec.CreateFlags, tt.createFlags) {
+				t.Errorf("expected create flags %v, got %v", tt.createFlags, spec.CreateFlags)
+			}
+			if spec.Network != tt.network {
+				t.Errorf("expected network %q, got %q", tt.network, spec.Network)
+			}
+			if len(spec.Links) != 0 {
+				t.Errorf("expected links to be removed, got %v", spec.Links)
+			}
+		})
+	}
+}
@@ -2,15 +2,14 @@ package healthcheck
 
 import (
 	"context"
+	"dokku-service/engine"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"time"
 
-	"github.com/alexellis/go-execute/v2"
 	retry "github.com/avast/retry-go"
-	"github.com/docker/docker/api/types"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -19,7 +18,7 @@ type ListeningCheckInput struct {
 	Attempts int
 
 	// Container is the container to check
-	Container types.ContainerJSON
+	Container engine.Container
 
 	// InitialNetwork is the network to use for the wait container
 	InitialNetwork string
@@ -82,39 +81,30 @@ func ListeningCheck(ctx context.Context, input ListeningCheckInput) error {
 }
 
 func _dockerlisteningCheck(ctx context.Context, input ListeningCheckInput, port int) error {
-	if !input.Container.State.Running {
+	if !input.Container.Running {
 		return errors.New("container state is not running")
 	}
 
-	if input.Container.State.Pid == 0 {
+	if input.Container.Pid == 0 {
 		return errors.New("container state is not running")
 	}
 
-	args := []string{"container", "run", "--rm", "--link", fmt.Sprintf("%s:%s", input.Container.Name, input.NetworkAlias)}
-	if input.InitialNetwork != "" {
-		args = append(args, "--network", input.InitialNetwork)
+	spec := engine.ContainerSpec{
+		Command: []string{"-c", fmt.Sprintf("%s:%d", input.NetworkAlias, port)},
+		Image:   input.WaitImage,
+		Links:   []string{fmt.Sprintf("%s:%s", input.Container.Name, input.NetworkAlias)},
+		Network: input.InitialNetwork,
 	}
 
-	args = append(args, input.WaitImage)
-	args = append(args, "-c", fmt.Sprintf("%s:%d", input.NetworkAlias, port))
-
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        args,
-		StreamStdio: false,
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
+	engine.Trace(input.Trace, "container run", input.WaitImage, strings.Join(spec.Command, " "))
+	err := engine.FromContext(ctx).ContainerRun(ctx, spec, io.Discard, io.Discard)
+	var exitErr *engine.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("container is not listening on port: %d", port)
 	}
-	result, err := cmd.Execute(ctx)
 	if err != nil {
 		return fmt.Errorf("error running dokku/wait on port: %d: %w", port, err)
 	}
 
-	if result.ExitCode != 0 {
-		return fmt.Errorf("container is not listening on port: %d", port)
-	}
-
 	return nil
 }
@@ -2,16 +2,16 @@ package hook
 
 import (
 	"context"
+	"dokku-service/engine"
 	"dokku-service/logstreamer"
+	"dokku-service/plan"
+	"dokku-service/service"
 	"dokku-service/template"
 	"dokku-service/volume"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // ExecuteInput contains the input parameters for the Execute function
@@ -44,62 +44,62 @@ func Execute(ctx context.Context, input ExecuteInput) error {
 		return nil
 	}
 
-	// todo: support a library for templates
-	hookPath := filepath.Join(input.Template.TemplatePath, "bin", input.Name)
-	hookPath, err := filepath.Abs(hookPath)
+	hookPath, err := filepath.Abs(input.Template.HookPath(input.Name))
 	if err != nil {
 		return fmt.Errorf("deriving absolute path to %s hook failed: %w", input.Name, err)
 	}
 
 	// todo: validate that hook is executable
-	if err := os.Chmod(hookPath, 0755); err != nil {
-		return fmt.Errorf("chmod failed: %w", err)
+	if plan.FromContext(ctx) == nil {
+		if err := os.Chmod(hookPath, 0755); err != nil {
+			return fmt.Errorf("chmod failed: %w", err)
+		}
 	}
 
 	serviceRoot := fmt.Sprintf("%s/%s/%s", input.DataRoot, input.Template.Name, input.ServiceName)
-	cmdArgs := []string{
-		"container",
-		"run",
-		"--rm",
-		"--volume",
-		fmt.Sprintf("%s:/usr/local/bin/hook", hookPath),
-		"--env-file",
-		fmt.Sprintf("%s/.env", serviceRoot),
+	env, err := service.ReadEnvFile(ctx, service.ReadEnvFileInput{
+		Path: fmt.Sprintf("%s/.env", serviceRoot),
+	})
+	if err != nil {
+		return fmt.Errorf("%s hook container for service failed: %w", input.Name, err)
 	}
 
-	for _, volume := range input.Volumes {
-		cmdArgs = append(cmdArgs, "--mount", volume.MountArgs)
-		cmdArgs = append(cmdArgs, "--env", fmt.Sprintf("VOLUME_%s=%s", volume.Alias, volume.ContainerPath))
+	spec := engine.ContainerSpec{
+		Command: []string{"/usr/local/bin/hook"},
+		Env:     env,
+		Image:   input.Template.Hooks.Image,
+		Mounts: []engine.Mount{
+			{
+				Source: hookPath,
+				Target: "/usr/local/bin/hook",
+				Type:   "bind",
+			},
+		},
 	}
 
-	cmdArgs = append(cmdArgs, input.Template.Hooks.Image, "/usr/local/bin/hook")
-	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        cmdArgs,
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stdout,
-		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stderr,
-		}),
+	for _, volume := range input.Volumes {
+		spec.Mounts = append(spec.Mounts, engine.Mount{
+			Source: volume.Source,
+			Target: volume.ContainerPath,
+			Type:   volume.MountType,
+		})
+		spec.Env = append(spec.Env, fmt.Sprintf("VOLUME_%s=%s", volume.Alias, volume.ContainerPath))
 	}
 
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
-	if err != nil {
+	var mu sync.Mutex
+	stdout := logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+		Mutex:  &mu,
+		Writer: os.Stdout,
+	})
+	stderr := logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+		Mutex:  &mu,
+		Writer: os.Stderr,
+	})
+
+	engine.Trace(input.Trace, "container run", input.Template.Hooks.Image, "/usr/local/bin/hook")
+	if err := engine.FromContext(ctx).ContainerRun(ctx, spec, stdout, stderr); err != nil {
 		return fmt.Errorf("%s hook container for service failed: %w", input.Name, err)
 	}
 
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
-	}
-
 	return nil
 }
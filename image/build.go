@@ -8,9 +8,8 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/alexellis/go-execute/v2"
-
 	"dokku-service/argument"
+	"dokku-service/engine"
 	"dokku-service/logstreamer"
 	"dokku-service/registry"
 	"dokku-service/template"
@@ -38,55 +37,51 @@ type BuildInput struct {
 
 // Build builds a Docker image
 func Build(ctx context.Context, input BuildInput) error {
-	dockerfilePath := filepath.Join(input.Template.TemplatePath, "Dockerfile")
-	cmdArgs := []string{
-		"image", "build",
-		"-f", dockerfilePath,
-		"-t", input.Name,
-	}
-
+	buildArgs := map[string]string{}
 	for _, argument := range input.Arguments {
-		if strings.HasSuffix(argument.Key, "_SECRET") {
+		if argument.Secret || strings.HasSuffix(argument.Key, "_SECRET") {
 			continue
 		}
 
-		cmdArgs = append(cmdArgs, "--build-arg")
-		cmdArgs = append(cmdArgs, fmt.Sprintf("%s=%s", argument.Key, argument.Value))
+		buildArgs[argument.Key] = argument.Value
 	}
 
-	for _, flag := range input.BuildFlags {
-		cmdArgs = append(cmdArgs, "--"+flag)
+	// templates parsed before the build file was recorded are always built from their Dockerfile
+	contextDirectory := input.Template.TemplatePath
+	dockerfilePath := input.Template.DockerfilePath
+	if dockerfilePath == "" {
+		dockerfilePath = "Dockerfile"
 	}
 
-	cmdArgs = append(cmdArgs, input.Template.TemplatePath)
+	if input.Template.GeneratedDockerfile {
+		generatedDirectory, err := os.MkdirTemp("", "dokku-service-build-")
+		if err != nil {
+			return fmt.Errorf("failed to create build context: %w", err)
+		}
+		defer os.RemoveAll(generatedDirectory)
+
+		if err := os.WriteFile(filepath.Join(generatedDirectory, dockerfilePath), []byte(template.GENERATED_DOCKERFILE), 0o644); err != nil {
+			return fmt.Errorf("failed to write generated Dockerfile: %w", err)
+		}
+		contextDirectory = generatedDirectory
+	}
 
 	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command:     "docker",
-		Args:        cmdArgs,
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
+	engine.Trace(input.Trace, "image build", "-t", input.Name, contextDirectory)
+	err := engine.FromContext(ctx).ImageBuild(ctx, engine.BuildOptions{
+		BuildArgs:        buildArgs,
+		ContextDirectory: contextDirectory,
+		Dockerfile:       dockerfilePath,
+		Flags:            input.BuildFlags,
+		Output: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
 			Mutex:  &mu,
 			Writer: os.Stdout,
 		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stderr,
-		}),
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+		Tag: input.Name,
+	})
 	if err != nil {
 		return fmt.Errorf("image build for service failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
-	}
-
 	return nil
 }
@@ -2,11 +2,8 @@ package image
 
 import (
 	"context"
+	"dokku-service/engine"
 	"fmt"
-	"os"
-	"strings"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // ExistsInput contains the input parameters for the Exists function
@@ -20,26 +17,11 @@ type ExistsInput struct {
 
 // Exists checks if a image exists
 func Exists(ctx context.Context, input ExistsInput) (bool, error) {
-	cmd := execute.ExecTask{
-		Command: "docker",
-		Args: []string{
-			"image", "inspect",
-			input.Name,
-		},
-		StreamStdio: false,
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+	engine.Trace(input.Trace, "image inspect", input.Name)
+	exists, err := engine.FromContext(ctx).ImageExists(ctx, input.Name)
 	if err != nil {
-		return false, fmt.Errorf("check for container existence failed: %w", err)
-	}
-
-	if res.ExitCode != 0 {
-		return false, nil
+		return false, fmt.Errorf("check for image existence failed: %w", err)
 	}
 
-	return true, nil
+	return exists, nil
 }
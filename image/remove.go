@@ -0,0 +1,26 @@
+package image
+
+import (
+	"context"
+	"dokku-service/engine"
+	"fmt"
+)
+
+// RemoveInput contains the input parameters for the Remove function
+type RemoveInput struct {
+	// Name of the image to remove
+	Name string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Remove removes an image tag, deleting the image if it has no other tags
+func Remove(ctx context.Context, input RemoveInput) error {
+	engine.Trace(input.Trace, "image rm", input.Name)
+	if err := engine.FromContext(ctx).ImageRemove(ctx, input.Name); err != nil {
+		return fmt.Errorf("failed to remove image: %w", err)
+	}
+
+	return nil
+}
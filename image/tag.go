@@ -0,0 +1,29 @@
+package image
+
+import (
+	"context"
+	"dokku-service/engine"
+	"fmt"
+)
+
+// TagInput contains the input parameters for the Tag function
+type TagInput struct {
+	// Source is the name of the image to tag
+	Source string
+
+	// Target is the name to tag the image as
+	Target string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Tag tags an image with a new name
+func Tag(ctx context.Context, input TagInput) error {
+	engine.Trace(input.Trace, "image tag", input.Source, input.Target)
+	if err := engine.FromContext(ctx).ImageTag(ctx, input.Source, input.Target); err != nil {
+		return fmt.Errorf("failed to tag image: %w", err)
+	}
+
+	return nil
+}
@@ -17,10 +17,14 @@ type NewLogstreamerInput struct {
 	Writer        io.Writer
 	Mutex         *sync.Mutex
 	DisablePrefix bool
+	Prefix        string
 }
 
 func NewLogstreamer(input NewLogstreamerInput) *Logstreamer {
 	prefix := "       "
+	if input.Prefix != "" {
+		prefix = input.Prefix
+	}
 	if input.DisablePrefix {
 		prefix = ""
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"dokku-service/commands"
+	"dokku-service/engine"
 
 	"github.com/josegonzalez/cli-skeleton/command"
 	"github.com/mitchellh/cli"
@@ -23,7 +24,15 @@ func main() {
 
 // Executes the specified subcommand
 func Run(args []string) int {
-	ctx := context.Background()
+	runtime, err := engine.NewRuntime(engine.NewRuntimeInput{
+		Name: os.Getenv(engine.RuntimeEnvVar),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing CLI: %s\n", err.Error())
+		return 1
+	}
+
+	ctx := engine.WithRuntime(context.Background(), runtime)
 	commandMeta := command.SetupRun(ctx, AppName, Version, args)
 	commandMeta.Ui = command.HumanZerologUiWithFields(commandMeta.Ui, make(map[string]interface{}, 0))
 	c := cli.NewCLI(AppName, Version)
@@ -41,6 +50,36 @@ func Run(args []string) int {
 // Returns a list of implemented commands
 func Commands(ctx context.Context, meta command.Meta) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
+		"app-links": func() (cli.Command, error) {
+			return &commands.AppLinksCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup": func() (cli.Command, error) {
+			return &commands.ServiceBackupCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-auth": func() (cli.Command, error) {
+			return &commands.ServiceBackupAuthCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-deauth": func() (cli.Command, error) {
+			return &commands.ServiceBackupDeauthCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-prune": func() (cli.Command, error) {
+			return &commands.ServiceBackupPruneCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-schedule": func() (cli.Command, error) {
+			return &commands.ServiceBackupScheduleCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-set-encryption": func() (cli.Command, error) {
+			return &commands.ServiceBackupSetEncryptionCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-unset-encryption": func() (cli.Command, error) {
+			return &commands.ServiceBackupUnsetEncryptionCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-backup-unschedule": func() (cli.Command, error) {
+			return &commands.ServiceBackupUnscheduleCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-clone": func() (cli.Command, error) {
+			return &commands.ServiceCloneCommand{Meta: meta, Context: ctx}, nil
+		},
 		"service-create": func() (cli.Command, error) {
 			return &commands.ServiceCreateCommand{Meta: meta, Context: ctx}, nil
 		},
@@ -59,9 +98,24 @@ func Commands(ctx context.Context, meta command.Meta) map[string]cli.CommandFact
 		"service-exists": func() (cli.Command, error) {
 			return &commands.ServiceExistsCommand{Meta: meta, Context: ctx}, nil
 		},
+		"service-expose": func() (cli.Command, error) {
+			return &commands.ServiceExposeCommand{Meta: meta, Context: ctx}, nil
+		},
 		"service-import": func() (cli.Command, error) {
 			return &commands.ServiceImportCommand{Meta: meta, Context: ctx}, nil
 		},
+		"service-info": func() (cli.Command, error) {
+			return &commands.ServiceInfoCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-link": func() (cli.Command, error) {
+			return &commands.ServiceLinkCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-linked": func() (cli.Command, error) {
+			return &commands.ServiceLinkedCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-links": func() (cli.Command, error) {
+			return &commands.ServiceLinksCommand{Meta: meta, Context: ctx}, nil
+		},
 		"service-list": func() (cli.Command, error) {
 			return &commands.ServiceListCommand{Meta: meta, Context: ctx}, nil
 		},
@@ -71,18 +125,45 @@ func Commands(ctx context.Context, meta command.Meta) map[string]cli.CommandFact
 		"service-pause": func() (cli.Command, error) {
 			return &commands.ServicePauseCommand{Meta: meta, Context: ctx}, nil
 		},
+		"service-promote": func() (cli.Command, error) {
+			return &commands.ServicePromoteCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-restart": func() (cli.Command, error) {
+			return &commands.ServiceRestartCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-rotate-secret": func() (cli.Command, error) {
+			return &commands.ServiceRotateSecretCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-set": func() (cli.Command, error) {
+			return &commands.ServiceSetCommand{Meta: meta, Context: ctx}, nil
+		},
 		"service-start": func() (cli.Command, error) {
 			return &commands.ServiceStartCommand{Meta: meta, Context: ctx}, nil
 		},
 		"service-stop": func() (cli.Command, error) {
 			return &commands.ServiceStopCommand{Meta: meta, Context: ctx}, nil
 		},
+		"service-unexpose": func() (cli.Command, error) {
+			return &commands.ServiceUnexposeCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-unlink": func() (cli.Command, error) {
+			return &commands.ServiceUnlinkCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-unset": func() (cli.Command, error) {
+			return &commands.ServiceUnsetCommand{Meta: meta, Context: ctx}, nil
+		},
+		"service-upgrade": func() (cli.Command, error) {
+			return &commands.ServiceUpgradeCommand{Meta: meta, Context: ctx}, nil
+		},
 		"template-info": func() (cli.Command, error) {
 			return &commands.TemplateInfoCommand{Meta: meta}, nil
 		},
 		"template-list": func() (cli.Command, error) {
 			return &commands.TemplateListCommand{Meta: meta}, nil
 		},
+		"template-validate": func() (cli.Command, error) {
+			return &commands.TemplateValidateCommand{Meta: meta}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{Meta: meta}, nil
 		},
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josegonzalez/cli-skeleton/command"
+	"github.com/mitchellh/cli"
+	flag "github.com/spf13/pflag"
+)
+
+// TestCommandFlagSets checks that every command defines each of its flags once,
+// as pflag panics when a flag is redefined
+func TestCommandFlagSets(t *testing.T) {
+	meta := command.Meta{Ui: cli.NewMockUi()}
+	for name, factory := range Commands(context.Background(), meta) {
+		t.Run(name, func(t *testing.T) {
+			c, err := factory()
+			if err != nil {
+				t.Fatalf("failed to create command: %s", err)
+			}
+
+			flagSetCommand, ok := c.(interface{ FlagSet() *flag.FlagSet })
+			if !ok {
+				return
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("failed to define flags: %v", r)
+				}
+			}()
+			flagSetCommand.FlagSet()
+		})
+	}
+}
@@ -12,9 +12,16 @@ type AliasInput struct {
 
 	// ServiceType is the type of service to connect to the network
 	ServiceType string
+
+	// Sidecar is the name of a sidecar of the service, or empty for the service container
+	Sidecar string
 }
 
 // Alias creates a network alias for a container
 func Alias(input AliasInput) string {
+	if input.Sidecar != "" {
+		return fmt.Sprintf("dokku.%s.%s.%s", input.ServiceType, slug.Make(input.ServiceName), input.Sidecar)
+	}
+
 	return fmt.Sprintf("dokku.%s.%s", input.ServiceType, slug.Make(input.ServiceName))
 }
@@ -2,11 +2,8 @@ package network
 
 import (
 	"context"
+	"dokku-service/engine"
 	"fmt"
-	"os"
-	"strings"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // ConnectInput contains the input parameters for the Connect function
@@ -26,28 +23,10 @@ type ConnectInput struct {
 
 // Connect connects a container to a network
 func Connect(ctx context.Context, input ConnectInput) error {
-	cmd := execute.ExecTask{
-		Command: "docker",
-		Args: []string{
-			"network", "connect",
-			"--alias", input.NetworkAlias,
-			input.NetworkName,
-			input.ContainerName,
-		},
-		StreamStdio: false,
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
-	if err != nil {
+	engine.Trace(input.Trace, "network connect", "--alias", input.NetworkAlias, input.NetworkName, input.ContainerName)
+	if err := engine.FromContext(ctx).NetworkConnect(ctx, input.NetworkName, input.ContainerName, input.NetworkAlias); err != nil {
 		return fmt.Errorf("network connect failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		return fmt.Errorf("network connect failed: %s", res.Stderr)
-	}
-
 	return nil
 }
@@ -0,0 +1,29 @@
+package network
+
+import (
+	"context"
+	"dokku-service/engine"
+	"fmt"
+)
+
+// DisconnectInput contains the input parameters for the Disconnect function
+type DisconnectInput struct {
+	// ContainerName is the name of the container to disconnect from the network
+	ContainerName string
+
+	// NetworkName is the name of the network to disconnect from
+	NetworkName string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Disconnect disconnects a container from a network
+func Disconnect(ctx context.Context, input DisconnectInput) error {
+	engine.Trace(input.Trace, "network disconnect", input.NetworkName, input.ContainerName)
+	if err := engine.FromContext(ctx).NetworkDisconnect(ctx, input.NetworkName, input.ContainerName); err != nil {
+		return fmt.Errorf("network disconnect failed: %w", err)
+	}
+
+	return nil
+}
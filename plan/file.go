@@ -0,0 +1,112 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MkdirAll creates a directory and any missing parents,
+// or records creating it when a plan is being built
+func MkdirAll(ctx context.Context, path string, perm os.FileMode) error {
+	p := FromContext(ctx)
+	if p == nil {
+		return os.MkdirAll(path, perm)
+	}
+
+	path = filepath.Clean(path)
+	if info, err := os.Stat(path); err == nil && info.IsDir() && !p.isRemoved(path) {
+		return nil
+	}
+
+	p.Add("directory create", path, nil)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.removed, path)
+	return nil
+}
+
+// ReadFile reads a file, returning the planned contents if the file was written by the plan
+func ReadFile(ctx context.Context, path string) ([]byte, error) {
+	p := FromContext(ctx)
+	if p == nil {
+		return os.ReadFile(path)
+	}
+
+	path = filepath.Clean(path)
+	p.mu.Lock()
+	data, ok := p.files[path]
+	p.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	if p.isRemoved(path) {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	return os.ReadFile(path)
+}
+
+// RemoveAll removes a path and any children, or records removing it when a plan is being built
+func RemoveAll(ctx context.Context, path string) error {
+	p := FromContext(ctx)
+	if p == nil {
+		return os.RemoveAll(path)
+	}
+
+	path = filepath.Clean(path)
+	_, err := os.Stat(path)
+	p.mu.Lock()
+	_, planned := p.files[path]
+	p.mu.Unlock()
+	if (errors.Is(err, fs.ErrNotExist) && !planned) || p.isRemoved(path) {
+		return nil
+	}
+
+	p.Add("path remove", path, nil)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for file := range p.files {
+		if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+			delete(p.files, file)
+		}
+	}
+	p.removed[path] = true
+	return nil
+}
+
+// WriteFile writes a file, or records writing it when a plan is being built
+func WriteFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	p := FromContext(ctx)
+	if p == nil {
+		return os.WriteFile(path, data, perm)
+	}
+
+	path = filepath.Clean(path)
+	p.Add("file write", path, map[string]any{
+		"size": fmt.Sprintf("%d bytes", len(data)),
+	})
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[path] = data
+	delete(p.removed, path)
+	return nil
+}
+
+// isRemoved returns whether a path or one of its parents was removed by the plan
+func (p *Plan) isRemoved(path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for removed := range p.removed {
+		if path == removed || strings.HasPrefix(path, removed+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,222 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces secret values in a rendered plan
+const redacted = "***"
+
+// Step is a single change a command would make
+type Step struct {
+	// Action is the kind of change, such as "container create" or "file write"
+	Action string `json:"action"`
+
+	// Target is the name of the object being changed
+	Target string `json:"target"`
+
+	// Details are the options the change is made with
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Plan is an ordered list of the changes a command would make
+type Plan struct {
+	// Steps are the planned changes, in order
+	Steps []Step `json:"steps"`
+
+	files   map[string][]byte
+	mu      sync.Mutex
+	removed map[string]bool
+	secrets []string
+}
+
+// New returns an empty plan
+func New() *Plan {
+	return &Plan{
+		Steps:   []Step{},
+		files:   map[string][]byte{},
+		removed: map[string]bool{},
+	}
+}
+
+type planKey struct{}
+
+// WithPlan returns a context that records changes to the plan instead of making them
+func WithPlan(ctx context.Context, p *Plan) context.Context {
+	return context.WithValue(ctx, planKey{}, p)
+}
+
+// FromContext returns the plan changes are recorded to, or nil if changes should be made
+func FromContext(ctx context.Context) *Plan {
+	p, _ := ctx.Value(planKey{}).(*Plan)
+	return p
+}
+
+// Add records a change, omitting empty details
+func (p *Plan) Add(action string, target string, details map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	step := Step{
+		Action: action,
+		Target: target,
+	}
+	for key, value := range details {
+		if isEmpty(value) {
+			continue
+		}
+		if step.Details == nil {
+			step.Details = map[string]any{}
+		}
+		step.Details[key] = value
+	}
+
+	p.Steps = append(p.Steps, step)
+}
+
+// Redact hides values, such as generated passwords, wherever they appear in the rendered plan
+func (p *Plan) Redact(values ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, value := range values {
+		if value != "" {
+			p.secrets = append(p.secrets, value)
+		}
+	}
+}
+
+// JSON returns the plan as indented json with secret values redacted
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Steps []Step `json:"steps"`
+	}{
+		Steps: p.redactedSteps(),
+	}, "", "  ")
+}
+
+// Lines returns the plan as numbered human readable lines with secret values redacted
+func (p *Plan) Lines() []string {
+	steps := p.redactedSteps()
+	if len(steps) == 0 {
+		return []string{"No changes"}
+	}
+
+	lines := []string{}
+	for i, step := range steps {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%d. %s %s", i+1, step.Action, step.Target)))
+
+		keys := []string{}
+		for key := range step.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("   %s: %s", key, formatValue(step.Details[key])))
+		}
+	}
+
+	return lines
+}
+
+// redactedSteps returns a copy of the steps with secret values replaced
+func (p *Plan) redactedSteps() []Step {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// replace longer secrets first so a secret containing another is fully hidden
+	secrets := append([]string{}, p.secrets...)
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	redact := func(value string) string {
+		for _, secret := range secrets {
+			value = strings.ReplaceAll(value, secret, redacted)
+		}
+		return value
+	}
+
+	steps := []Step{}
+	for _, step := range p.Steps {
+		redactedStep := Step{
+			Action: step.Action,
+			Target: redact(step.Target),
+		}
+		if step.Details != nil {
+			redactedStep.Details = map[string]any{}
+			for key, value := range step.Details {
+				redactedStep.Details[key] = redactValue(value, redact)
+			}
+		}
+		steps = append(steps, redactedStep)
+	}
+
+	return steps
+}
+
+// redactValue applies a redaction to each string in a detail value
+func redactValue(value any, redact func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return redact(v)
+	case []string:
+		values := []string{}
+		for _, s := range v {
+			values = append(values, redact(s))
+		}
+		return values
+	case map[string]string:
+		values := map[string]string{}
+		for key, s := range v {
+			values[key] = redact(s)
+		}
+		return values
+	}
+
+	return value
+}
+
+// formatValue returns a detail value on a single line
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ", ")
+	case map[string]string:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := []string{}
+		for _, key := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, v[key]))
+		}
+		return strings.Join(pairs, ", ")
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// isEmpty returns whether a detail value has nothing to show
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	case map[string]string:
+		return len(v) == 0
+	case bool:
+		return !v
+	}
+
+	return false
+}
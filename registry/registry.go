@@ -16,6 +16,9 @@ type Registry struct {
 
 	// Vendored specifies if the registry is vendored
 	Vendored bool
+
+	// VendoredRegistryPath specifies the path the vendored registry is extracted to
+	VendoredRegistryPath string
 }
 
 // NewRegistryInput represents the input to the NewRegistry function
@@ -25,14 +28,19 @@ type NewRegistryInput struct {
 
 	// Vendored specifies if the registry is vendored
 	Vendored bool
+
+	// VendoredRegistryPath specifies the path the vendored registry is extracted to,
+	// used to resolve templates extending vendored templates
+	VendoredRegistryPath string
 }
 
 // NewRegistry creates a new Registry
 func NewRegistry(ctx context.Context, input NewRegistryInput) (Registry, error) {
 	r := Registry{
-		RegistryPath: input.RegistryPath,
-		Templates:    map[string]template.ServiceTemplate{},
-		Vendored:     input.Vendored,
+		RegistryPath:         input.RegistryPath,
+		Templates:            map[string]template.ServiceTemplate{},
+		Vendored:             input.Vendored,
+		VendoredRegistryPath: input.VendoredRegistryPath,
 	}
 	err := r.Parse(ctx)
 	if err != nil {
@@ -55,9 +63,10 @@ func (r *Registry) Parse(ctx context.Context) error {
 		}
 
 		template, err := template.NewServiceTemplate(ctx, template.NewServiceTemplateInput{
-			Name:             dirEntry.Name(),
-			RegistryPath:     r.RegistryPath,
-			VendoredRegistry: r.Vendored,
+			Name:                 dirEntry.Name(),
+			RegistryPath:         r.RegistryPath,
+			VendoredRegistry:     r.Vendored,
+			VendoredRegistryPath: r.VendoredRegistryPath,
 		})
 		if err != nil {
 			return err
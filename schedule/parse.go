@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Schedule represents a parsed five-field cron expression
+type Schedule struct {
+	// Minute is the minute field
+	Minute string
+
+	// Hour is the hour field
+	Hour string
+
+	// DayOfMonth is the day of month field
+	DayOfMonth string
+
+	// Month is the month field
+	Month string
+
+	// DayOfWeek is the day of week field
+	DayOfWeek string
+}
+
+// String returns the cron expression for the schedule
+func (s Schedule) String() string {
+	return strings.Join([]string{s.Minute, s.Hour, s.DayOfMonth, s.Month, s.DayOfWeek}, " ")
+}
+
+// field describes the bounds of a cron field
+type field struct {
+	// name is the name of the field used in error messages
+	name string
+
+	// min is the smallest allowed value
+	min int
+
+	// max is the largest allowed value
+	max int
+
+	// names maps value aliases to their numeric value
+	names map[string]int
+}
+
+var (
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day of month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dayOfWeekField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// macros maps the supported cron macros to their expanded expression
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses and validates a five-field cron expression
+func Parse(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if expanded, ok := macros[strings.ToLower(expression)]; ok {
+		expression = expanded
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", expression, len(fields))
+	}
+
+	for i, f := range []field{minuteField, hourField, dayOfMonthField, monthField, dayOfWeekField} {
+		if _, err := f.expand(fields[i]); err != nil {
+			return Schedule{}, fmt.Errorf("invalid schedule %q: %w", expression, err)
+		}
+	}
+
+	return Schedule{
+		Minute:     fields[0],
+		Hour:       fields[1],
+		DayOfMonth: fields[2],
+		Month:      fields[3],
+		DayOfWeek:  fields[4],
+	}, nil
+}
+
+// expand returns every value matched by a cron field
+func (f field) expand(value string) ([]int, error) {
+	values := []int{}
+	for _, part := range strings.Split(value, ",") {
+		rangeValue, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			var err error
+			rangeValue = before
+			step, err = strconv.Atoi(after)
+			if err != nil || step <= 0 {
+				return []int{}, fmt.Errorf("invalid %s step: %s", f.name, after)
+			}
+		}
+
+		start, end := f.min, f.max
+		if rangeValue != "*" {
+			var err error
+			before, after, isRange := strings.Cut(rangeValue, "-")
+			start, err = f.value(before)
+			if err != nil {
+				return []int{}, err
+			}
+
+			end = start
+			if isRange {
+				end, err = f.value(after)
+				if err != nil {
+					return []int{}, err
+				}
+			} else if step != 1 {
+				end = f.max
+			}
+		}
+
+		if start > end {
+			return []int{}, fmt.Errorf("invalid %s range: %s", f.name, rangeValue)
+		}
+
+		for i := start; i <= end; i += step {
+			values = append(values, i)
+		}
+	}
+
+	return values, nil
+}
+
+// value parses a single cron field value
+func (f field) value(value string) (int, error) {
+	if named, ok := f.names[strings.ToLower(value)]; ok {
+		return named, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value: %s", f.name, value)
+	}
+
+	if number < f.min || number > f.max {
+		return 0, fmt.Errorf("%s value %d out of range %d-%d", f.name, number, f.min, f.max)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+		err        string
+	}{
+		{
+			name:       "parses a five-field expression",
+			expression: "30 2 * * *",
+			expected:   "30 2 * * *",
+		},
+		{
+			name:       "expands macros",
+			expression: "@weekly",
+			expected:   "0 0 * * 0",
+		},
+		{
+			name:       "expands macros regardless of case",
+			expression: " @Daily ",
+			expected:   "0 0 * * *",
+		},
+		{
+			name:       "accepts lists, ranges and steps",
+			expression: "0,15,30,45 9-17 */2 1-12/3 mon-fri",
+			expected:   "0,15,30,45 9-17 */2 1-12/3 mon-fri",
+		},
+		{
+			name:       "accepts month and day names",
+			expression: "0 0 1 JAN,jul sun",
+			expected:   "0 0 1 JAN,jul sun",
+		},
+		{
+			name:       "accepts 7 as sunday",
+			expression: "0 0 * * 7",
+			expected:   "0 0 * * 7",
+		},
+		{
+			name:       "rejects an empty expression",
+			expression: "",
+			err:        "expected 5 fields, got 0",
+		},
+		{
+			name:       "rejects too few fields",
+			expression: "0 0 * *",
+			err:        "expected 5 fields, got 4",
+		},
+		{
+			name:       "rejects too many fields",
+			expression: "0 0 0 * * *",
+			err:        "expected 5 fields, got 6",
+		},
+		{
+			name:       "rejects an unknown macro",
+			expression: "@fortnightly",
+			err:        "expected 5 fields, got 1",
+		},
+		{
+			name:       "rejects a minute out of range",
+			expression: "60 0 * * *",
+			err:        "minute value 60 out of range 0-59",
+		},
+		{
+			name:       "rejects an hour out of range",
+			expression: "0 24 * * *",
+			err:        "hour value 24 out of range 0-23",
+		},
+		{
+			name:       "rejects a day of month out of range",
+			expression: "0 0 0 * *",
+			err:        "day of month value 0 out of range 1-31",
+		},
+		{
+			name:       "rejects a month out of range",
+			expression: "0 0 * 13 *",
+			err:        "month value 13 out of range 1-12",
+		},
+		{
+			name:       "rejects a day of week out of range",
+			expression: "0 0 * * 8",
+			err:        "day of week value 8 out of range 0-7",
+		},
+		{
+			name:       "rejects a value that is not a number or name",
+			expression: "0 0 * foo *",
+			err:        "invalid month value: foo",
+		},
+		{
+			name:       "rejects a name in a field without names",
+			expression: "0 mon * * *",
+			err:        "invalid hour value: mon",
+		},
+		{
+			name:       "rejects a zero step",
+			expression: "*/0 * * * *",
+			err:        "invalid minute step: 0",
+		},
+		{
+			name:       "rejects a step that is not a number",
+			expression: "*/x * * * *",
+			err:        "invalid minute step: x",
+		},
+		{
+			name:       "rejects a reversed range",
+			expression: "0 17-9 * * *",
+			err:        "invalid hour range: 17-9",
+		},
+		{
+			name:       "rejects an empty list entry",
+			expression: "0, 0 * * *",
+			err:        "invalid minute value: ",
+		},
+		{
+			name:       "rejects an empty range bound",
+			expression: "0 9- * * *",
+			err:        "invalid hour value: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expression)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if schedule.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, schedule.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// weekdays maps cron day of week values to systemd weekday names
+var weekdays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// OnCalendar converts a schedule to a systemd OnCalendar expression
+//
+// Cron runs a job when either the day of month or the day of week matches if
+// both are restricted, while systemd requires both to match, so such
+// schedules cannot be converted
+func OnCalendar(s Schedule) (string, error) {
+	if s.DayOfMonth != "*" && s.DayOfWeek != "*" {
+		return "", fmt.Errorf("schedules restricting both day of month and day of week are not supported by systemd")
+	}
+
+	minutes, err := calendarField(minuteField, s.Minute)
+	if err != nil {
+		return "", err
+	}
+	hours, err := calendarField(hourField, s.Hour)
+	if err != nil {
+		return "", err
+	}
+	days, err := calendarField(dayOfMonthField, s.DayOfMonth)
+	if err != nil {
+		return "", err
+	}
+	months, err := calendarField(monthField, s.Month)
+	if err != nil {
+		return "", err
+	}
+
+	calendar := fmt.Sprintf("*-%s-%s %s:%s:00", months, days, hours, minutes)
+	if s.DayOfWeek == "*" {
+		return calendar, nil
+	}
+
+	values, err := dayOfWeekField.expand(s.DayOfWeek)
+	if err != nil {
+		return "", err
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, value := range values {
+		if !seen[weekdays[value]] {
+			seen[weekdays[value]] = true
+			names = append(names, weekdays[value])
+		}
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(names, ","), calendar), nil
+}
+
+// calendarField converts a cron field to the equivalent systemd calendar component
+func calendarField(f field, value string) (string, error) {
+	if value == "*" {
+		return "*", nil
+	}
+
+	values, err := f.expand(value)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{}
+	for _, v := range values {
+		parts = append(parts, strconv.Itoa(v))
+	}
+
+	return strings.Join(parts, ","), nil
+}
@@ -0,0 +1,194 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// SchedulerCron writes schedule entries as cron.d files
+	SchedulerCron = "cron"
+
+	// SchedulerSystemd writes schedule entries as systemd service and timer units
+	SchedulerSystemd = "systemd"
+)
+
+// DefaultDirectory returns the directory schedule entries are written to by default for a scheduler
+func DefaultDirectory(scheduler string) string {
+	if scheduler == SchedulerSystemd {
+		return "/etc/systemd/system"
+	}
+
+	return "/etc/cron.d"
+}
+
+// WriteInput contains the input parameters for the Write function
+type WriteInput struct {
+	// Commands are the commands to run, in order, stopping at the first failure
+	Commands [][]string
+
+	// Description is a human readable description of the entry
+	Description string
+
+	// Environment are the environment variables to run the commands with
+	Environment map[string]string
+
+	// Directory is the directory to write the entry to
+	Directory string
+
+	// Name is the name of the entry
+	Name string
+
+	// Schedule is the schedule to run the commands on
+	Schedule Schedule
+
+	// Scheduler is the scheduler to write the entry for
+	Scheduler string
+
+	// User is the user to run the commands as
+	User string
+}
+
+// Write writes a schedule entry, returning the paths of the files written
+func Write(ctx context.Context, input WriteInput) ([]string, error) {
+	if len(input.Commands) == 0 {
+		return []string{}, fmt.Errorf("no commands specified")
+	}
+
+	files := map[string]string{}
+	switch input.Scheduler {
+	case SchedulerCron:
+		files[input.Name] = cronEntry(input)
+	case SchedulerSystemd:
+		onCalendar, err := OnCalendar(input.Schedule)
+		if err != nil {
+			return []string{}, err
+		}
+		files[input.Name+".service"] = systemdService(input)
+		files[input.Name+".timer"] = systemdTimer(input, onCalendar)
+	default:
+		return []string{}, fmt.Errorf("unsupported scheduler: %s", input.Scheduler)
+	}
+
+	if err := os.MkdirAll(input.Directory, 0o755); err != nil {
+		return []string{}, fmt.Errorf("failed to create schedule directory: %s", err.Error())
+	}
+
+	paths := []string{}
+	for name, contents := range files {
+		path := filepath.Join(input.Directory, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			return []string{}, fmt.Errorf("failed to write schedule entry: %s", err.Error())
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// RemoveInput contains the input parameters for the Remove function
+type RemoveInput struct {
+	// Directory is the directory the entry was written to
+	Directory string
+
+	// Name is the name of the entry
+	Name string
+
+	// Scheduler is the scheduler the entry was written for
+	Scheduler string
+}
+
+// Remove removes the files written for a schedule entry
+func Remove(ctx context.Context, input RemoveInput) error {
+	names := []string{input.Name}
+	if input.Scheduler == SchedulerSystemd {
+		names = []string{input.Name + ".service", input.Name + ".timer"}
+	}
+
+	for _, name := range names {
+		err := os.Remove(filepath.Join(input.Directory, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove schedule entry: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// cronEntry returns the contents of a cron.d file for a schedule entry
+func cronEntry(input WriteInput) string {
+	commands := []string{}
+	for _, command := range input.Commands {
+		// cron treats unescaped percent signs as newlines
+		commands = append(commands, strings.ReplaceAll(shellJoin(command), "%", `\%`))
+	}
+
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "# %s\n# managed by dokku-service, do not edit\nPATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n", input.Description)
+	for _, key := range environmentKeys(input.Environment) {
+		fmt.Fprintf(builder, "%s=%s\n", key, input.Environment[key])
+	}
+	fmt.Fprintf(builder, "%s %s %s\n", input.Schedule.String(), input.User, strings.Join(commands, " && "))
+
+	return builder.String()
+}
+
+// systemdService returns the contents of a systemd service unit for a schedule entry
+func systemdService(input WriteInput) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "# managed by dokku-service, do not edit\n[Unit]\nDescription=%s\n\n[Service]\nType=oneshot\nUser=%s\n", input.Description, input.User)
+	for _, key := range environmentKeys(input.Environment) {
+		// systemd expands percent specifiers and unquotes backslash escapes in Environment
+		fmt.Fprintf(builder, "Environment=\"%s\"\n", systemdEscaper.Replace(key+"="+input.Environment[key]))
+	}
+	for _, command := range input.Commands {
+		// systemd expands percent specifiers in ExecStart
+		fmt.Fprintf(builder, "ExecStart=%s\n", strings.ReplaceAll(shellJoin(command), "%", "%%"))
+	}
+
+	return builder.String()
+}
+
+// systemdTimer returns the contents of a systemd timer unit for a schedule entry
+func systemdTimer(input WriteInput, onCalendar string) string {
+	return fmt.Sprintf("# managed by dokku-service, do not edit\n[Unit]\nDescription=%s\n\n[Timer]\nOnCalendar=%s\nPersistent=true\n\n[Install]\nWantedBy=timers.target\n",
+		input.Description, onCalendar)
+}
+
+// systemdEscaper escapes a value for a double quoted systemd setting
+var systemdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%")
+
+// environmentKeys returns the keys of an environment in a stable order
+func environmentKeys(environment map[string]string) []string {
+	keys := []string{}
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// safeArgument matches arguments that do not need quoting
+var safeArgument = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellJoin joins a command into a string safe to pass to a shell
+func shellJoin(command []string) string {
+	quoted := []string{}
+	for _, arg := range command {
+		if safeArgument.MatchString(arg) {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"dokku-service/argument"
+	"dokku-service/plan"
 	"dokku-service/registry"
 	"dokku-service/template"
 	"encoding/json"
@@ -48,6 +49,9 @@ type RunConfig struct {
 	// EnvironmentVariables are the environment variables to pass to the service
 	EnvironmentVariables map[string]string `json:"env"`
 
+	// Expose is the configuration for ports exposed via an ambassador container
+	Expose ExposeConfig `json:"expose"`
+
 	// Image is the image to use for the service
 	Image RunImageConfig `json:"image"`
 
@@ -67,6 +71,24 @@ type RunConfig struct {
 	UseVolumes bool `json:"use_volumes"`
 }
 
+// ExposeConfig represents the configuration for ports exposed via an ambassador container
+type ExposeConfig struct {
+	// BindIP is the host ip to bind exposed ports to
+	BindIP string `json:"bind_ip"`
+
+	// Ports are the exposed port mappings
+	Ports []ExposedPort `json:"ports"`
+}
+
+// ExposedPort represents a service port published on the host
+type ExposedPort struct {
+	// ContainerPort is the port the service listens on
+	ContainerPort int `json:"container_port"`
+
+	// HostPort is the port published on the host
+	HostPort int `json:"host_port"`
+}
+
 // RunImageConfig represents the configuration for the image to run
 type RunImageConfig struct {
 	// Name is the name of the image
@@ -92,7 +114,7 @@ func Config(ctx context.Context, input ConfigInput) (ConfigOutput, error) {
 		return ConfigOutput{}, fmt.Errorf("%s service %s config not found", input.ServiceType, input.Name)
 	}
 
-	b, err := os.ReadFile(configPath)
+	b, err := plan.ReadFile(ctx, configPath)
 	if err != nil {
 		return ConfigOutput{}, fmt.Errorf("failed to read service config: %s", err.Error())
 	}
@@ -104,3 +126,24 @@ func Config(ctx context.Context, input ConfigInput) (ConfigOutput, error) {
 
 	return parsedServiceTemplate, nil
 }
+
+// WriteConfigInput contains the input parameters for the WriteConfig function
+type WriteConfigInput struct {
+	// ConfigOutput is the service config to persist
+	ConfigOutput ConfigOutput
+}
+
+// WriteConfig persists the config for a service to its service root
+func WriteConfig(ctx context.Context, input WriteConfigInput) error {
+	data, err := json.MarshalIndent(input.ConfigOutput, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal service config: %s", err.Error())
+	}
+
+	configPath := fmt.Sprintf("%s/config.json", input.ConfigOutput.Config.ServiceRoot)
+	if err := plan.WriteFile(ctx, configPath, data, 0o666); err != nil {
+		return fmt.Errorf("failed to write service config: %s", err.Error())
+	}
+
+	return nil
+}
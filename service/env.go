@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"dokku-service/plan"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WriteEnvFileInput contains the input parameters for the WriteEnvFile function
+type WriteEnvFileInput struct {
+	// ConfigOutput is the service config to write the environment variables of
+	ConfigOutput ConfigOutput
+}
+
+// WriteEnvFile writes the environment variables of a service to the .env file in its service root
+func WriteEnvFile(ctx context.Context, input WriteEnvFileInput) error {
+	keys := []string{}
+	for key := range input.ConfigOutput.Config.EnvironmentVariables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envLines := []string{}
+	for _, key := range keys {
+		envLines = append(envLines, fmt.Sprintf(`%s=%s`, key, input.ConfigOutput.Config.EnvironmentVariables[key]))
+	}
+
+	envFile := fmt.Sprintf("%s/.env", input.ConfigOutput.Config.ServiceRoot)
+	if err := plan.WriteFile(ctx, envFile, []byte(strings.Join(envLines, "\n")+"\n"), 0o666); err != nil {
+		return fmt.Errorf("failed to write service env file: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ReadEnvFileInput contains the input parameters for the ReadEnvFile function
+type ReadEnvFileInput struct {
+	// Path is the path to the env file to read
+	Path string
+}
+
+// ReadEnvFile reads KEY=VALUE lines from an env file, skipping blank lines and comments
+func ReadEnvFile(ctx context.Context, input ReadEnvFileInput) ([]string, error) {
+	b, err := plan.ReadFile(ctx, input.Path)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to read service env file: %w", err)
+	}
+
+	env := []string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		env = append(env, line)
+	}
+
+	return env, nil
+}
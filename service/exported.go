@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// ExportedVariablesInput contains the input parameters for the ExportedVariables function
+type ExportedVariablesInput struct {
+	// ConfigOutput is the service config to render the variables against
+	ConfigOutput ConfigOutput
+
+	// Hostname is the hostname the service is reachable at
+	Hostname string
+}
+
+// ExportedVariables renders the exported variables of a service template
+func ExportedVariables(ctx context.Context, input ExportedVariablesInput) (map[string]string, error) {
+	data := templateData(input.ConfigOutput, input.Hostname)
+	variables := map[string]string{}
+	for name, value := range input.ConfigOutput.Template.ExportedVariables {
+		tmpl, err := template.New("base").Funcs(sprig.FuncMap()).Parse(value)
+		if err != nil {
+			return map[string]string{}, fmt.Errorf("failed to parse exported variable %s template: %w", name, err)
+		}
+
+		builder := &strings.Builder{}
+		if err := tmpl.Execute(builder, data); err != nil {
+			return map[string]string{}, fmt.Errorf("failed to execute exported variable %s template: %w", name, err)
+		}
+
+		variables[name] = builder.String()
+	}
+
+	return variables, nil
+}
+
+// templateData returns the values available to templates rendered against a service,
+// where arguments are available both with and without the _SECRET suffix
+func templateData(config ConfigOutput, hostname string) map[string]string {
+	data := map[string]string{}
+	for _, argument := range config.Config.Arguments {
+		data[argument.Key] = argument.Value
+		data[strings.TrimSuffix(argument.Key, "_SECRET")] = argument.Value
+	}
+	for key, value := range config.Config.EnvironmentVariables {
+		data[key] = value
+	}
+	data["HOSTNAME"] = hostname
+
+	return data
+}
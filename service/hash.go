@@ -0,0 +1,49 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ConfigHashLabel is the container label holding the hash of the config the container was created from
+const ConfigHashLabel = "com.dokku.service-config-hash"
+
+// containerConfig is the subset of a RunConfig that requires recreating the container when changed
+type containerConfig struct {
+	Arguments            map[string]string `json:"arguments"`
+	ContainerCreateFlags []string          `json:"container_create_flags"`
+	EnvironmentVariables map[string]string `json:"env"`
+	Image                RunImageConfig    `json:"image"`
+	ImageBuildFlags      []string          `json:"image_build_flags"`
+	PostCreateNetworks   []string          `json:"post_create_networks"`
+	PostStartNetworks    []string          `json:"post_start_networks,omitempty"`
+	UseVolumes           bool              `json:"use_volumes"`
+}
+
+// ConfigHash returns a hash of the parts of a service config that the service container is created from
+func ConfigHash(config ConfigOutput) (string, error) {
+	arguments := map[string]string{}
+	for key, arg := range config.Config.Arguments {
+		arguments[key] = arg.Value
+	}
+
+	// json.Marshal sorts map keys, so the output is stable
+	data, err := json.Marshal(containerConfig{
+		Arguments:            arguments,
+		ContainerCreateFlags: config.Config.ContainerCreateFlags,
+		EnvironmentVariables: config.Config.EnvironmentVariables,
+		Image:                config.Config.Image,
+		ImageBuildFlags:      config.Config.ImageBuildFlags,
+		PostCreateNetworks:   config.Config.PostCreateNetworks,
+		PostStartNetworks:    config.Config.PostStartNetworks,
+		UseVolumes:           config.Config.UseVolumes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal service config: %s", err.Error())
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"dokku-service/plan"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+// Link represents a dokku app linked to a service
+type Link struct {
+	// App is the name of the linked app
+	App string `json:"app"`
+
+	// Networks are the networks the service was attached to for the app
+	Networks []string `json:"networks"`
+
+	// Primary specifies whether the link owns the unprefixed exported variables on the app
+	Primary bool `json:"primary"`
+}
+
+// LinksInput contains the input parameters for the Links function
+type LinksInput struct {
+	// DataRoot is the root data directory
+	DataRoot string
+
+	// The name of the service
+	Name string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// Links returns the apps linked to a service
+func Links(ctx context.Context, input LinksInput) ([]Link, error) {
+	linksPath := fmt.Sprintf("%s/%s/%s/links.json", input.DataRoot, input.ServiceType, input.Name)
+	b, err := plan.ReadFile(ctx, linksPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Link{}, nil
+	}
+	if err != nil {
+		return []Link{}, fmt.Errorf("failed to read service links: %s", err.Error())
+	}
+
+	links := []Link{}
+	if err := json.Unmarshal(b, &links); err != nil {
+		return []Link{}, fmt.Errorf("failed to unmarshal service links: %s", err.Error())
+	}
+
+	return links, nil
+}
+
+// WriteLinksInput contains the input parameters for the WriteLinks function
+type WriteLinksInput struct {
+	// DataRoot is the root data directory
+	DataRoot string
+
+	// Links are the links to persist
+	Links []Link
+
+	// The name of the service
+	Name string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// WriteLinks persists the apps linked to a service
+func WriteLinks(ctx context.Context, input WriteLinksInput) error {
+	links := input.Links
+	if links == nil {
+		links = []Link{}
+	}
+
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal service links: %s", err.Error())
+	}
+
+	linksPath := fmt.Sprintf("%s/%s/%s/links.json", input.DataRoot, input.ServiceType, input.Name)
+	if err := plan.WriteFile(ctx, linksPath, data, 0o666); err != nil {
+		return fmt.Errorf("failed to write service links: %s", err.Error())
+	}
+
+	return nil
+}
+
+// LinkedServicesInput contains the input parameters for the LinkedServices function
+type LinkedServicesInput struct {
+	// AppName is the name of the app to search links for
+	AppName string
+
+	// DataRoot is the root data directory
+	DataRoot string
+
+	// ServiceType restricts the search to a single service type when set
+	ServiceType string
+}
+
+// LinkedService represents a service linked to an app
+type LinkedService struct {
+	// Link is the link record for the app
+	Link Link
+
+	// Name is the name of the service
+	Name string
+
+	// ServiceType is the type of service
+	ServiceType string
+}
+
+// LinkedServices returns every service linked to an app
+func LinkedServices(ctx context.Context, input LinkedServicesInput) ([]LinkedService, error) {
+	serviceTypes := []string{input.ServiceType}
+	if input.ServiceType == "" {
+		serviceTypes = []string{}
+		dirEntries, err := os.ReadDir(input.DataRoot)
+		if errors.Is(err, os.ErrNotExist) {
+			return []LinkedService{}, nil
+		}
+		if err != nil {
+			return []LinkedService{}, fmt.Errorf("failed to read data root: %s", err.Error())
+		}
+
+		for _, dirEntry := range dirEntries {
+			if dirEntry.IsDir() {
+				serviceTypes = append(serviceTypes, dirEntry.Name())
+			}
+		}
+	}
+
+	linkedServices := []LinkedService{}
+	for _, serviceType := range serviceTypes {
+		dirEntries, err := os.ReadDir(fmt.Sprintf("%s/%s", input.DataRoot, serviceType))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return []LinkedService{}, fmt.Errorf("failed to read services: %s", err.Error())
+		}
+
+		for _, dirEntry := range dirEntries {
+			if !dirEntry.IsDir() {
+				continue
+			}
+
+			links, err := Links(ctx, LinksInput{
+				DataRoot:    input.DataRoot,
+				Name:        dirEntry.Name(),
+				ServiceType: serviceType,
+			})
+			if err != nil {
+				return []LinkedService{}, err
+			}
+
+			for _, link := range links {
+				if link.App != input.AppName {
+					continue
+				}
+
+				linkedServices = append(linkedServices, LinkedService{
+					Link:        link,
+					Name:        dirEntry.Name(),
+					ServiceType: serviceType,
+				})
+			}
+		}
+	}
+
+	return linkedServices, nil
+}
+
+// AliasVariableInput contains the input parameters for the AliasVariable function
+type AliasVariableInput struct {
+	// ServiceName is the name of the service
+	ServiceName string
+
+	// ServiceType is the type of service
+	ServiceType string
+
+	// Variable is the exported variable to alias
+	Variable string
+}
+
+// AliasVariable returns the service-specific name for an exported variable
+//
+// For a postgres service named "lollipop", DATABASE_URL is aliased to DOKKU_POSTGRES_LOLLIPOP_URL
+func AliasVariable(input AliasVariableInput) string {
+	suffix := input.Variable
+	if parts := strings.SplitN(input.Variable, "_", 2); len(parts) == 2 && parts[1] != "" {
+		suffix = parts[1]
+	}
+
+	return strings.ToUpper(strings.Join([]string{
+		"DOKKU",
+		strings.ReplaceAll(slug.Make(input.ServiceType), "-", "_"),
+		strings.ReplaceAll(slug.Make(input.ServiceName), "-", "_"),
+		suffix,
+	}, "_"))
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"dokku-service/template"
+	"fmt"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// RenderSidecarInput contains the input parameters for the RenderSidecar function
+type RenderSidecarInput struct {
+	// ConfigOutput is the service config to render the sidecar against
+	ConfigOutput ConfigOutput
+
+	// Hostname is the hostname the service container is reachable at from the sidecar
+	Hostname string
+
+	// Sidecar is the sidecar to render
+	Sidecar template.Sidecar
+}
+
+// RenderSidecar renders the image, command and arguments of a sidecar, which may reference the service arguments
+func RenderSidecar(ctx context.Context, input RenderSidecarInput) (template.Sidecar, error) {
+	data := templateData(input.ConfigOutput, input.Hostname)
+	render := func(field string, value string) (string, error) {
+		tmpl, err := texttemplate.New("base").Funcs(sprig.FuncMap()).Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse sidecar %s %s template: %w", input.Sidecar.Name, field, err)
+		}
+
+		builder := &strings.Builder{}
+		if err := tmpl.Execute(builder, data); err != nil {
+			return "", fmt.Errorf("failed to execute sidecar %s %s template: %w", input.Sidecar.Name, field, err)
+		}
+
+		return builder.String(), nil
+	}
+
+	sidecar := input.Sidecar
+	var err error
+	if sidecar.Image, err = render("image", sidecar.Image); err != nil {
+		return template.Sidecar{}, err
+	}
+	if sidecar.Command, err = render("command", sidecar.Command); err != nil {
+		return template.Sidecar{}, err
+	}
+
+	sidecar.Arguments = map[string]string{}
+	for key, value := range input.Sidecar.Arguments {
+		if sidecar.Arguments[key], err = render(key+" argument", value); err != nil {
+			return template.Sidecar{}, err
+		}
+	}
+
+	return sidecar, nil
+}
@@ -0,0 +1,219 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/asottile/dockerfile"
+)
+
+const (
+	ARGUMENT_TYPE_BOOL   = "bool"
+	ARGUMENT_TYPE_INT    = "int"
+	ARGUMENT_TYPE_STRING = "string"
+)
+
+// argumentFields are the metadata fields that may be set on an argument via labels
+var argumentFields = map[string]bool{
+	"description": true,
+	"enum":        true,
+	"max":         true,
+	"min":         true,
+	"pattern":     true,
+	"type":        true,
+}
+
+// isArgumentLabel checks if a label sets argument metadata
+func isArgumentLabel(label string) bool {
+	return strings.HasPrefix(label, string(LABEL_CONFIG_ARGUMENTS)+".")
+}
+
+// parseArgumentLabel splits an argument metadata label into the argument name and field
+func parseArgumentLabel(label string) (string, string, error) {
+	key := strings.TrimPrefix(label, string(LABEL_CONFIG_ARGUMENTS)+".")
+	index := strings.LastIndex(key, ".")
+	if index <= 0 {
+		return "", "", fmt.Errorf("invalid label key: %s", label)
+	}
+
+	name, field := key[:index], key[index+1:]
+	if !argumentFields[field] {
+		return "", "", fmt.Errorf("invalid label key: %s", label)
+	}
+
+	return name, field, nil
+}
+
+// applyArgumentLabels sets the metadata described by argument labels on the matching arguments
+func applyArgumentLabels(arguments []Argument, commands []dockerfile.Command) error {
+	for _, command := range commands {
+		if err := applyArgumentLabel(arguments, command); err != nil {
+			return err
+		}
+	}
+
+	for _, argument := range arguments {
+		if err := validateArgumentMetadata(argument); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyArgumentLabel sets the metadata described by an argument label on the matching arguments
+func applyArgumentLabel(arguments []Argument, command dockerfile.Command) error {
+	label := command.Value[0]
+	name, field, err := parseArgumentLabel(label)
+	if err != nil {
+		return err
+	}
+
+	value, err := getLabelValue([]dockerfile.Command{command}, label)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range arguments {
+		if arguments[i].Name != name {
+			continue
+		}
+
+		found = true
+		if err := setArgumentMetadata(&arguments[i], field, value); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("label %s describes unknown argument %s", label, name)
+	}
+
+	return nil
+}
+
+// setArgumentMetadata sets a metadata field on an argument from a label value
+func setArgumentMetadata(argument *Argument, field string, value string) error {
+	switch field {
+	case "description":
+		argument.Description = value
+	case "enum":
+		argument.Enum = []string{}
+		for _, option := range strings.Split(value, ",") {
+			if option = strings.TrimSpace(option); option != "" {
+				argument.Enum = append(argument.Enum, option)
+			}
+		}
+	case "max", "min":
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s for argument %s: %w", field, argument.Name, err)
+		}
+		if field == "max" {
+			argument.Max = &i
+		} else {
+			argument.Min = &i
+		}
+	case "pattern":
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid pattern for argument %s: %w", argument.Name, err)
+		}
+		argument.Pattern = value
+	case "type":
+		if value != ARGUMENT_TYPE_BOOL && value != ARGUMENT_TYPE_INT && value != ARGUMENT_TYPE_STRING {
+			return fmt.Errorf("invalid type for argument %s: %s, must be one of %s, %s or %s", argument.Name, value, ARGUMENT_TYPE_BOOL, ARGUMENT_TYPE_INT, ARGUMENT_TYPE_STRING)
+		}
+		argument.Type = value
+	}
+
+	return nil
+}
+
+// validateArgumentMetadata ensures the metadata of an argument is consistent, including its default value
+func validateArgumentMetadata(argument Argument) error {
+	if (argument.Min != nil || argument.Max != nil) && argument.Type != ARGUMENT_TYPE_INT {
+		return fmt.Errorf("min and max for argument %s require type %s", argument.Name, ARGUMENT_TYPE_INT)
+	}
+
+	if argument.Min != nil && argument.Max != nil && *argument.Min > *argument.Max {
+		return fmt.Errorf("min for argument %s is greater than max", argument.Name)
+	}
+
+	for _, option := range argument.Enum {
+		if err := argument.validateType(option); err != nil {
+			return fmt.Errorf("invalid enum value %s for argument %s: %w", option, argument.Name, err)
+		}
+	}
+
+	// generated defaults differ on every create, so only literal defaults are checked
+	if argument.Value != "" && !argument.IsVariable {
+		if err := argument.ValidateValue(argument.Value); err != nil {
+			return fmt.Errorf("invalid default for argument %s: %w", argument.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateValue checks a value against the type and constraints of an argument.
+// Empty values are not checked, as whether an argument is required is handled separately.
+func (a Argument) ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if err := a.validateType(value); err != nil {
+		return err
+	}
+
+	if len(a.Enum) > 0 {
+		found := false
+		for _, option := range a.Enum {
+			found = found || option == value
+		}
+		if !found {
+			return fmt.Errorf("value %q must be one of: %s", value, strings.Join(a.Enum, ", "))
+		}
+	}
+
+	if a.Pattern != "" {
+		// patterns must match the whole value
+		pattern, err := regexp.Compile("^(?:" + a.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", a.Pattern, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("value %q must match pattern %s", value, a.Pattern)
+		}
+	}
+
+	if a.Type == ARGUMENT_TYPE_INT {
+		i, _ := strconv.Atoi(value)
+		if a.Min != nil && i < *a.Min {
+			return fmt.Errorf("value %d must be at least %d", i, *a.Min)
+		}
+		if a.Max != nil && i > *a.Max {
+			return fmt.Errorf("value %d must be at most %d", i, *a.Max)
+		}
+	}
+
+	return nil
+}
+
+// validateType checks that a value can be parsed as the type of an argument
+func (a Argument) validateType(value string) error {
+	switch a.Type {
+	case ARGUMENT_TYPE_BOOL:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value %q must be a boolean", value)
+		}
+	case ARGUMENT_TYPE_INT:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %q must be an integer", value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/asottile/dockerfile"
+	"go.yaml.in/yaml/v3"
+)
+
+// COMPOSE_FILE is the name of the compose file defining a compose-based service template
+const COMPOSE_FILE = "docker-compose.yml"
+
+// COMPOSE_LABEL_PREFIX is the prefix of compose labels that configure a service template
+const COMPOSE_LABEL_PREFIX = "dokku.com/service."
+
+// GENERATED_DOCKERFILE is the Dockerfile a compose template's image is built from when it does not specify a build file
+const GENERATED_DOCKERFILE = "ARG IMAGE\nFROM ${IMAGE}\n"
+
+// composeKeys are the keys a compose file may set at each level, as any other key would have no effect
+var composeKeys = map[string]map[string]bool{
+	"top level": {"services": true, "version": true},
+	"service":   {"build": true, "image": true, "labels": true, "ports": true, "restart": true, "volumes": true},
+	"build":     {"args": true, "dockerfile": true},
+}
+
+// ParseComposeFile parses a service template from a docker-compose.yml file.
+// The compose file is translated into the directives of an equivalent Dockerfile,
+// so both formats are subject to the same rules and produce the same templates.
+func ParseComposeFile(ctx context.Context, input NewServiceTemplateInput) (ServiceTemplate, error) {
+	templatePath := filepath.Join(input.RegistryPath, input.Name)
+
+	b, err := os.ReadFile(filepath.Join(templatePath, COMPOSE_FILE))
+	if err != nil {
+		return ServiceTemplate{}, fmt.Errorf("failed to read %s: %w", COMPOSE_FILE, err)
+	}
+
+	commands, dockerfilePath, err := composeCommands(b)
+	if err != nil {
+		return ServiceTemplate{}, fmt.Errorf("compose parse error: %w", err)
+	}
+
+	if dockerfilePath != "" {
+		if _, err := os.Stat(filepath.Join(templatePath, dockerfilePath)); err != nil {
+			return ServiceTemplate{}, fmt.Errorf("missing build dockerfile %s: %w", dockerfilePath, err)
+		}
+	}
+
+	template, err := parseCommands(ctx, input, commands)
+	if err != nil {
+		return ServiceTemplate{}, err
+	}
+
+	template.DockerfilePath = dockerfilePath
+	template.GeneratedDockerfile = dockerfilePath == ""
+	return template, nil
+}
+
+// composeCommands translates the single service of a compose file into Dockerfile directives,
+// returning them along with the path to the Dockerfile the service image is built from,
+// which is empty if the image is built from the GENERATED_DOCKERFILE
+func composeCommands(b []byte) ([]dockerfile.Command, string, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(b, &document); err != nil {
+		return []dockerfile.Command{}, "", err
+	}
+	if len(document.Content) == 0 {
+		return []dockerfile.Command{}, "", errors.New("empty compose file")
+	}
+	if err := checkComposeKeys(document.Content[0], "top level"); err != nil {
+		return []dockerfile.Command{}, "", err
+	}
+
+	services := mappingValue(document.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode || len(services.Content) == 0 {
+		return []dockerfile.Command{}, "", errors.New("missing services")
+	}
+	if len(services.Content) > 2 {
+		return []dockerfile.Command{}, "", errors.New("cannot specify multiple services in a single compose file")
+	}
+	service := services.Content[1]
+	if err := checkComposeKeys(service, "service"); err != nil {
+		return []dockerfile.Command{}, "", err
+	}
+
+	// service containers are always created with the always restart policy
+	if restart := mappingValue(service, "restart"); restart != nil && restart.Value != "always" {
+		return []dockerfile.Command{}, "", fmt.Errorf("line %d: restart must be always, as service containers are always restarted", restart.Line)
+	}
+
+	commands := []dockerfile.Command{}
+	image := mappingValue(service, "image")
+	if image == nil || image.Value == "" {
+		return []dockerfile.Command{}, "", errors.New("missing service image")
+	}
+	commands = append(commands, composeCommand("arg", image.Line, "IMAGE="+strconv.Quote(image.Value)))
+	commands = append(commands, composeCommand("from", image.Line, "${IMAGE}"))
+
+	dockerfilePath := ""
+	if build := mappingValue(service, "build"); build != nil {
+		if err := checkComposeKeys(build, "build"); err != nil {
+			return []dockerfile.Command{}, "", err
+		}
+
+		if value := mappingValue(build, "dockerfile"); value != nil && value.Value != "" {
+			dockerfilePath = value.Value
+		}
+
+		args, err := composePairs(mappingValue(build, "args"))
+		if err != nil {
+			return []dockerfile.Command{}, "", fmt.Errorf("invalid build args: %w", err)
+		}
+		for _, arg := range args {
+			if arg.key == "IMAGE" {
+				return []dockerfile.Command{}, "", fmt.Errorf("line %d: the IMAGE argument must be specified via the service image", arg.line)
+			}
+
+			value := arg.key
+			if arg.value != "" {
+				value = arg.key + "=" + strconv.Quote(arg.value)
+			}
+			commands = append(commands, composeCommand("arg", arg.line, value))
+		}
+	}
+
+	if volumes := mappingValue(service, "volumes"); volumes != nil {
+		for _, volume := range volumes.Content {
+			if volume.Kind != yaml.ScalarNode || strings.Contains(volume.Value, ":") {
+				return []dockerfile.Command{}, "", fmt.Errorf("line %d: volumes must be specified as container paths", volume.Line)
+			}
+			commands = append(commands, composeCommand("volume", volume.Line, volume.Value))
+		}
+	}
+
+	labels, err := composePairs(mappingValue(service, "labels"))
+	if err != nil {
+		return []dockerfile.Command{}, "", fmt.Errorf("invalid labels: %w", err)
+	}
+	for _, label := range labels {
+		if !strings.HasPrefix(label.key, "dokku.com/service") {
+			continue
+		}
+
+		key, err := composeLabelKey(label.key)
+		if err != nil {
+			return []dockerfile.Command{}, "", fmt.Errorf("line %d: %w", label.line, err)
+		}
+		if key == string(LABEL_CONFIG_PORTS_EXPOSE) {
+			return []dockerfile.Command{}, "", fmt.Errorf("line %d: exposed ports must be specified via the service ports", label.line)
+		}
+		commands = append(commands, composeCommand("label", label.line, key, strconv.Quote(label.value)))
+	}
+
+	if ports := mappingValue(service, "ports"); ports != nil && len(ports.Content) > 0 {
+		exposePorts := []string{}
+		for _, port := range ports.Content {
+			if port.Kind != yaml.ScalarNode || strings.Contains(port.Value, ":") {
+				return []dockerfile.Command{}, "", fmt.Errorf("line %d: ports must be specified as container ports", port.Line)
+			}
+			exposePorts = append(exposePorts, port.Value)
+		}
+		commands = append(commands, composeCommand("label", ports.Line, string(LABEL_CONFIG_PORTS_EXPOSE), strconv.Quote(strings.Join(exposePorts, ","))))
+	}
+
+	return commands, dockerfilePath, nil
+}
+
+// composeLabelKey translates a compose label key into the equivalent Dockerfile label key
+func composeLabelKey(key string) (string, error) {
+	if !strings.HasPrefix(key, COMPOSE_LABEL_PREFIX) {
+		return "", fmt.Errorf("invalid label key: %s", key)
+	}
+
+	suffix := strings.TrimPrefix(key, COMPOSE_LABEL_PREFIX)
+	switch suffix {
+	case "name":
+		return string(LABEL_NAME), nil
+	case "description":
+		return string(LABEL_DESCRIPTION), nil
+	case "extends":
+		return string(LABEL_EXTENDS), nil
+	}
+
+	return "com.dokku.template.config." + suffix, nil
+}
+
+// checkComposeKeys returns an error for the first key of a compose mapping that the loader does not support
+func checkComposeKeys(node *yaml.Node, level string) error {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !composeKeys[level][node.Content[i].Value] {
+			return fmt.Errorf("line %d: unsupported %s key %s", node.Content[i].Line, level, node.Content[i].Value)
+		}
+	}
+
+	return nil
+}
+
+// composeCommand creates a Dockerfile directive from compose file values
+func composeCommand(cmd string, line int, value ...string) dockerfile.Command {
+	return dockerfile.Command{
+		Cmd:       cmd,
+		EndLine:   line,
+		Original:  strings.ToUpper(cmd) + " " + strings.Join(value, "="),
+		StartLine: line,
+		Value:     value,
+	}
+}
+
+// composePair is a key and value from a compose mapping or list of KEY=VALUE entries
+type composePair struct {
+	key   string
+	line  int
+	value string
+}
+
+// composePairs returns the entries of a compose mapping or list of KEY=VALUE entries, in order
+func composePairs(node *yaml.Node) ([]composePair, error) {
+	pairs := []composePair{}
+	if node == nil {
+		return pairs, nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, composePair{
+				key:   node.Content[i].Value,
+				line:  node.Content[i].Line,
+				value: node.Content[i+1].Value,
+			})
+		}
+	case yaml.SequenceNode:
+		for _, entry := range node.Content {
+			key, value, _ := strings.Cut(entry.Value, "=")
+			pairs = append(pairs, composePair{
+				key:   key,
+				line:  entry.Line,
+				value: value,
+			})
+		}
+	default:
+		return pairs, fmt.Errorf("line %d: expected a mapping or list", node.Line)
+	}
+
+	return pairs, nil
+}
+
+// mappingValue returns the value of a key in a yaml mapping, or nil if it is not set
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/asottile/dockerfile"
+)
+
+// VENDORED_REGISTRY is the registry name that refers to the vendored registry when extending a template
+const VENDORED_REGISTRY = "vendored"
+
+// extendedTemplate contains the directives of a template merged with those of the templates it extends
+type extendedTemplate struct {
+	// commands are the merged directives, where inherited directives have no line
+	commands []dockerfile.Command
+
+	// hookPaths maps hook names to the scripts inherited from extended templates
+	hookPaths map[string]string
+}
+
+// readCommands reads the directives of a template from its Dockerfile, or its docker-compose.yml if it has no Dockerfile
+func readCommands(input NewServiceTemplateInput) ([]dockerfile.Command, error) {
+	templatePath := filepath.Join(input.RegistryPath, input.Name)
+	if _, err := os.Stat(filepath.Join(templatePath, "Dockerfile")); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(templatePath, COMPOSE_FILE)); err == nil {
+			b, err := os.ReadFile(filepath.Join(templatePath, COMPOSE_FILE))
+			if err != nil {
+				return []dockerfile.Command{}, fmt.Errorf("failed to read %s: %w", COMPOSE_FILE, err)
+			}
+
+			commands, _, err := composeCommands(b)
+			if err != nil {
+				return []dockerfile.Command{}, fmt.Errorf("compose parse error: %w", err)
+			}
+			return commands, nil
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(templatePath, "Dockerfile"))
+	if err != nil {
+		return []dockerfile.Command{}, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	commands, err := dockerfile.ParseReader(bytes.NewReader(b))
+	if err != nil {
+		return []dockerfile.Command{}, fmt.Errorf("dockerfile parse error: %w", err)
+	}
+
+	return commands, nil
+}
+
+// extendsInput returns the input for parsing the template referenced by an extends label,
+// which is either <name> in the same registry, vendored:<name> or <registry-path>:<name>
+func extendsInput(input NewServiceTemplateInput, extends string) (NewServiceTemplateInput, error) {
+	registryName, name, found := strings.Cut(extends, ":")
+	if !found {
+		name = registryName
+		registryName = ""
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return NewServiceTemplateInput{}, fmt.Errorf("invalid template reference: %s", extends)
+	}
+
+	parent := input
+	parent.Name = name
+	switch {
+	case registryName == "":
+	case registryName == VENDORED_REGISTRY:
+		if input.VendoredRegistryPath == "" {
+			return NewServiceTemplateInput{}, fmt.Errorf("vendored registry is not available to resolve %s", extends)
+		}
+		parent.RegistryPath = input.VendoredRegistryPath
+		parent.VendoredRegistry = true
+	case filepath.IsAbs(registryName):
+		parent.RegistryPath = registryName
+		parent.VendoredRegistry = false
+	default:
+		parent.RegistryPath = filepath.Join(input.RegistryPath, registryName)
+		parent.VendoredRegistry = false
+	}
+
+	return parent, nil
+}
+
+// extendTemplate merges the directives of the templates a template extends into its own.
+// chain holds the templates already being extended, and is used to detect cycles.
+func extendTemplate(input NewServiceTemplateInput, commands []dockerfile.Command, chain []string) (extendedTemplate, error) {
+	extended := extendedTemplate{
+		commands:  commands,
+		hookPaths: map[string]string{},
+	}
+
+	extends, err := getLabelValue(commands, string(LABEL_EXTENDS))
+	if err != nil {
+		return extended, nil
+	}
+
+	parentInput, err := extendsInput(input, extends)
+	if err != nil {
+		return extendedTemplate{}, err
+	}
+
+	key := filepath.Join(parentInput.RegistryPath, parentInput.Name)
+	names := []string{}
+	for _, link := range append(chain, key) {
+		names = append(names, filepath.Base(link))
+	}
+	for _, link := range chain {
+		if link == key {
+			return extendedTemplate{}, fmt.Errorf("template inheritance cycle: %s", strings.Join(names, " -> "))
+		}
+	}
+
+	parentCommands, err := readCommands(parentInput)
+	if err != nil {
+		return extendedTemplate{}, fmt.Errorf("failed to read extended template %s: %w", extends, err)
+	}
+
+	parent, err := extendTemplate(parentInput, parentCommands, append(chain, key))
+	if err != nil {
+		return extendedTemplate{}, err
+	}
+
+	for _, hookName := range []string{"pre-create", "post-create", "post-start"} {
+		hookPath := filepath.Join(parentInput.RegistryPath, parentInput.Name, "bin", hookName)
+		if _, err := os.Stat(hookPath); err == nil {
+			parent.hookPaths[hookName] = hookPath
+		}
+	}
+	for hookName, hookPath := range parent.hookPaths {
+		if _, err := os.Stat(filepath.Join(input.RegistryPath, input.Name, "bin", hookName)); errors.Is(err, os.ErrNotExist) {
+			extended.hookPaths[hookName] = hookPath
+		}
+	}
+
+	extended.commands = mergeCommands(parent.commands, commands)
+	return extended, nil
+}
+
+// mergeCommands merges the ARG, LABEL and VOLUME directives of a parent template with those of a child.
+// Child arguments and labels override the parent's in place, though a child argument without a default
+// keeps the parent's default. Inherited directives have no line, as they are not part of the child's file.
+func mergeCommands(parent []dockerfile.Command, child []dockerfile.Command) []dockerfile.Command {
+	childArguments := map[string]dockerfile.Command{}
+	childLabels := map[string]bool{}
+	childVolumes := map[string]bool{}
+	for _, command := range child {
+		switch {
+		case isArg(command) && len(command.Value) > 0:
+			name, _, _ := strings.Cut(command.Value[0], "=")
+			childArguments[name] = command
+		case isLabel(command) && len(command.Value) > 0:
+			childLabels[command.Value[0]] = true
+		case isVolume(command) && len(command.Value) > 0:
+			childVolumes[command.Value[0]] = true
+		}
+	}
+
+	merged := []dockerfile.Command{}
+	overridden := map[string]bool{}
+	for _, command := range parent {
+		if len(command.Value) == 0 {
+			continue
+		}
+
+		switch {
+		case isArg(command):
+			name, _, _ := strings.Cut(command.Value[0], "=")
+			if childArgument, ok := childArguments[name]; ok {
+				overridden[name] = true
+				if strings.Contains(childArgument.Value[0], "=") {
+					merged = append(merged, childArgument)
+					continue
+				}
+			}
+		case isLabel(command):
+			if childLabels[command.Value[0]] || command.Value[0] == string(LABEL_EXTENDS) {
+				continue
+			}
+		case isVolume(command):
+			if childVolumes[command.Value[0]] {
+				continue
+			}
+		default:
+			continue
+		}
+
+		command.StartLine = 0
+		command.EndLine = 0
+		merged = append(merged, command)
+	}
+
+	for _, command := range child {
+		if isArg(command) && len(command.Value) > 0 {
+			name, _, _ := strings.Cut(command.Value[0], "=")
+			if overridden[name] {
+				continue
+			}
+		}
+		merged = append(merged, command)
+	}
+
+	return merged
+}
@@ -21,16 +21,20 @@ type Label string
 const (
 	LABEL_NAME                     Label = "com.dokku.template.name"
 	LABEL_DESCRIPTION              Label = "com.dokku.template.description"
+	LABEL_EXTENDS                  Label = "com.dokku.template.extends"
+	LABEL_CONFIG_ARGUMENTS         Label = "com.dokku.template.config.arguments"
 	LABEL_CONFIG_COMMANDS_CONNECT  Label = "com.dokku.template.config.commands.connect"
 	LABEL_CONFIG_COMMANDS_ENTER    Label = "com.dokku.template.config.commands.enter"
 	LABEL_CONFIG_COMMANDS_EXPORT   Label = "com.dokku.template.config.commands.export"
 	LABEL_CONFIG_COMMANDS_IMPORT   Label = "com.dokku.template.config.commands.import"
+	LABEL_CONFIG_COMMANDS_ROTATE   Label = "com.dokku.template.config.commands.rotate-secret"
 	LABEL_CONFIG_HOOKS_IMAGE       Label = "com.dokku.template.config.hooks.image"
 	LABEL_CONFIG_HOOKS_PRE_CREATE  Label = "com.dokku.template.config.hooks.pre-create"
 	LABEL_CONFIG_HOOKS_POST_CREATE Label = "com.dokku.template.config.hooks.post-create"
 	LABEL_CONFIG_HOOKS_POST_START  Label = "com.dokku.template.config.hooks.post-start"
 	LABEL_CONFIG_PORTS_EXPOSE      Label = "com.dokku.template.config.ports.expose"
 	LABEL_CONFIG_PORTS_WAIT        Label = "com.dokku.template.config.ports.wait"
+	LABEL_CONFIG_SIDECARS          Label = "com.dokku.template.config.sidecars"
 	LABEL_CONFIG_VARIABLES_EXPORT  Label = "com.dokku.template.config.variables.exported"
 	LABEL_CONFIG_VARIABLES_MAPPED  Label = "com.dokku.template.config.variables.mapped"
 )
@@ -42,26 +46,29 @@ const (
 )
 
 type ServiceTemplate struct {
-	Name              string            `json:"name"`
-	Image             ServiceImage      `json:"image"`
-	DockerfilePath    string            `json:"dockerfile_path"`
-	Description       string            `json:"description"`
-	Arguments         []Argument        `json:"arguments"`
-	Hooks             ServiceHooks      `json:"hooks"`
-	ExportedVariables map[string]string `json:"exported_variables"`
-	MappedVariables   map[string]string `json:"mapped_variables"`
-	Commands          map[string]string `json:"commands"`
-	TemplatePath      string            `json:"path"`
-	VendoredTemplate  bool              `json:"vendored_template"`
-	Ports             ServicePorts      `json:"ports"`
-	Volumes           []Volume          `json:"volumes"`
+	Name                string            `json:"name"`
+	Image               ServiceImage      `json:"image"`
+	DockerfilePath      string            `json:"dockerfile_path"`
+	GeneratedDockerfile bool              `json:"generated_dockerfile,omitempty"`
+	Description         string            `json:"description"`
+	Arguments           []Argument        `json:"arguments"`
+	Hooks               ServiceHooks      `json:"hooks"`
+	ExportedVariables   map[string]string `json:"exported_variables"`
+	MappedVariables     map[string]string `json:"mapped_variables"`
+	Commands            map[string]string `json:"commands"`
+	TemplatePath        string            `json:"path"`
+	VendoredTemplate    bool              `json:"vendored_template"`
+	Ports               ServicePorts      `json:"ports"`
+	Volumes             []Volume          `json:"volumes"`
+	Sidecars            []Sidecar         `json:"sidecars,omitempty"`
 }
 
 type ServiceHooks struct {
-	Image      string `json:"image"`
-	PreCreate  bool   `json:"pre_create"`
-	PostCreate bool   `json:"post_create"`
-	PostStart  bool   `json:"post_start"`
+	Image      string            `json:"image"`
+	PreCreate  bool              `json:"pre_create"`
+	PostCreate bool              `json:"post_create"`
+	PostStart  bool              `json:"post_start"`
+	Paths      map[string]string `json:"paths,omitempty"`
 }
 
 type ServiceImage struct {
@@ -75,10 +82,26 @@ type ServicePorts struct {
 }
 
 type Argument struct {
-	Name       string `json:"name"`
-	Template   string `json:"template"`
-	Value      string `json:"value"`
-	IsVariable bool   `json:"is_variable"`
+	Name        string   `json:"name"`
+	Template    string   `json:"template"`
+	Value       string   `json:"value"`
+	IsVariable  bool     `json:"is_variable"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Min         *int     `json:"min,omitempty"`
+	Max         *int     `json:"max,omitempty"`
+}
+
+// Sidecar is an additional container run alongside the service container
+type Sidecar struct {
+	Name      string            `json:"name"`
+	Image     string            `json:"image"`
+	Command   string            `json:"command"`
+	Arguments map[string]string `json:"arguments"`
+	Volumes   []Volume          `json:"volumes"`
+	WaitPorts []int             `json:"wait_ports"`
 }
 
 type Volume struct {
@@ -92,25 +115,43 @@ func init() {
 	validLabels = map[Label]bool{
 		LABEL_NAME:                     true,
 		LABEL_DESCRIPTION:              true,
+		LABEL_EXTENDS:                  true,
 		LABEL_CONFIG_COMMANDS_CONNECT:  true,
 		LABEL_CONFIG_COMMANDS_ENTER:    true,
 		LABEL_CONFIG_COMMANDS_EXPORT:   true,
 		LABEL_CONFIG_COMMANDS_IMPORT:   true,
+		LABEL_CONFIG_COMMANDS_ROTATE:   true,
 		LABEL_CONFIG_HOOKS_IMAGE:       true,
 		LABEL_CONFIG_HOOKS_PRE_CREATE:  true,
 		LABEL_CONFIG_HOOKS_POST_CREATE: true,
+		LABEL_CONFIG_HOOKS_POST_START:  true,
 		LABEL_CONFIG_PORTS_EXPOSE:      true,
 		LABEL_CONFIG_PORTS_WAIT:        true,
 	}
 }
 
 type NewServiceTemplateInput struct {
-	Name             string
-	RegistryPath     string
-	VendoredRegistry bool
+	Name                 string
+	RegistryPath         string
+	VendoredRegistry     bool
+	VendoredRegistryPath string
 }
 
+// NewServiceTemplate parses a service template from its Dockerfile,
+// falling back to its docker-compose.yml if the template has no Dockerfile
 func NewServiceTemplate(ctx context.Context, input NewServiceTemplateInput) (ServiceTemplate, error) {
+	templatePath := filepath.Join(input.RegistryPath, input.Name)
+	_, dockerfileErr := os.Stat(filepath.Join(templatePath, "Dockerfile"))
+	_, composeErr := os.Stat(filepath.Join(templatePath, COMPOSE_FILE))
+	if os.IsNotExist(dockerfileErr) && composeErr == nil {
+		template, err := ParseComposeFile(ctx, input)
+		if err != nil {
+			return ServiceTemplate{}, fmt.Errorf("failed to parse %s: %w", COMPOSE_FILE, err)
+		}
+
+		return template, nil
+	}
+
 	template, err := ParseDockerfile(ctx, input)
 	if err != nil {
 		return ServiceTemplate{}, fmt.Errorf("failed to parse Dockerfile: %w", err)
@@ -134,10 +175,31 @@ func ParseDockerfile(ctx context.Context, input NewServiceTemplateInput) (Servic
 		return ServiceTemplate{}, fmt.Errorf("dockerfile parse error: %w", err)
 	}
 
+	template, err := parseCommands(ctx, input, commands)
+	if err != nil {
+		return ServiceTemplate{}, err
+	}
+
+	template.DockerfilePath = "Dockerfile"
+	return template, nil
+}
+
+// parseCommands parses a service template from the directives of its Dockerfile
+func parseCommands(ctx context.Context, input NewServiceTemplateInput, commands []dockerfile.Command) (ServiceTemplate, error) {
+	templatePath := filepath.Join(input.RegistryPath, input.Name)
+
+	extended, err := extendTemplate(input, commands, []string{templatePath})
+	if err != nil {
+		return ServiceTemplate{}, fmt.Errorf("failed to extend template: %w", err)
+	}
+	commands = extended.commands
+
 	arguments := []Argument{}
 	volumes := []Volume{}
 	exportedVariables := map[string]string{}
 	mappedVariables := map[string]string{}
+	argumentLabels := []dockerfile.Command{}
+	sidecarLabels := []dockerfile.Command{}
 	for _, command := range commands {
 		if err := validateLabel(command); err != nil {
 			return ServiceTemplate{}, fmt.Errorf("invalid LABEL directive: %w", err)
@@ -177,6 +239,25 @@ func ParseDockerfile(ctx context.Context, input NewServiceTemplateInput) (Servic
 
 			mappedVariables[command.Value[0]] = value
 		}
+
+		if isLabel(command) && isArgumentLabel(command.Value[0]) {
+			argumentLabels = append(argumentLabels, command)
+		}
+
+		if isLabel(command) && isSidecarLabel(command.Value[0]) {
+			sidecarLabels = append(sidecarLabels, command)
+		}
+	}
+
+	// argument metadata labels may precede the ARG directives they describe
+	if err := applyArgumentLabels(arguments, argumentLabels); err != nil {
+		return ServiceTemplate{}, fmt.Errorf("invalid argument label: %w", err)
+	}
+
+	// sidecar volumes may share VOLUME directives declared after the sidecar labels
+	sidecars, err := applySidecarLabels(sidecarLabels, volumes)
+	if err != nil {
+		return ServiceTemplate{}, fmt.Errorf("invalid sidecar label: %w", err)
 	}
 
 	image := ServiceImage{}
@@ -250,6 +331,7 @@ func ParseDockerfile(ctx context.Context, input NewServiceTemplateInput) (Servic
 		LABEL_CONFIG_COMMANDS_ENTER:   "enter",
 		LABEL_CONFIG_COMMANDS_EXPORT:  "export",
 		LABEL_CONFIG_COMMANDS_IMPORT:  "import",
+		LABEL_CONFIG_COMMANDS_ROTATE:  "rotate-secret",
 	}
 	for label, commandName := range commandLabels {
 		command, _ := getLabelValue(commands, string(label))
@@ -275,15 +357,26 @@ func ParseDockerfile(ctx context.Context, input NewServiceTemplateInput) (Servic
 			PreCreate:  preCreateHook,
 			PostCreate: postCreateHook,
 			PostStart:  postStartHook,
+			Paths:      extended.hookPaths,
 		},
 		ExportedVariables: exportedVariables,
 		MappedVariables:   mappedVariables,
 		Volumes:           volumes,
+		Sidecars:          sidecars,
 	}
 
 	return template, nil
 }
 
+// HookPath returns the path to the script of a hook, which may be inherited from an extended template
+func (t ServiceTemplate) HookPath(name string) string {
+	if hookPath, ok := t.Hooks.Paths[name]; ok {
+		return hookPath
+	}
+
+	return filepath.Join(t.TemplatePath, "bin", name)
+}
+
 func isArg(command dockerfile.Command) bool {
 	return strings.ToUpper(command.Cmd) == "ARG"
 }
@@ -304,6 +397,7 @@ func parseArg(command dockerfile.Command) (Argument, error) {
 	parts := strings.SplitN(command.Value[0], "=", 2)
 	argument := Argument{
 		Name: parts[0],
+		Type: ARGUMENT_TYPE_STRING,
 	}
 
 	if len(parts) == 2 {
@@ -314,23 +408,33 @@ func parseArg(command dockerfile.Command) (Argument, error) {
 			argument.Template = s
 		}
 
-		tmpl, err := template.New("base").Funcs(sprig.FuncMap()).Parse(argument.Template)
+		value, err := RenderArgument(argument)
 		if err != nil {
-			return Argument{}, fmt.Errorf("failed to parse argument template: %w", err)
+			return Argument{}, err
 		}
 
-		builder := &strings.Builder{}
-		if err := tmpl.Execute(builder, nil); err != nil {
-			return Argument{}, fmt.Errorf("failed to initialize argument: %w", err)
-		}
-
-		argument.Value = builder.String()
+		argument.Value = value
 		argument.IsVariable = argument.Template != argument.Value
 	}
 
 	return argument, nil
 }
 
+// RenderArgument evaluates the template of an argument, returning a freshly generated value
+func RenderArgument(argument Argument) (string, error) {
+	tmpl, err := template.New("base").Funcs(sprig.FuncMap()).Parse(argument.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse argument template: %w", err)
+	}
+
+	builder := &strings.Builder{}
+	if err := tmpl.Execute(builder, nil); err != nil {
+		return "", fmt.Errorf("failed to initialize argument: %w", err)
+	}
+
+	return builder.String(), nil
+}
+
 func validateArgument(command dockerfile.Command) error {
 	if !isArg(command) {
 		return errors.New("command directive is not ARG")
@@ -387,12 +491,20 @@ func validateLabel(command dockerfile.Command) error {
 		return nil
 	}
 
-	// special-case exported and mapped variables
+	// special-case argument metadata, sidecars, exported and mapped variables
 	isExported := strings.HasPrefix(key, string(LABEL_CONFIG_VARIABLES_EXPORT)+".")
 	isMapped := strings.HasPrefix(key, string(LABEL_CONFIG_VARIABLES_MAPPED)+".")
 	if ok := validLabels[Label(key)]; ok {
 		return nil
 	}
+	if isArgumentLabel(key) {
+		_, _, err := parseArgumentLabel(key)
+		return err
+	}
+	if isSidecarLabel(key) {
+		_, _, err := parseSidecarLabel(key)
+		return err
+	}
 	if !isExported && !isMapped {
 		return fmt.Errorf("invalid label key: %s", key)
 	}
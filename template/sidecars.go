@@ -0,0 +1,218 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/asottile/dockerfile"
+	"github.com/gosimple/slug"
+)
+
+// sidecarFields are the fields that may be set on a sidecar via labels, besides its arguments
+var sidecarFields = map[string]bool{
+	"command":    true,
+	"image":      true,
+	"ports.wait": true,
+	"volumes":    true,
+}
+
+// sidecarNamePattern matches valid sidecar names, which are used as container name suffixes
+var sidecarNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// reservedSidecarNames are container name suffixes already used for other containers of a service
+var reservedSidecarNames = map[string]bool{
+	"ambassador": true,
+	"rollback":   true,
+}
+
+// isSidecarLabel checks if a label configures a sidecar
+func isSidecarLabel(label string) bool {
+	return strings.HasPrefix(label, string(LABEL_CONFIG_SIDECARS)+".")
+}
+
+// parseSidecarLabel splits a sidecar label into the sidecar name and field
+func parseSidecarLabel(label string) (string, string, error) {
+	key := strings.TrimPrefix(label, string(LABEL_CONFIG_SIDECARS)+".")
+	name, field, found := strings.Cut(key, ".")
+	if !found || name == "" {
+		return "", "", fmt.Errorf("invalid label key: %s", label)
+	}
+
+	if !sidecarNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid sidecar name %s, must be lowercase alphanumeric characters and dashes", name)
+	}
+	if reservedSidecarNames[name] {
+		return "", "", fmt.Errorf("invalid sidecar name %s, the name is reserved", name)
+	}
+
+	argument, isArgument := strings.CutPrefix(field, "args.")
+	if isArgument && argument == "" {
+		return "", "", fmt.Errorf("invalid label key: %s", label)
+	}
+	if !isArgument && !sidecarFields[field] {
+		return "", "", fmt.Errorf("invalid label key: %s", label)
+	}
+
+	return name, field, nil
+}
+
+// applySidecarLabels builds the sidecars described by sidecar labels, in the order they are first declared
+func applySidecarLabels(commands []dockerfile.Command, volumes []Volume) ([]Sidecar, error) {
+	sidecars := []Sidecar{}
+	for _, command := range commands {
+		var err error
+		sidecars, err = applySidecarLabel(sidecars, command, volumes)
+		if err != nil {
+			return sidecars, err
+		}
+	}
+
+	for _, sidecar := range sidecars {
+		if err := validateSidecar(sidecar); err != nil {
+			return sidecars, err
+		}
+	}
+
+	return sidecars, nil
+}
+
+// applySidecarLabel sets the field described by a sidecar label, adding the sidecar if it has not been declared yet
+func applySidecarLabel(sidecars []Sidecar, command dockerfile.Command, volumes []Volume) ([]Sidecar, error) {
+	label := command.Value[0]
+	name, field, err := parseSidecarLabel(label)
+	if err != nil {
+		return sidecars, err
+	}
+
+	value, err := getLabelValue([]dockerfile.Command{command}, label)
+	if err != nil {
+		return sidecars, err
+	}
+
+	index := -1
+	for i := range sidecars {
+		if sidecars[i].Name == name {
+			index = i
+		}
+	}
+	if index == -1 {
+		sidecars = append(sidecars, Sidecar{
+			Name:      name,
+			Arguments: map[string]string{},
+			Volumes:   []Volume{},
+			WaitPorts: []int{},
+		})
+		index = len(sidecars) - 1
+	}
+
+	if err := setSidecarField(&sidecars[index], field, value, volumes); err != nil {
+		return sidecars, err
+	}
+
+	return sidecars, nil
+}
+
+// setSidecarField sets a field on a sidecar from a label value
+func setSidecarField(sidecar *Sidecar, field string, value string, volumes []Volume) error {
+	if argument, ok := strings.CutPrefix(field, "args."); ok {
+		sidecar.Arguments[argument] = value
+		return nil
+	}
+
+	switch field {
+	case "command":
+		sidecar.Command = value
+	case "image":
+		sidecar.Image = value
+	case "ports.wait":
+		sidecar.WaitPorts = []int{}
+		for _, port := range strings.Split(value, ",") {
+			if port = strings.TrimSpace(port); port == "" {
+				continue
+			}
+
+			p, err := strconv.Atoi(port)
+			if err != nil {
+				return fmt.Errorf("invalid wait port %q for sidecar %s", port, sidecar.Name)
+			}
+			sidecar.WaitPorts = append(sidecar.WaitPorts, p)
+		}
+	case "volumes":
+		sidecar.Volumes = []Volume{}
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+
+			volume, err := parseSidecarVolume(sidecar.Name, entry, volumes)
+			if err != nil {
+				return err
+			}
+			sidecar.Volumes = append(sidecar.Volumes, volume)
+		}
+	}
+
+	return nil
+}
+
+// parseSidecarVolume parses a sidecar volume, which is either a container path or <template-volume>:<container-path>.
+// Container paths matching a VOLUME of the template share that volume, while any other path gets its own volume.
+func parseSidecarVolume(sidecarName string, entry string, volumes []Volume) (Volume, error) {
+	source, containerPath, shared := strings.Cut(entry, ":")
+	if !shared {
+		containerPath = source
+	}
+	if !strings.HasPrefix(containerPath, "/") || !strings.HasPrefix(source, "/") {
+		return Volume{}, fmt.Errorf("invalid volume %s for sidecar %s, paths must be absolute", entry, sidecarName)
+	}
+
+	for _, volume := range volumes {
+		if volume.ContainerPath == source {
+			return Volume{
+				Alias:         volume.Alias,
+				ContainerPath: containerPath,
+			}, nil
+		}
+	}
+	if shared {
+		return Volume{}, fmt.Errorf("invalid volume %s for sidecar %s, %s is not a VOLUME of the template", entry, sidecarName, source)
+	}
+
+	return Volume{
+		Alias:         strings.ToUpper(strings.ReplaceAll(slug.Make(sidecarName+" "+containerPath), "-", "_")),
+		ContainerPath: containerPath,
+	}, nil
+}
+
+// validateSidecar ensures a sidecar declares everything it needs to be created
+func validateSidecar(sidecar Sidecar) error {
+	if sidecar.Image == "" {
+		return fmt.Errorf("missing required label %s.%s.image", string(LABEL_CONFIG_SIDECARS), sidecar.Name)
+	}
+
+	return nil
+}
+
+// SidecarVolumes returns the volumes used only by sidecars, which are not shared with the service container
+func (t ServiceTemplate) SidecarVolumes() []Volume {
+	seen := map[string]bool{}
+	for _, volume := range t.Volumes {
+		seen[volume.Alias] = true
+	}
+
+	volumes := []Volume{}
+	for _, sidecar := range t.Sidecars {
+		for _, volume := range sidecar.Volumes {
+			if seen[volume.Alias] {
+				continue
+			}
+
+			seen[volume.Alias] = true
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes
+}
@@ -0,0 +1,425 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/asottile/dockerfile"
+)
+
+// imageReference matches a reference to the IMAGE argument
+var imageReference = regexp.MustCompile(`\$(\{IMAGE\}|IMAGE\b)`)
+
+// Problem is a mistake found in a service template
+type Problem struct {
+	// File is the path to the file containing the problem, relative to the registry
+	File string `json:"file"`
+
+	// Line is the line of the file containing the problem, or 0 if it applies to the whole file
+	Line int `json:"line"`
+
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+// String formats the problem as file:line: message
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// ValidateInput contains the input parameters for the Validate function
+type ValidateInput struct {
+	// Name is the name of the template folder
+	Name string
+
+	// RegistryPath is the path to the registry containing the template
+	RegistryPath string
+
+	// VendoredRegistryPath is the path the vendored registry is extracted to
+	VendoredRegistryPath string
+}
+
+// Validate checks a service template for mistakes, returning every problem found.
+// Templates without a Dockerfile are validated from their docker-compose.yml instead.
+// An error is only returned if the template file cannot be read.
+func Validate(ctx context.Context, input ValidateInput) ([]Problem, error) {
+	templatePath := filepath.Join(input.RegistryPath, input.Name)
+	fileName := "Dockerfile"
+	if _, err := os.Stat(filepath.Join(templatePath, fileName)); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(templatePath, COMPOSE_FILE)); err == nil {
+			fileName = COMPOSE_FILE
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(templatePath, fileName))
+	if err != nil {
+		return []Problem{}, fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+
+	problems := []Problem{}
+	addProblem := func(line int, format string, a ...any) {
+		problems = append(problems, Problem{
+			File:    filepath.Join(input.Name, fileName),
+			Line:    line,
+			Message: fmt.Sprintf(format, a...),
+		})
+	}
+
+	// problems with directives inherited from an extended template belong to that template
+	addCommandProblem := func(command dockerfile.Command, format string, a ...any) {
+		if command.StartLine != 0 {
+			addProblem(command.StartLine, format, a...)
+		}
+	}
+
+	var commands []dockerfile.Command
+	if fileName == COMPOSE_FILE {
+		var dockerfilePath string
+		commands, dockerfilePath, err = composeCommands(b)
+		if err != nil {
+			addProblem(0, "compose parse error: %s", err.Error())
+			return problems, nil
+		}
+		if dockerfilePath != "" {
+			if _, err := os.Stat(filepath.Join(templatePath, dockerfilePath)); err != nil {
+				addProblem(0, "build dockerfile %s does not exist", dockerfilePath)
+			}
+		}
+	} else {
+		commands, err = dockerfile.ParseReader(bytes.NewReader(b))
+		if err != nil {
+			addProblem(0, "dockerfile parse error: %s", err.Error())
+			return problems, nil
+		}
+	}
+
+	// FROM can only use an IMAGE argument declared in the template's own file
+	imageLine := 0
+	for _, command := range commands {
+		if isArg(command) && len(command.Value) > 0 && strings.SplitN(command.Value[0], "=", 2)[0] == "IMAGE" {
+			imageLine = command.StartLine
+			break
+		}
+	}
+
+	hookPaths := map[string]string{}
+	extended, err := extendTemplate(NewServiceTemplateInput{
+		Name:                 input.Name,
+		RegistryPath:         input.RegistryPath,
+		VendoredRegistryPath: input.VendoredRegistryPath,
+	}, commands, []string{templatePath})
+	if err != nil {
+		line := 0
+		for _, command := range commands {
+			if isLabel(command) && len(command.Value) > 0 && command.Value[0] == string(LABEL_EXTENDS) {
+				line = command.StartLine
+			}
+		}
+		addProblem(line, "failed to extend template: %s", err.Error())
+	} else {
+		commands = extended.commands
+		hookPaths = extended.hookPaths
+	}
+
+	// arguments maps argument names to the line they are declared on
+	arguments := map[string]int{}
+	parsedArguments := []Argument{}
+	parsedVolumes := []Volume{}
+	labels := map[string]dockerfile.Command{}
+	var from *dockerfile.Command
+	for i, command := range commands {
+		switch {
+		case isArg(command):
+			argument, err := parseArg(command)
+			if err != nil {
+				addCommandProblem(command, "invalid ARG directive: %s", err.Error())
+				continue
+			}
+			if _, ok := arguments[argument.Name]; !ok {
+				arguments[argument.Name] = command.StartLine
+				parsedArguments = append(parsedArguments, argument)
+			}
+		case isLabel(command):
+			if err := validateLabel(command); err != nil {
+				addCommandProblem(command, "invalid LABEL directive: %s", err.Error())
+				continue
+			}
+			labels[command.Value[0]] = command
+		case isVolume(command):
+			volume, err := parseVolume(command)
+			if err != nil {
+				addCommandProblem(command, "invalid VOLUME directive: %s", err.Error())
+				continue
+			}
+			parsedVolumes = append(parsedVolumes, volume)
+		case strings.ToUpper(command.Cmd) == "FROM":
+			from = &commands[i]
+		}
+	}
+
+	labelValue := func(label Label) (string, int, bool) {
+		command, ok := labels[string(label)]
+		if !ok {
+			return "", 0, false
+		}
+
+		value, _ := getLabelValue([]dockerfile.Command{command}, string(label))
+		return value, command.StartLine, true
+	}
+
+	// the service image is built from the final stage, which must be based on the IMAGE argument
+	_, hasImage := arguments["IMAGE"]
+	if !hasImage {
+		addProblem(0, "missing required ARG IMAGE")
+	}
+	if from == nil {
+		addProblem(0, "missing FROM directive")
+	} else {
+		if len(from.Value) == 0 || !imageReference.MatchString(from.Value[0]) {
+			addProblem(from.StartLine, "FROM must use the ${IMAGE} argument")
+		}
+		if imageLine > from.StartLine {
+			addProblem(imageLine, "ARG IMAGE must be declared before FROM")
+		} else if hasImage && imageLine == 0 {
+			addProblem(from.StartLine, "ARG IMAGE must be declared before FROM")
+		}
+	}
+
+	for _, label := range []Label{LABEL_NAME, LABEL_DESCRIPTION} {
+		if _, _, ok := labelValue(label); !ok {
+			addProblem(0, "missing required label %s", string(label))
+		}
+	}
+	if name, line, ok := labelValue(LABEL_NAME); ok && name != input.Name {
+		addProblem(line, "template name %s does not match folder %s", name, input.Name)
+	}
+
+	ports := map[Label][]int{}
+	for _, label := range []Label{LABEL_CONFIG_PORTS_EXPOSE, LABEL_CONFIG_PORTS_WAIT} {
+		value, line, _ := labelValue(label)
+		for _, port := range strings.Split(value, ",") {
+			if port == "" {
+				continue
+			}
+
+			p, err := strconv.Atoi(port)
+			if err != nil {
+				addProblem(line, "invalid port %q for label %s", port, string(label))
+				continue
+			}
+			ports[label] = append(ports[label], p)
+		}
+	}
+	for _, port := range ports[LABEL_CONFIG_PORTS_WAIT] {
+		exposed := false
+		for _, exposePort := range ports[LABEL_CONFIG_PORTS_EXPOSE] {
+			exposed = exposed || exposePort == port
+		}
+		if !exposed {
+			_, line, _ := labelValue(LABEL_CONFIG_PORTS_WAIT)
+			addProblem(line, "wait port %d is not listed in %s", port, string(LABEL_CONFIG_PORTS_EXPOSE))
+		}
+	}
+
+	hookLabels := map[Label]string{
+		LABEL_CONFIG_HOOKS_PRE_CREATE:  "pre-create",
+		LABEL_CONFIG_HOOKS_POST_CREATE: "post-create",
+		LABEL_CONFIG_HOOKS_POST_START:  "post-start",
+	}
+	for label, hookName := range hookLabels {
+		value, line, ok := labelValue(label)
+		if !ok {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			addProblem(line, "invalid value %q for label %s", value, string(label))
+			continue
+		}
+		if !enabled {
+			continue
+		}
+
+		if _, ok := hookPaths[hookName]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(templatePath, "bin", hookName)); err != nil {
+			addProblem(line, "%s hook is enabled but bin/%s does not exist", hookName, hookName)
+		}
+	}
+
+	labelKeys := []string{}
+	for key := range labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+
+	for _, key := range labelKeys {
+		if !isArgumentLabel(key) {
+			continue
+		}
+
+		if err := applyArgumentLabel(parsedArguments, labels[key]); err != nil {
+			addCommandProblem(labels[key], "invalid argument label: %s", err.Error())
+		}
+	}
+	for _, argument := range parsedArguments {
+		if err := validateArgumentMetadata(argument); err != nil && arguments[argument.Name] != 0 {
+			addProblem(arguments[argument.Name], "invalid argument metadata: %s", err.Error())
+		}
+	}
+
+	// sidecars are built in the order they are declared, and reported on the label that declares them
+	sidecars := []Sidecar{}
+	sidecarCommands := map[string]dockerfile.Command{}
+	for _, command := range commands {
+		if !isLabel(command) || len(command.Value) == 0 || !isSidecarLabel(command.Value[0]) {
+			continue
+		}
+		if validateLabel(command) != nil {
+			continue
+		}
+
+		name, _, _ := parseSidecarLabel(command.Value[0])
+		if _, ok := sidecarCommands[name]; !ok {
+			sidecarCommands[name] = command
+		}
+		if sidecars, err = applySidecarLabel(sidecars, command, parsedVolumes); err != nil {
+			addCommandProblem(command, "invalid sidecar label: %s", err.Error())
+		}
+	}
+	for _, sidecar := range sidecars {
+		if err := validateSidecar(sidecar); err != nil {
+			addCommandProblem(sidecarCommands[sidecar.Name], "invalid sidecar: %s", err.Error())
+		}
+	}
+
+	// commands are rendered against the service environment, where _SECRET suffixes are stripped,
+	// while exported variables and sidecars also see the raw argument names and the service hostname
+	commandVariables := map[string]bool{}
+	exportedVariables := map[string]bool{"HOSTNAME": true}
+	for name := range arguments {
+		commandVariables[strings.TrimSuffix(name, "_SECRET")] = true
+		exportedVariables[name] = true
+		exportedVariables[strings.TrimSuffix(name, "_SECRET")] = true
+	}
+
+	for _, key := range labelKeys {
+		command := labels[key]
+		value, _ := getLabelValue([]dockerfile.Command{command}, key)
+
+		var available map[string]bool
+		switch {
+		case key == string(LABEL_CONFIG_COMMANDS_ROTATE):
+			// the rotation command also sees the previous value of the rotated argument
+			available = map[string]bool{}
+			for name := range commandVariables {
+				available[name] = true
+				available["PREVIOUS_"+name] = true
+			}
+		case strings.HasPrefix(key, "com.dokku.template.config.commands."):
+			available = commandVariables
+		case isExportedVariable(key), isSidecarLabel(key):
+			available = exportedVariables
+		case isMappedVariable(key):
+			if value != "" && !commandVariables[strings.TrimSuffix(value, "_SECRET")] {
+				addCommandProblem(command, "mapped variable %s is not defined by an ARG", value)
+			}
+			continue
+		default:
+			continue
+		}
+
+		references, err := templateReferences(value)
+		if err != nil {
+			addCommandProblem(command, "invalid template for label %s: %s", key, err.Error())
+			continue
+		}
+		for _, reference := range references {
+			if !available[reference] {
+				addCommandProblem(command, "label %s references {{ .%s }}, which is not defined by an ARG", key, reference)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems, nil
+}
+
+// templateReferences returns the top-level fields referenced by a template, in order of appearance
+func templateReferences(text string) ([]string, error) {
+	tmpl, err := template.New("base").Funcs(sprig.FuncMap()).Parse(text)
+	if err != nil {
+		return []string{}, err
+	}
+
+	references := []string{}
+	seen := map[string]bool{}
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, command := range n.Cmds {
+				walk(command)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			if !seen[n.Ident[0]] {
+				seen[n.Ident[0]] = true
+				references = append(references, n.Ident[0])
+			}
+		}
+	}
+	walk(tmpl.Tree.Root)
+
+	return references, nil
+}
@@ -2,17 +2,13 @@ package volume
 
 import (
 	"context"
-	"dokku-service/logstreamer"
+	"dokku-service/engine"
+	"dokku-service/plan"
 	"dokku-service/template"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
-	"sync"
-
-	"github.com/alexellis/go-execute/v2"
-	"github.com/gosimple/slug"
 )
 
 type CreateInput struct {
@@ -36,80 +32,42 @@ type CreateInput struct {
 }
 
 func Create(ctx context.Context, input CreateInput) (v Volume, err error) {
+	v = Resolve(ResolveInput{
+		DataRoot:         input.DataRoot,
+		ServiceName:      input.ServiceName,
+		Template:         input.Template,
+		VolumeDescriptor: input.VolumeDescriptor,
+		UseVolumes:       input.UseVolumes,
+	})
+
 	if !input.UseVolumes {
-		serviceRoot := fmt.Sprintf("%s/%s/%s", input.DataRoot, input.Template.Name, input.ServiceName)
-		source := fmt.Sprintf("%s/%s", serviceRoot, input.VolumeDescriptor.Alias)
-		mountType := "bind"
-		if err := os.MkdirAll(filepath.Clean(source), os.ModePerm); err != nil {
+		if err := plan.MkdirAll(ctx, filepath.Clean(v.Source), os.ModePerm); err != nil {
 			return Volume{}, errors.New("could not create volume host dir")
 		}
 
-		return Volume{
-			Alias:         input.VolumeDescriptor.Alias,
-			ContainerPath: input.VolumeDescriptor.ContainerPath,
-			MountType:     mountType,
-			Source:        source,
-			MountArgs:     fmt.Sprintf("type=%s,source=%s,destination=%s", mountType, source, input.VolumeDescriptor.ContainerPath),
-		}, nil
+		return v, nil
 	}
 
-	volumeName := fmt.Sprintf("dokku.%s.%s.%s", input.Template.Name, input.ServiceName, slug.Make(input.VolumeDescriptor.Alias))
-	mountType := "volume"
 	if ok, err := Exists(ctx, ExistsInput{
-		Name:  volumeName,
+		Name:  v.Source,
 		Trace: input.Trace,
 	}); ok && err == nil {
-		return Volume{
-			Alias:         input.VolumeDescriptor.Alias,
-			ContainerPath: input.VolumeDescriptor.ContainerPath,
-			MountType:     "volume",
-			Source:        volumeName,
-			MountArgs:     fmt.Sprintf("type=%s,source=%s,destination=%s", mountType, volumeName, input.VolumeDescriptor.ContainerPath),
-		}, nil
+		return v, nil
 	}
 
-	var mu sync.Mutex
-	cmd := execute.ExecTask{
-		Command: "docker",
-		Args: []string{
-			"volume", "create",
-			"--label=org.label-schema.schema-version=1.0",
-			"--label=org.label-schema.vendor=dokku",
-			fmt.Sprintf("--label=com.dokku.service-name=%s", input.ServiceName),
-			fmt.Sprintf("--label=com.dokku.service-type=%s", input.Template.Name),
-			fmt.Sprintf("--label=com.dokku.service-container-path=%s", input.VolumeDescriptor.ContainerPath),
-			fmt.Sprintf("--label=com.dokku.service-alias=%s", input.VolumeDescriptor.Alias),
-			volumeName,
-		},
-		StreamStdio: false,
-		StdOutWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stdout,
-		}),
-		StdErrWriter: logstreamer.NewLogstreamer(logstreamer.NewLogstreamerInput{
-			Mutex:  &mu,
-			Writer: os.Stderr,
-		}),
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
-	if err != nil {
-		return Volume{}, err
+	labels := map[string]string{
+		"org.label-schema.schema-version":  "1.0",
+		"org.label-schema.vendor":          "dokku",
+		"com.dokku.service-name":           input.ServiceName,
+		"com.dokku.service-type":           input.Template.Name,
+		"com.dokku.service-container-path": input.VolumeDescriptor.ContainerPath,
+		"com.dokku.service-alias":          input.VolumeDescriptor.Alias,
 	}
 
-	if res.ExitCode != 0 {
-		// todo: return exit code
-		return Volume{}, fmt.Errorf("non-zero exit code %d: %s", res.ExitCode, res.Stderr)
+	engine.Trace(input.Trace, "volume create", v.Source)
+	if err := engine.FromContext(ctx).VolumeCreate(ctx, v.Source, labels); err != nil {
+		return Volume{}, fmt.Errorf("volume create for service failed: %w", err)
 	}
 
-	return Volume{
-		Alias:         input.VolumeDescriptor.Alias,
-		ContainerPath: input.VolumeDescriptor.ContainerPath,
-		MountType:     mountType,
-		Source:        volumeName,
-		MountArgs:     fmt.Sprintf("type=%s,source=%s,destination=%s", mountType, volumeName, input.VolumeDescriptor.ContainerPath),
-	}, nil
+	return v, nil
 }
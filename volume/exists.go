@@ -2,11 +2,8 @@ package volume
 
 import (
 	"context"
+	"dokku-service/engine"
 	"fmt"
-	"os"
-	"strings"
-
-	"github.com/alexellis/go-execute/v2"
 )
 
 // ExistsInput contains the input parameters for the Exists function
@@ -20,26 +17,11 @@ type ExistsInput struct {
 
 // Exists checks if a volume exists
 func Exists(ctx context.Context, input ExistsInput) (bool, error) {
-	cmd := execute.ExecTask{
-		Command: "docker",
-		Args: []string{
-			"volume", "inspect",
-			input.Name,
-		},
-		StreamStdio: false,
-	}
-
-	if input.Trace {
-		fmt.Fprintln(os.Stderr, "exec: ", cmd.Command, strings.Join(cmd.Args, " "))
-	}
-	res, err := cmd.Execute(ctx)
+	engine.Trace(input.Trace, "volume inspect", input.Name)
+	exists, err := engine.FromContext(ctx).VolumeExists(ctx, input.Name)
 	if err != nil {
 		return false, fmt.Errorf("check for volume existence failed: %w", err)
 	}
 
-	if res.ExitCode != 0 {
-		return false, nil
-	}
-
-	return true, nil
+	return exists, nil
 }
@@ -0,0 +1,26 @@
+package volume
+
+import (
+	"context"
+	"dokku-service/engine"
+	"fmt"
+)
+
+// RemoveInput contains the input parameters for the Remove function
+type RemoveInput struct {
+	// Name of the volume to remove
+	Name string
+
+	// Trace controls whether to print the command being executed
+	Trace bool
+}
+
+// Remove removes a named volume
+func Remove(ctx context.Context, input RemoveInput) error {
+	engine.Trace(input.Trace, "volume rm", input.Name)
+	if err := engine.FromContext(ctx).VolumeRemove(ctx, input.Name); err != nil {
+		return fmt.Errorf("failed to remove volume: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package volume
+
+import (
+	"dokku-service/template"
+	"fmt"
+
+	"github.com/gosimple/slug"
+)
+
+// ResolveInput contains the input parameters for the Resolve function
+type ResolveInput struct {
+	// DataRoot specifies the root directory for the service data
+	DataRoot string
+
+	// ServiceName specifies the name of the service
+	ServiceName string
+
+	// Template specifies the service template
+	Template template.ServiceTemplate
+
+	// VolumeDescriptor specifies the volume descriptor
+	VolumeDescriptor template.Volume
+
+	// UseVolumes specifies whether to use volumes
+	UseVolumes bool
+}
+
+// Resolve returns the volume for a volume descriptor without creating it
+func Resolve(input ResolveInput) Volume {
+	mountType := "bind"
+	source := fmt.Sprintf("%s/%s/%s/%s", input.DataRoot, input.Template.Name, input.ServiceName, input.VolumeDescriptor.Alias)
+	if input.UseVolumes {
+		mountType = "volume"
+		source = fmt.Sprintf("dokku.%s.%s.%s", input.Template.Name, input.ServiceName, slug.Make(input.VolumeDescriptor.Alias))
+	}
+
+	return Volume{
+		Alias:         input.VolumeDescriptor.Alias,
+		ContainerPath: input.VolumeDescriptor.ContainerPath,
+		MountType:     mountType,
+		Source:        source,
+	}
+}
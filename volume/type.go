@@ -5,5 +5,4 @@ type Volume struct {
 	Source        string
 	MountType     string
 	ContainerPath string
-	MountArgs     string
 }